@@ -1,19 +1,46 @@
 package main
 
 import (
+	"context"
 	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
 
-	amqp "github.com/rabbitmq/amqp091-go"
+	"github.com/rgoncalvesrr/fullcycle-clean-arch/configs"
 	"github.com/rgoncalvesrr/fullcycle-clean-arch/pkg/rabbitmq"
 )
 
+const queueName = "minhafila"
+
+// declareQueue binds the queue to amq.direct, where the producer publishes.
+func declareQueue(ch rabbitmq.AMQPChannel) error {
+	if _, err := ch.QueueDeclare(queueName, true, false, false, false, nil); err != nil {
+		return err
+	}
+
+	return ch.QueueBind(queueName, "", "amq.direct", false, nil)
+}
+
 func main() {
-	ch := rabbitmq.OpenChannel()
-	defer ch.Close()
+	cfg, err := configs.LoadConfig(".").RabbitMQ()
+	if err != nil {
+		panic(err)
+	}
+
+	conn, err := rabbitmq.Dial(cfg, declareQueue)
+	if err != nil {
+		panic(err)
+	}
+	defer conn.Close()
 
-	msgs := make(chan amqp.Delivery)
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
-	go rabbitmq.Consume(ch, "minhafila", msgs)
+	msgs, err := conn.Consume(ctx, queueName, "consumer")
+	if err != nil {
+		panic(err)
+	}
 
 	for msg := range msgs {
 		fmt.Println(string(msg.Body))
@@ -3,12 +3,28 @@ package main
 import (
 	"context"
 
+	amqp "github.com/rabbitmq/amqp091-go"
+	"github.com/rgoncalvesrr/fullcycle-clean-arch/configs"
 	"github.com/rgoncalvesrr/fullcycle-clean-arch/pkg/rabbitmq"
 )
 
 func main() {
-	ch := rabbitmq.OpenChannel()
-	defer ch.Close()
+	cfg, err := configs.LoadConfig(".").RabbitMQ()
+	if err != nil {
+		panic(err)
+	}
 
-	rabbitmq.Publish(context.Background(), ch, "amq.direct", "Publicado via GO")
+	conn, err := rabbitmq.Dial(cfg)
+	if err != nil {
+		panic(err)
+	}
+	defer conn.Close()
+
+	err = conn.Publish(context.Background(), "amq.direct", "", amqp.Publishing{
+		ContentType: "text/plain",
+		Body:        []byte("Publicado via GO"),
+	})
+	if err != nil {
+		panic(err)
+	}
 }
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"log"
+
+	"github.com/rgoncalvesrr/fullcycle-clean-arch/configs"
+	"github.com/rgoncalvesrr/fullcycle-clean-arch/internal/infra/database"
+)
+
+// Rebuilds the product full-text index from the products table, in the
+// database set in the .env of the working directory.
+func main() {
+	db, err := configs.LoadConfig(".").Database()
+	if err != nil {
+		panic(err)
+	}
+	if err = database.Migrate(db); err != nil {
+		panic(err)
+	}
+
+	searchIndex, err := database.NewProductSearchIndex(db)
+	if err != nil {
+		panic(err)
+	}
+
+	if err = searchIndex.Setup(db); err != nil {
+		panic(err)
+	}
+
+	productGateway := database.NewProductGateway(db)
+	productGateway.SearchIndex = searchIndex
+
+	if err = productGateway.Reindex(); err != nil {
+		panic(err)
+	}
+
+	log.Println("product search index rebuilt")
+}
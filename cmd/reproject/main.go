@@ -0,0 +1,26 @@
+package main
+
+import (
+	"log"
+
+	"github.com/rgoncalvesrr/fullcycle-clean-arch/configs"
+	"github.com/rgoncalvesrr/fullcycle-clean-arch/internal/infra/database"
+)
+
+// Rebuilds the orders tables from the orders' event streams, in the database
+// set in the .env of the working directory.
+func main() {
+	db, err := configs.LoadConfig(".").Database()
+	if err != nil {
+		panic(err)
+	}
+	if err = database.Migrate(db); err != nil {
+		panic(err)
+	}
+
+	if err = database.NewOrderGateway(db).RebuildProjection(); err != nil {
+		panic(err)
+	}
+
+	log.Println("order projection rebuilt")
+}
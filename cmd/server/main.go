@@ -1,21 +1,31 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/playground"
 	"github.com/go-chi/chi"
 	"github.com/go-chi/chi/middleware"
 	"github.com/go-chi/jwtauth"
 	"github.com/rgoncalvesrr/fullcycle-clean-arch/configs"
 	_ "github.com/rgoncalvesrr/fullcycle-clean-arch/docs"
+	"github.com/rgoncalvesrr/fullcycle-clean-arch/graph"
 	"github.com/rgoncalvesrr/fullcycle-clean-arch/internal/entity"
+	"github.com/rgoncalvesrr/fullcycle-clean-arch/internal/event"
 	"github.com/rgoncalvesrr/fullcycle-clean-arch/internal/infra/database"
 	"github.com/rgoncalvesrr/fullcycle-clean-arch/internal/infra/webserver/handlers"
+	"github.com/rgoncalvesrr/fullcycle-clean-arch/pkg/events"
+	"github.com/rgoncalvesrr/fullcycle-clean-arch/pkg/rabbitmq"
 	httpSwagger "github.com/swaggo/http-swagger/v2"
-	"gorm.io/driver/sqlite"
-	"gorm.io/gorm"
 )
 
 //	@title			Go Expert API Example
@@ -35,14 +45,82 @@ import (
 func main() {
 	cfg := configs.LoadConfig(".")
 
-	db, err := gorm.Open(sqlite.Open("teste.db"), &gorm.Config{})
+	db, err := cfg.Database()
 	if err != nil {
 		panic(err)
 	}
-	db.AutoMigrate(&entity.User{}, &entity.Product{})
+	if err = database.Migrate(db); err != nil {
+		panic(err)
+	}
 
 	productGateway := database.NewProductGateway(db)
-	productHandler := handlers.NewProductHandler(productGateway)
+
+	searchIndex, err := database.NewProductSearchIndex(db)
+	if err == nil {
+		err = searchIndex.Setup(db)
+	}
+	if err != nil {
+		log.Println("product search disabled:", err)
+	} else {
+		productGateway.SearchIndex = searchIndex
+	}
+
+	categoryGateway := database.NewCategoryGateway(db)
+
+	eventDispatcher, err := newEventDispatcher(cfg.EventWorkers, cfg.EventQueueSize, cfg.EventBackpressure)
+	if err != nil {
+		panic(err)
+	}
+
+	eventDispatcher.Use(events.Logging(nil), events.Recover(nil))
+	if cfg.EventHandlerTimeout > 0 {
+		eventDispatcher.Use(events.Timeout(cfg.EventHandlerTimeout))
+	}
+
+	// Every dispatched event is kept, so projections can be rebuilt from
+	// history with events.Replay and events.CatchUp. Handlers and resolvers
+	// store their events in the transaction of the change and publish them
+	// through it once committed; only subscriptions go to eventDispatcher.
+	recordingDispatcher := events.NewRecordingDispatcher(eventDispatcher, database.NewEventStoreGateway(db))
+
+	categoryHandler := handlers.NewCategoryHandler(categoryGateway, recordingDispatcher)
+	productHandler := handlers.NewProductHandler(productGateway, categoryGateway, recordingDispatcher)
+
+	deadLetters := events.NewDeadLetterQueue(database.NewDeadLetterGateway(db))
+	deadLetterHandler := handlers.NewDeadLetterHandler(deadLetters)
+
+	rabbitCfg, err := cfg.RabbitMQ()
+	if err != nil {
+		panic(err)
+	}
+
+	subscriptions, broker := eventSubscriptions(rabbitCfg, cfg.RabbitMQEventsExchange)
+	if broker != nil {
+		defer broker.Close()
+	}
+
+	retryPolicy := events.RetryPolicy{
+		MaxAttempts:    cfg.EventRetryAttempts,
+		InitialBackoff: cfg.EventRetryBackoff,
+		MaxBackoff:     cfg.EventRetryMaxBackoff,
+		Jitter:         0.2,
+	}
+
+	if err = event.Subscribe(eventDispatcher, deadLetters, retryPolicy, subscriptions...); err != nil {
+		panic(err)
+	}
+
+	taxPolicy, err := loadTaxPolicy(cfg.TaxRulesFile)
+	if err != nil {
+		panic(err)
+	}
+	taxPolicy.Ancestors = categoryGateway.AncestorIDs
+
+	orderGateway := database.NewOrderGateway(db)
+	orderHandler := handlers.NewOrderHandler(productGateway, orderGateway, taxPolicy, recordingDispatcher)
+
+	idempotencyKeyGateway := database.NewIdempotencyKeyGateway(db)
+	idempotencyHandler := handlers.NewIdempotencyHandler(idempotencyKeyGateway, cfg.IdempotencyKeyTTL)
 
 	userGateway := database.NewUserGateway(db)
 	userHandler := handlers.NewUserHandler(userGateway, cfg.TokenAuth, cfg.JWTExpiresIn)
@@ -55,11 +133,79 @@ func main() {
 	r.Route("/products", func(r chi.Router) {
 		r.Use(jwtauth.Verifier(cfg.TokenAuth)) // verificação do token JWT
 		r.Use(jwtauth.Authenticator)           // validação do token
+		r.Use(idempotencyHandler.Middleware)
 		r.Get("/", productHandler.GetProducts)
+		r.Get("/search", productHandler.SearchProducts)
+		r.Get("/trash", productHandler.GetTrash)
+		r.Get("/export", productHandler.ExportProducts)
+		r.Post("/import", productHandler.ImportProducts)
 		r.Get("/{id}", productHandler.GetProduct)
+		r.Get("/{id}/history", productHandler.GetProductHistory)
 		r.Post("/", productHandler.CreateProduct)
+		r.Put("/{id}", productHandler.ReplaceProduct)
 		r.Patch("/{id}", productHandler.UpdateProduct)
 		r.Delete("/{id}", productHandler.DeleteProduct)
+		r.Post("/{id}/restore", productHandler.RestoreProduct)
+	})
+
+	r.Group(func(r chi.Router) {
+		r.Use(jwtauth.Verifier(cfg.TokenAuth))
+		r.Use(jwtauth.Authenticator)
+		r.Use(idempotencyHandler.Middleware)
+		r.Post("/products:batch", productHandler.BatchProducts)
+	})
+
+	r.Route("/categories", func(r chi.Router) {
+		r.Use(jwtauth.Verifier(cfg.TokenAuth))
+		r.Use(jwtauth.Authenticator)
+		r.Use(idempotencyHandler.Middleware)
+		r.Get("/", categoryHandler.GetCategories)
+		r.Get("/{id}", categoryHandler.GetCategory)
+		r.Get("/{id}/descendants", categoryHandler.GetCategoryDescendants)
+		r.Post("/", categoryHandler.CreateCategory)
+		r.Put("/{id}", categoryHandler.UpdateCategory)
+		r.Delete("/{id}", categoryHandler.DeleteCategory)
+	})
+
+	r.Route("/order", func(r chi.Router) {
+		r.Use(jwtauth.Verifier(cfg.TokenAuth))
+		r.Use(jwtauth.Authenticator)
+		r.Use(idempotencyHandler.Middleware)
+		r.Get("/", orderHandler.ListOrders)
+		r.Get("/{id}", orderHandler.GetOrder)
+		r.Post("/", orderHandler.CreateOrder)
+		r.Post("/{id}/pay", orderHandler.PayOrder)
+		r.Post("/{id}/ship", orderHandler.ShipOrder)
+		r.Post("/{id}/deliver", orderHandler.DeliverOrder)
+		r.Post("/{id}/cancel", orderHandler.CancelOrder)
+		r.Post("/{id}/refund", orderHandler.RefundOrder)
+	})
+
+	r.Route("/dead-letters", func(r chi.Router) {
+		r.Use(jwtauth.Verifier(cfg.TokenAuth))
+		r.Use(jwtauth.Authenticator)
+		r.Get("/", deadLetterHandler.ListDeadLetters)
+		r.Get("/{id}", deadLetterHandler.GetDeadLetter)
+		r.Post("/{id}/replay", deadLetterHandler.ReplayDeadLetter)
+		r.Delete("/{id}", deadLetterHandler.DiscardDeadLetter)
+	})
+
+	graphqlServer := handler.NewDefaultServer(graph.NewExecutableSchema(graph.Config{
+		Resolvers: &graph.Resolver{
+			ProductGateway:  productGateway,
+			CategoryGateway: categoryGateway,
+			OrderGateway:    orderGateway,
+			TaxPolicy:       taxPolicy,
+			EventDispatcher: recordingDispatcher,
+		},
+	}))
+
+	r.Get("/playground", playground.Handler("GraphQL playground", "/query"))
+	r.Group(func(r chi.Router) {
+		r.Use(jwtauth.Verifier(cfg.TokenAuth))
+		r.Use(jwtauth.Authenticator)
+		r.Use(idempotencyHandler.Middleware)
+		r.Handle("/query", graphqlServer)
 	})
 
 	r.Post("/users", userHandler.CreateUser)
@@ -69,7 +215,34 @@ func main() {
 	r.Get("/docs/*", httpSwagger.Handler(httpSwagger.URL(fmt.Sprintf("http://localhost:%s/docs/doc.json", cfg.WebServerPort))))
 	// })
 
-	http.ListenAndServe(fmt.Sprintf(":%s", cfg.WebServerPort), r)
+	go purgeDeletedProducts(productGateway, cfg.ProductTrashRetention, cfg.ProductPurgeInterval)
+	go purgeExpiredIdempotencyKeys(idempotencyKeyGateway, time.Hour)
+
+	server := &http.Server{Addr: fmt.Sprintf(":%s", cfg.WebServerPort), Handler: r}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	go func() {
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Println("server failed:", err)
+			stop()
+		}
+	}()
+
+	<-ctx.Done()
+
+	// Finish the requests in flight, then the events they raised.
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		log.Println("server shutdown failed:", err)
+	}
+
+	if err := eventDispatcher.Shutdown(shutdownCtx); err != nil {
+		log.Println("event dispatcher shutdown failed:", err)
+	}
 }
 
 func LogRequest(next http.Handler) http.Handler {
@@ -79,3 +252,103 @@ func LogRequest(next http.Handler) http.Handler {
 		next.ServeHTTP(w, r)
 	})
 }
+
+// newEventDispatcher handles events in the background when workers is above
+// zero, and before Dispatch returns otherwise.
+func newEventDispatcher(workers, queueSize int, backpressure string) (*events.EventDispatcher, error) {
+	if workers <= 0 {
+		return events.NewEventDispatcher(), nil
+	}
+
+	policy, err := events.ParseBackpressurePolicy(backpressure)
+	if err != nil {
+		return nil, err
+	}
+
+	return events.NewAsyncEventDispatcher(events.AsyncOptions{
+		Workers:      workers,
+		QueueSize:    queueSize,
+		Backpressure: policy,
+	}), nil
+}
+
+// eventSubscriptions lists the handlers of the server's events: publishing
+// them to RabbitMQ, when an exchange is configured and the broker is
+// reachable. The returned connection must be closed once the dispatcher is
+// shut down.
+func eventSubscriptions(rabbitCfg rabbitmq.Config, exchange string) ([]event.Subscription, *rabbitmq.Connection) {
+	if exchange == "" {
+		return nil, nil
+	}
+
+	conn, err := rabbitmq.Dial(rabbitCfg, rabbitmq.DeclareExchange(exchange))
+	if err != nil {
+		log.Println("publishing events to RabbitMQ disabled:", err)
+		return nil, nil
+	}
+
+	return []event.Subscription{{
+		Name:    "rabbitmq",
+		Pattern: "#",
+		Handler: rabbitmq.NewEventPublisher(conn, exchange),
+	}}, conn
+}
+
+// loadTaxPolicy reads the tax rules charged on orders from path. Without a
+// file nothing is taxed.
+func loadTaxPolicy(path string) (*entity.RuleTaxPolicy, error) {
+	if path == "" {
+		return entity.NewRuleTaxPolicy(nil, entity.RoundHalfUp)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return entity.ParseRuleTaxPolicy(data)
+}
+
+// purgeDeletedProducts periodically removes products that have been in the
+// trash for longer than retention.
+func purgeDeletedProducts(gateway database.ProductInterface, retention, interval time.Duration) {
+	if retention <= 0 {
+		return
+	}
+
+	if interval <= 0 {
+		interval = time.Hour
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		n, err := gateway.Purge(time.Now().Add(-retention))
+		if err != nil {
+			log.Println("product purge failed:", err)
+		} else if n > 0 {
+			log.Printf("purged %d deleted products", n)
+		}
+
+		<-ticker.C
+	}
+}
+
+// purgeExpiredIdempotencyKeys periodically removes idempotency keys whose
+// responses are no longer replayed.
+func purgeExpiredIdempotencyKeys(gateway database.IdempotencyKeyInterface, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		n, err := gateway.Purge(time.Now())
+		if err != nil {
+			log.Println("idempotency key purge failed:", err)
+		} else if n > 0 {
+			log.Printf("purged %d expired idempotency keys", n)
+		}
+
+		<-ticker.C
+	}
+}
@@ -1,10 +1,18 @@
 package configs
 
 import (
+	"time"
+
 	"github.com/go-chi/jwtauth"
+	"github.com/rgoncalvesrr/fullcycle-clean-arch/pkg/rabbitmq"
 	"github.com/spf13/viper"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
 )
 
+// defaultDBFile is the SQLite database used when DB_FILE is empty.
+const defaultDBFile = "teste.db"
+
 type conf struct {
 	DBDriver      string `mapstructure:"DB_DRIVER"`
 	DBHost        string `mapstructure:"DB_HOST"`
@@ -12,10 +20,49 @@ type conf struct {
 	DBUser        string `mapstructure:"DB_USER"`
 	DBPassword    string `mapstructure:"DB_PASSWORD"`
 	DBName        string `mapstructure:"DB_NAME"`
+	DBFile        string `mapstructure:"DB_FILE"`
 	WebServerPort string `mapstructure:"WEB_SERVER_PORT"`
 	JWTSecret     string `mapstructure:"JWT_SECRET"`
 	JWTExpiresIn  int    `mapstructure:"JWT_EXPIRES_IN"`
-	TokenAuth     *jwtauth.JWTAuth
+	// Deleted products are purged once they have been in the trash for
+	// longer than ProductTrashRetention; zero keeps them forever.
+	ProductTrashRetention time.Duration `mapstructure:"PRODUCT_TRASH_RETENTION"`
+	ProductPurgeInterval  time.Duration `mapstructure:"PRODUCT_PURGE_INTERVAL"`
+	// Responses to requests with an Idempotency-Key are replayed for
+	// IdempotencyKeyTTL.
+	IdempotencyKeyTTL time.Duration `mapstructure:"IDEMPOTENCY_KEY_TTL"`
+	// TaxRulesFile is a JSON file with the tax rules charged on orders;
+	// without it orders are untaxed.
+	TaxRulesFile string `mapstructure:"TAX_RULES_FILE"`
+	// With EventWorkers above zero events are handled in the background by
+	// that many workers, each queueing up to EventQueueSize events; when a
+	// queue is full EventBackpressure (block, drop or error) applies.
+	EventWorkers      int    `mapstructure:"EVENT_WORKERS"`
+	EventQueueSize    int    `mapstructure:"EVENT_QUEUE_SIZE"`
+	EventBackpressure string `mapstructure:"EVENT_BACKPRESSURE"`
+	// EventHandlerTimeout bounds how long each event handler may run; zero
+	// means no limit.
+	EventHandlerTimeout time.Duration `mapstructure:"EVENT_HANDLER_TIMEOUT"`
+	// Subscribed handlers are tried up to EventRetryAttempts times, waiting
+	// from EventRetryBackoff up to EventRetryMaxBackoff in between; events
+	// still failing go to the dead letter queue.
+	EventRetryAttempts   int           `mapstructure:"EVENT_RETRY_ATTEMPTS"`
+	EventRetryBackoff    time.Duration `mapstructure:"EVENT_RETRY_BACKOFF"`
+	EventRetryMaxBackoff time.Duration `mapstructure:"EVENT_RETRY_MAX_BACKOFF"`
+	// RabbitMQ broker; VHost overrides the one in the URL. The TLS files
+	// (PEM) are used with amqps URLs. Lost connections are retried after
+	// RabbitMQReconnectBackoff, doubling up to RabbitMQReconnectMaxBackoff.
+	RabbitMQURL                 string        `mapstructure:"RABBITMQ_URL"`
+	RabbitMQVHost               string        `mapstructure:"RABBITMQ_VHOST"`
+	RabbitMQTLSCAFile           string        `mapstructure:"RABBITMQ_TLS_CA_FILE"`
+	RabbitMQTLSCertFile         string        `mapstructure:"RABBITMQ_TLS_CERT_FILE"`
+	RabbitMQTLSKeyFile          string        `mapstructure:"RABBITMQ_TLS_KEY_FILE"`
+	RabbitMQReconnectBackoff    time.Duration `mapstructure:"RABBITMQ_RECONNECT_BACKOFF"`
+	RabbitMQReconnectMaxBackoff time.Duration `mapstructure:"RABBITMQ_RECONNECT_MAX_BACKOFF"`
+	// RabbitMQEventsExchange is where the server publishes its events;
+	// empty disables publishing.
+	RabbitMQEventsExchange string `mapstructure:"RABBITMQ_EVENTS_EXCHANGE"`
+	TokenAuth              *jwtauth.JWTAuth
 }
 
 func LoadConfig(path string) *conf {
@@ -41,3 +88,30 @@ func LoadConfig(path string) *conf {
 
 	return cfg
 }
+
+// Database opens the SQLite database in DBFile, relative to the working
+// directory, that the server and the maintenance commands share.
+func (c *conf) Database() (*gorm.DB, error) {
+	file := c.DBFile
+	if file == "" {
+		file = defaultDBFile
+	}
+
+	return gorm.Open(sqlite.Open(file), &gorm.Config{})
+}
+
+// RabbitMQ is the broker configuration, its TLS files loaded.
+func (c *conf) RabbitMQ() (rabbitmq.Config, error) {
+	tlsConfig, err := rabbitmq.LoadTLSConfig(c.RabbitMQTLSCAFile, c.RabbitMQTLSCertFile, c.RabbitMQTLSKeyFile)
+	if err != nil {
+		return rabbitmq.Config{}, err
+	}
+
+	return rabbitmq.Config{
+		URL:            c.RabbitMQURL,
+		VHost:          c.RabbitMQVHost,
+		TLS:            tlsConfig,
+		InitialBackoff: c.RabbitMQReconnectBackoff,
+		MaxBackoff:     c.RabbitMQReconnectMaxBackoff,
+	}, nil
+}
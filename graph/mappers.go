@@ -0,0 +1,139 @@
+package graph
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/rgoncalvesrr/fullcycle-clean-arch/graph/model"
+	"github.com/rgoncalvesrr/fullcycle-clean-arch/internal/dto"
+	"github.com/rgoncalvesrr/fullcycle-clean-arch/internal/entity"
+	pkgEntity "github.com/rgoncalvesrr/fullcycle-clean-arch/pkg/entity"
+	"gorm.io/gorm"
+)
+
+func toProductModel(p *entity.Product) *model.Product {
+	return &model.Product{
+		ID:          p.ID.String(),
+		Sku:         p.SKU,
+		Name:        p.Name,
+		Description: p.Description,
+		CategoryID:  optionalID(p.CategoryID),
+		Price:       toMoneyModel(p.Price),
+		Stock:       p.Stock,
+		Status:      model.ProductStatus(strings.ToUpper(string(p.Status))),
+		Version:     p.Version,
+		CreatedAt:   p.CreatedAt,
+		UpdatedAt:   p.UpdatedAt,
+	}
+}
+
+func toProductStatus(s model.ProductStatus) entity.ProductStatus {
+	return entity.ProductStatus(strings.ToLower(string(s)))
+}
+
+func toMoneyModel(m entity.Money) *model.Money {
+	return &model.Money{
+		Amount:   m.String(),
+		Currency: m.Currency,
+	}
+}
+
+func parseMoneyArg(amount *string, currency string) (*entity.Money, error) {
+	if amount == nil {
+		return nil, nil
+	}
+
+	m, err := entity.ParseMoney(*amount, currency)
+	if err != nil {
+		return nil, err
+	}
+
+	return &m, nil
+}
+
+func toCategoryModel(c *entity.Category) *model.Category {
+	var description *string
+	if c.Description != "" {
+		description = &c.Description
+	}
+
+	return &model.Category{
+		ID:          c.ID.String(),
+		Name:        c.Name,
+		Description: description,
+		ParentID:    optionalID(c.ParentID),
+	}
+}
+
+// toCategoryPayload is the payload of category events, shaped as the REST
+// API's category.
+func toCategoryPayload(c *entity.Category) *dto.CategoryOutput {
+	return &dto.CategoryOutput{
+		ID:          c.ID.String(),
+		ParentID:    optionalID(c.ParentID),
+		Name:        c.Name,
+		Description: c.Description,
+		CreatedAt:   c.CreatedAt,
+		UpdatedAt:   c.UpdatedAt,
+	}
+}
+
+func toCategoryModels(categories []entity.Category) []*model.Category {
+	result := make([]*model.Category, 0, len(categories))
+	for i := range categories {
+		result = append(result, toCategoryModel(&categories[i]))
+	}
+
+	return result
+}
+
+// findCategory looks a category up by id, resolving to null when it doesn't
+// exist.
+func (r *Resolver) findCategory(id string) (*model.Category, error) {
+	category, err := r.CategoryGateway.FindByID(id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return toCategoryModel(category), nil
+}
+
+func optionalID(id *pkgEntity.ID) *string {
+	if id == nil {
+		return nil
+	}
+
+	s := id.String()
+	return &s
+}
+
+func toOrderModel(o *dto.OrderOutput) *model.Order {
+	items := make([]*model.OrderItem, 0, len(o.Items))
+	for _, item := range o.Items {
+		items = append(items, &model.OrderItem{
+			ID:        item.ID,
+			ProductID: item.ProductID,
+			Sku:       item.SKU,
+			Name:      item.Name,
+			Quantity:  item.Quantity,
+			UnitPrice: &model.Money{Amount: item.UnitPrice.Amount, Currency: item.UnitPrice.Currency},
+			Total:     &model.Money{Amount: item.Total.Amount, Currency: item.Total.Currency},
+			TaxRate:   item.TaxRate,
+			Tax:       &model.Money{Amount: item.Tax.Amount, Currency: item.Tax.Currency},
+		})
+	}
+
+	return &model.Order{
+		ID:        o.ID,
+		Items:     items,
+		Region:    o.Region,
+		Subtotal:  &model.Money{Amount: o.Subtotal.Amount, Currency: o.Subtotal.Currency},
+		Tax:       &model.Money{Amount: o.Tax.Amount, Currency: o.Tax.Currency},
+		Total:     &model.Money{Amount: o.Total.Amount, Currency: o.Total.Currency},
+		Status:    model.OrderStatus(strings.ToUpper(o.Status)),
+		CreatedAt: o.CreatedAt,
+	}
+}
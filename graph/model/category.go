@@ -0,0 +1,10 @@
+package model
+
+// Category is bound in gqlgen.yml so parent, children and products are
+// resolved on demand from ParentID and ID.
+type Category struct {
+	ID          string  `json:"id"`
+	Name        string  `json:"name"`
+	Description *string `json:"description,omitempty"`
+	ParentID    *string `json:"-"`
+}
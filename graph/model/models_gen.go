@@ -2,18 +2,16 @@
 
 package model
 
-type Category struct {
-	ID          string    `json:"id"`
-	Name        string    `json:"name"`
-	Description *string   `json:"description,omitempty"`
-	Courses     []*Course `json:"courses"`
-}
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+)
 
-type Course struct {
-	ID          string    `json:"id"`
-	Name        string    `json:"name"`
-	Description *string   `json:"description,omitempty"`
-	Category    *Category `json:"category"`
+type Money struct {
+	Amount   string `json:"amount"`
+	Currency string `json:"currency"`
 }
 
 type Mutation struct {
@@ -22,13 +20,158 @@ type Mutation struct {
 type NewCategory struct {
 	Name        string  `json:"name"`
 	Description *string `json:"description,omitempty"`
+	ParentID    *string `json:"parentId,omitempty"`
 }
 
-type NewCourse struct {
-	Name        string  `json:"name"`
-	Description *string `json:"description,omitempty"`
-	CategoryID  string  `json:"categoryId"`
+type NewOrder struct {
+	Region *string         `json:"region,omitempty"`
+	Items  []*NewOrderItem `json:"items"`
+}
+
+type NewOrderItem struct {
+	ProductID string `json:"productId"`
+	Quantity  int    `json:"quantity"`
+}
+
+type Order struct {
+	ID        string       `json:"id"`
+	Items     []*OrderItem `json:"items"`
+	Region    string       `json:"region"`
+	Subtotal  *Money       `json:"subtotal"`
+	Tax       *Money       `json:"tax"`
+	Total     *Money       `json:"total"`
+	Status    OrderStatus  `json:"status"`
+	CreatedAt time.Time    `json:"createdAt"`
+}
+
+type OrderItem struct {
+	ID        string `json:"id"`
+	ProductID string `json:"productId"`
+	Sku       string `json:"sku"`
+	Name      string `json:"name"`
+	Quantity  int    `json:"quantity"`
+	UnitPrice *Money `json:"unitPrice"`
+	Total     *Money `json:"total"`
+	TaxRate   string `json:"taxRate"`
+	Tax       *Money `json:"tax"`
+}
+
+type Product struct {
+	ID          string        `json:"id"`
+	Sku         string        `json:"sku"`
+	Name        string        `json:"name"`
+	Description string        `json:"description"`
+	CategoryID  *string       `json:"categoryId,omitempty"`
+	Category    *Category     `json:"category,omitempty"`
+	Price       *Money        `json:"price"`
+	Stock       int           `json:"stock"`
+	Status      ProductStatus `json:"status"`
+	Version     int           `json:"version"`
+	CreatedAt   time.Time     `json:"createdAt"`
+	UpdatedAt   time.Time     `json:"updatedAt"`
+}
+
+type ProductFilter struct {
+	NameContains *string        `json:"nameContains,omitempty"`
+	NamePrefix   *string        `json:"namePrefix,omitempty"`
+	CategoryID   *string        `json:"categoryId,omitempty"`
+	Status       *ProductStatus `json:"status,omitempty"`
+	PriceMin     *string        `json:"priceMin,omitempty"`
+	PriceMax     *string        `json:"priceMax,omitempty"`
+	Currency     *string        `json:"currency,omitempty"`
+	CreatedFrom  *time.Time     `json:"createdFrom,omitempty"`
+	CreatedTo    *time.Time     `json:"createdTo,omitempty"`
 }
 
 type Query struct {
 }
+
+type OrderStatus string
+
+const (
+	OrderStatusPending   OrderStatus = "PENDING"
+	OrderStatusPaid      OrderStatus = "PAID"
+	OrderStatusShipped   OrderStatus = "SHIPPED"
+	OrderStatusDelivered OrderStatus = "DELIVERED"
+	OrderStatusCancelled OrderStatus = "CANCELLED"
+	OrderStatusRefunded  OrderStatus = "REFUNDED"
+)
+
+var AllOrderStatus = []OrderStatus{
+	OrderStatusPending,
+	OrderStatusPaid,
+	OrderStatusShipped,
+	OrderStatusDelivered,
+	OrderStatusCancelled,
+	OrderStatusRefunded,
+}
+
+func (e OrderStatus) IsValid() bool {
+	switch e {
+	case OrderStatusPending, OrderStatusPaid, OrderStatusShipped, OrderStatusDelivered, OrderStatusCancelled, OrderStatusRefunded:
+		return true
+	}
+	return false
+}
+
+func (e OrderStatus) String() string {
+	return string(e)
+}
+
+func (e *OrderStatus) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = OrderStatus(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid OrderStatus", str)
+	}
+	return nil
+}
+
+func (e OrderStatus) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type ProductStatus string
+
+const (
+	ProductStatusActive   ProductStatus = "ACTIVE"
+	ProductStatusArchived ProductStatus = "ARCHIVED"
+)
+
+var AllProductStatus = []ProductStatus{
+	ProductStatusActive,
+	ProductStatusArchived,
+}
+
+func (e ProductStatus) IsValid() bool {
+	switch e {
+	case ProductStatusActive, ProductStatusArchived:
+		return true
+	}
+	return false
+}
+
+func (e ProductStatus) String() string {
+	return string(e)
+}
+
+func (e *ProductStatus) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = ProductStatus(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid ProductStatus", str)
+	}
+	return nil
+}
+
+func (e ProductStatus) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
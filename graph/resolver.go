@@ -1,7 +1,19 @@
 package graph
 
+import (
+	"github.com/rgoncalvesrr/fullcycle-clean-arch/internal/entity"
+	"github.com/rgoncalvesrr/fullcycle-clean-arch/internal/infra/database"
+	"github.com/rgoncalvesrr/fullcycle-clean-arch/pkg/events"
+)
+
 // This file will not be regenerated automatically.
 //
 // It serves as dependency injection for your app, add any dependencies you require here.
 
-type Resolver struct{}
+type Resolver struct {
+	ProductGateway  database.ProductInterface
+	CategoryGateway database.CategoryInterface
+	OrderGateway    database.OrderInterface
+	TaxPolicy       entity.TaxPolicy
+	EventDispatcher *events.RecordingDispatcher
+}
@@ -6,36 +6,245 @@ package graph
 
 import (
 	"context"
-	"fmt"
+	"errors"
+	"log"
 
 	"github.com/rgoncalvesrr/fullcycle-clean-arch/graph/model"
+	"github.com/rgoncalvesrr/fullcycle-clean-arch/internal/dto"
+	"github.com/rgoncalvesrr/fullcycle-clean-arch/internal/entity"
+	"github.com/rgoncalvesrr/fullcycle-clean-arch/internal/event"
+	"github.com/rgoncalvesrr/fullcycle-clean-arch/internal/infra/database"
+	"github.com/rgoncalvesrr/fullcycle-clean-arch/internal/usecase"
+	pkgEntity "github.com/rgoncalvesrr/fullcycle-clean-arch/pkg/entity"
+	"github.com/rgoncalvesrr/fullcycle-clean-arch/pkg/events"
+	"gorm.io/gorm"
 )
 
+// Parent is the resolver for the parent field.
+func (r *categoryResolver) Parent(ctx context.Context, obj *model.Category) (*model.Category, error) {
+	if obj.ParentID == nil {
+		return nil, nil
+	}
+
+	return r.findCategory(*obj.ParentID)
+}
+
+// Children is the resolver for the children field.
+func (r *categoryResolver) Children(ctx context.Context, obj *model.Category) ([]*model.Category, error) {
+	categories, err := r.CategoryGateway.FindChildren(obj.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return toCategoryModels(categories), nil
+}
+
+// Products is the resolver for the products field.
+func (r *categoryResolver) Products(ctx context.Context, obj *model.Category, page *int, limit *int) ([]*model.Product, error) {
+	return r.Query().Products(ctx, &model.ProductFilter{CategoryID: &obj.ID}, nil, page, limit)
+}
+
 // CreateCategory is the resolver for the createCategory field.
 func (r *mutationResolver) CreateCategory(ctx context.Context, input model.NewCategory) (*model.Category, error) {
-	panic(fmt.Errorf("not implemented: CreateCategory - createCategory"))
+	var parentID *pkgEntity.ID
+
+	if input.ParentID != nil {
+		id, err := pkgEntity.ParseID(*input.ParentID)
+		if err != nil {
+			return nil, entity.ErrInvalidID
+		}
+		parentID = &id
+	}
+
+	description := ""
+	if input.Description != nil {
+		description = *input.Description
+	}
+
+	category, err := entity.NewCategory(input.Name, description, parentID)
+	if err != nil {
+		return nil, err
+	}
+
+	// The event is stored with the category and outlives the request.
+	ctx = context.WithoutCancel(ctx)
+
+	var created events.IEvent
+
+	err = r.CategoryGateway.Transaction(func(tx database.CategoryInterface) error {
+		if err := tx.Create(category); err != nil {
+			return err
+		}
+
+		created = event.NewCategoryChanged(event.CategoryCreated, category.ID.String(), toCategoryPayload(category))
+		return tx.RecordEvent(ctx, created)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// The category is already committed, so a failing handler doesn't fail
+	// the mutation.
+	if err = r.EventDispatcher.DispatchRecorded(ctx, created); err != nil {
+		log.Printf("dispatching %s for category %s failed: %v", event.CategoryCreated, category.ID, err)
+	}
+
+	return toCategoryModel(category), nil
 }
 
-// CreateCourse is the resolver for the createCourse field.
-func (r *mutationResolver) CreateCourse(ctx context.Context, input model.NewCourse) (*model.Course, error) {
-	panic(fmt.Errorf("not implemented: CreateCourse - createCourse"))
+// CreateOrder is the resolver for the createOrder field.
+func (r *mutationResolver) CreateOrder(ctx context.Context, input model.NewOrder) (*model.Order, error) {
+	orderDto := dto.CreateOrderInput{Items: make([]dto.OrderItemInput, 0, len(input.Items))}
+	if input.Region != nil {
+		orderDto.Region = *input.Region
+	}
+	for _, item := range input.Items {
+		orderDto.Items = append(orderDto.Items, dto.OrderItemInput{ProductID: item.ProductID, Quantity: item.Quantity})
+	}
+
+	order, err := usecase.NewCreateOrderUseCase(r.ProductGateway, r.OrderGateway, r.TaxPolicy).Execute(orderDto)
+	if err != nil {
+		return nil, err
+	}
+
+	return toOrderModel(order), nil
+}
+
+// Category is the resolver for the category field.
+func (r *productResolver) Category(ctx context.Context, obj *model.Product) (*model.Category, error) {
+	if obj.CategoryID == nil {
+		return nil, nil
+	}
+
+	return r.findCategory(*obj.CategoryID)
 }
 
 // Categories is the resolver for the categories field.
 func (r *queryResolver) Categories(ctx context.Context) ([]*model.Category, error) {
-	panic(fmt.Errorf("not implemented: Categories - categories"))
+	categories, err := r.CategoryGateway.FindAll()
+	if err != nil {
+		return nil, err
+	}
+
+	return toCategoryModels(categories), nil
+}
+
+// Category is the resolver for the category field.
+func (r *queryResolver) Category(ctx context.Context, id string) (*model.Category, error) {
+	return r.findCategory(id)
 }
 
-// Courses is the resolver for the courses field.
-func (r *queryResolver) Courses(ctx context.Context) ([]*model.Course, error) {
-	panic(fmt.Errorf("not implemented: Courses - courses"))
+// Products is the resolver for the products field.
+func (r *queryResolver) Products(ctx context.Context, filter *model.ProductFilter, sort *string, page *int, limit *int) ([]*model.Product, error) {
+	query := database.ProductQuery{Limit: 50}
+
+	if limit != nil {
+		query.Limit = *limit
+	}
+
+	if page != nil && *page > 1 {
+		query.Offset = (*page - 1) * query.Limit
+	}
+
+	if sort != nil {
+		fields, err := database.ParseProductSort(*sort)
+		if err != nil {
+			return nil, err
+		}
+		query.Sort = fields
+	}
+
+	if filter != nil {
+		if filter.NameContains != nil {
+			query.NameContains = *filter.NameContains
+		}
+		if filter.NamePrefix != nil {
+			query.NamePrefix = *filter.NamePrefix
+		}
+		if filter.CategoryID != nil {
+			query.CategoryID = *filter.CategoryID
+		}
+		if filter.Status != nil {
+			query.Status = toProductStatus(*filter.Status)
+		}
+		currency := entity.DefaultCurrency
+		if filter.Currency != nil {
+			currency = *filter.Currency
+		}
+
+		var err error
+		if query.PriceMin, err = parseMoneyArg(filter.PriceMin, currency); err != nil {
+			return nil, err
+		}
+		if query.PriceMax, err = parseMoneyArg(filter.PriceMax, currency); err != nil {
+			return nil, err
+		}
+		query.CreatedFrom = filter.CreatedFrom
+		query.CreatedTo = filter.CreatedTo
+	}
+
+	products, err := r.ProductGateway.FindByQuery(query)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*model.Product, 0, len(products))
+	for i := range products {
+		result = append(result, toProductModel(&products[i]))
+	}
+
+	return result, nil
 }
 
+// Orders is the resolver for the orders field.
+func (r *queryResolver) Orders(ctx context.Context, page *int, limit *int) ([]*model.Order, error) {
+	listDto := dto.ListOrdersInput{}
+	if page != nil {
+		listDto.Page = *page
+	}
+	if limit != nil {
+		listDto.Limit = *limit
+	}
+
+	orders, err := usecase.NewListOrdersUseCase(r.OrderGateway).Execute(listDto)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*model.Order, 0, len(orders))
+	for i := range orders {
+		result = append(result, toOrderModel(&orders[i]))
+	}
+
+	return result, nil
+}
+
+// Order is the resolver for the order field.
+func (r *queryResolver) Order(ctx context.Context, id string) (*model.Order, error) {
+	order, err := usecase.NewGetOrderUseCase(r.OrderGateway).Execute(id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return toOrderModel(order), nil
+}
+
+// Category returns CategoryResolver implementation.
+func (r *Resolver) Category() CategoryResolver { return &categoryResolver{r} }
+
 // Mutation returns MutationResolver implementation.
 func (r *Resolver) Mutation() MutationResolver { return &mutationResolver{r} }
 
+// Product returns ProductResolver implementation.
+func (r *Resolver) Product() ProductResolver { return &productResolver{r} }
+
 // Query returns QueryResolver implementation.
 func (r *Resolver) Query() QueryResolver { return &queryResolver{r} }
 
+type categoryResolver struct{ *Resolver }
 type mutationResolver struct{ *Resolver }
+type productResolver struct{ *Resolver }
 type queryResolver struct{ *Resolver }
@@ -1,23 +1,191 @@
 package dto
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+)
 
 type Error struct {
 	Message string `json:"message"`
 }
 
+// Money is a decimal amount, e.g. {"amount": "10.50", "currency": "BRL"}.
+type Money struct {
+	Amount   string `json:"amount" example:"10.50"`
+	Currency string `json:"currency" example:"BRL"`
+}
+
 type CreateProductInput struct {
-	Name  string  `json:"name"`
-	Price float64 `json:"price"`
+	SKU         string `json:"sku"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	CategoryID  string `json:"category_id"`
+	Price       Money  `json:"price"`
+	Stock       int    `json:"stock"`
+	Status      string `json:"status" enums:"active,archived"`
 }
 
 type CreateProductOutput struct {
-	ID        string    `json:"id"`
-	Name      string    `json:"name"`
-	Price     float64   `json:"price"`
+	ID          string    `json:"id"`
+	SKU         string    `json:"sku"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	CategoryID  *string   `json:"category_id"`
+	Price       Money     `json:"price"`
+	Stock       int       `json:"stock"`
+	Status      string    `json:"status"`
+	Version     int       `json:"version"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// ProductHistoryOutput is a recorded change of a product with its state
+// before and after it.
+type ProductHistoryOutput struct {
+	ID        string               `json:"id"`
+	Action    string               `json:"action" enums:"created,updated,deleted,restored,baseline"`
+	Actor     string               `json:"actor"`
+	Version   int                  `json:"version"`
+	Before    *CreateProductOutput `json:"before"`
+	After     *CreateProductOutput `json:"after"`
+	CreatedAt time.Time            `json:"created_at"`
+}
+
+type ImportProductsOutput struct {
+	Mode    string                  `json:"mode" enums:"transactional,best_effort"`
+	DryRun  bool                    `json:"dry_run"`
+	Created int                     `json:"created"`
+	Updated int                     `json:"updated"`
+	Failed  int                     `json:"failed"`
+	Errors  []ImportProductRowError `json:"errors"`
+}
+
+// ImportProductRowError reports a rejected row by its line in the uploaded
+// file.
+type ImportProductRowError struct {
+	Line    int    `json:"line"`
+	SKU     string `json:"sku,omitempty"`
+	Message string `json:"message"`
+}
+
+type BatchProductsInput struct {
+	Mode       string                  `json:"mode" enums:"atomic,independent"`
+	Operations []BatchProductOperation `json:"operations"`
+}
+
+// BatchProductOperation creates, updates or deletes one product. Product is
+// the full product for creates and a JSON Merge Patch for updates. A non
+// zero Version must match the current version of the product; zero, or no
+// version, updates or deletes whatever version is current.
+type BatchProductOperation struct {
+	Op      string          `json:"op" enums:"create,update,delete"`
+	ID      string          `json:"id,omitempty"`
+	Version int             `json:"version,omitempty"`
+	Product json.RawMessage `json:"product,omitempty" swaggertype:"object"`
+}
+
+type BatchProductsOutput struct {
+	Mode      string               `json:"mode"`
+	Committed bool                 `json:"committed"`
+	Results   []BatchProductResult `json:"results"`
+}
+
+// BatchProductResult reports the outcome of the operation at Index with the
+// HTTP status the equivalent single request would have returned.
+type BatchProductResult struct {
+	Index   int    `json:"index"`
+	Op      string `json:"op"`
+	ID      string `json:"id,omitempty"`
+	Status  int    `json:"status"`
+	Version int    `json:"version,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+type CreateCategoryInput struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	ParentID    string `json:"parent_id"`
+}
+
+type CategoryOutput struct {
+	ID          string    `json:"id"`
+	ParentID    *string   `json:"parent_id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+type CategoryTreeOutput struct {
+	ID          string               `json:"id"`
+	Name        string               `json:"name"`
+	Description string               `json:"description"`
+	Children    []CategoryTreeOutput `json:"children"`
+}
+
+type OrderItemInput struct {
+	ProductID string `json:"product_id"`
+	Quantity  int    `json:"quantity"`
+}
+
+type CreateOrderInput struct {
+	Region string           `json:"region" example:"SP"`
+	Items  []OrderItemInput `json:"items"`
+}
+
+type ListOrdersInput struct {
+	Page  int
+	Limit int
+}
+
+type OrderItemOutput struct {
+	ID        string `json:"id"`
+	ProductID string `json:"product_id"`
+	SKU       string `json:"sku"`
+	Name      string `json:"name"`
+	Quantity  int    `json:"quantity"`
+	UnitPrice Money  `json:"unit_price"`
+	Total     Money  `json:"total"`
+	TaxRate   string `json:"tax_rate" example:"18.00"`
+	Tax       Money  `json:"tax"`
+}
+
+type OrderTransitionOutput struct {
+	From      string    `json:"from"`
+	To        string    `json:"to"`
+	Actor     string    `json:"actor"`
 	CreatedAt time.Time `json:"created_at"`
 }
 
+type OrderOutput struct {
+	ID          string                  `json:"id"`
+	Items       []OrderItemOutput       `json:"items"`
+	Region      string                  `json:"region" example:"SP"`
+	Subtotal    Money                   `json:"subtotal"`
+	Tax         Money                   `json:"tax"`
+	Total       Money                   `json:"total"`
+	Status      string                  `json:"status" example:"pending"`
+	Transitions []OrderTransitionOutput `json:"transitions"`
+	CreatedAt   time.Time               `json:"created_at"`
+}
+
+type TransitionOrderInput struct {
+	OrderID string
+	Status  string
+	Actor   string
+}
+
+type DeadLetterOutput struct {
+	ID        string          `json:"id"`
+	Handler   string          `json:"handler"`
+	Event     string          `json:"event" example:"order.paid"`
+	Payload   json.RawMessage `json:"payload" swaggertype:"object"`
+	EventTime time.Time       `json:"event_time"`
+	Error     string          `json:"error"`
+	Attempts  int             `json:"attempts"`
+	FailedAt  time.Time       `json:"failed_at"`
+}
+
 type CreateUserInput struct {
 	Name     string `json:"name"`
 	Email    string `json:"email"`
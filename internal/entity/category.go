@@ -0,0 +1,70 @@
+package entity
+
+import (
+	"errors"
+	"time"
+
+	"github.com/rgoncalvesrr/fullcycle-clean-arch/pkg/entity"
+)
+
+var (
+	ErrInvalidParentCategory = errors.New("invalid parent category")
+)
+
+type Category struct {
+	ID          entity.ID  `json:"id"`
+	ParentID    *entity.ID `json:"parent_id" gorm:"index"`
+	Name        string     `json:"name" gorm:"size:100"`
+	Description string     `json:"description"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+func NewCategory(name string, description string, parentID *entity.ID) (*Category, error) {
+	now := time.Now()
+
+	c := &Category{
+		ID:          entity.NewID(),
+		ParentID:    parentID,
+		Name:        name,
+		Description: description,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+
+	err := c.Validate()
+
+	if err != nil {
+		c = nil
+	}
+
+	return c, err
+}
+
+func (c *Category) Validate() error {
+	if c.ID.String() == "" {
+		return ErrIDIsRequired
+	}
+
+	if _, err := entity.ParseID(c.ID.String()); err != nil {
+		return ErrInvalidID
+	}
+
+	if c.Name == "" {
+		return ErrNameIsRequired
+	}
+
+	if len(c.Name) > maxCategoryLength {
+		return ErrCategoryIsTooLong
+	}
+
+	if len(c.Description) > maxDescriptionLength {
+		return ErrDescriptionIsTooLong
+	}
+
+	if c.ParentID != nil && *c.ParentID == c.ID {
+		return ErrInvalidParentCategory
+	}
+
+	return nil
+}
@@ -0,0 +1,37 @@
+package entity
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewCategory(t *testing.T) {
+	parent, err := NewCategory("Papelaria", "Material de escritório", nil)
+
+	assert.Nil(t, err)
+	assert.NotNil(t, parent)
+	assert.NotEmpty(t, parent.ID)
+	assert.Nil(t, parent.ParentID)
+	assert.Equal(t, "Papelaria", parent.Name)
+
+	child, err := NewCategory("Canetas", "", &parent.ID)
+
+	assert.Nil(t, err)
+	assert.Equal(t, parent.ID, *child.ParentID)
+}
+
+func TestCategoryWhenNameIsRequired(t *testing.T) {
+	c, err := NewCategory("", "", nil)
+
+	assert.Nil(t, c)
+	assert.ErrorIs(t, err, ErrNameIsRequired)
+}
+
+func TestCategoryWhenParentIsItself(t *testing.T) {
+	c, err := NewCategory("Papelaria", "", nil)
+	assert.Nil(t, err)
+
+	c.ParentID = &c.ID
+	assert.ErrorIs(t, c.Validate(), ErrInvalidParentCategory)
+}
@@ -0,0 +1,17 @@
+package entity
+
+import "time"
+
+// DeadLetter is the stored form of an event a handler kept failing on. The
+// event payload is kept as JSON.
+type DeadLetter struct {
+	ID        string `gorm:"primaryKey;size:36"`
+	Handler   string `gorm:"size:100;index"`
+	EventName string `gorm:"size:100"`
+	EventKey  string `gorm:"size:255"`
+	Payload   []byte
+	EventTime time.Time
+	Error     string
+	Attempts  int
+	FailedAt  time.Time `gorm:"index"`
+}
@@ -0,0 +1,82 @@
+package entity
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	ErrIdempotencyKeyIsRequired = errors.New("idempotency key is required")
+	ErrIdempotencyKeyIsTooLong  = errors.New("idempotency key is too long")
+)
+
+const maxIdempotencyKeyLength = 255
+
+// IdempotencyKeyLease is how long a reservation holds a key without being
+// renewed. A request that crashed leaves its key pending; once the lease
+// runs out a retry takes it over instead of waiting for the key to expire.
+const IdempotencyKeyLease = 30 * time.Second
+
+// IdempotencyKey records the outcome of a request sent with an
+// Idempotency-Key header, so retries get the same response instead of
+// repeating the operation. Keys are scoped by the authenticated subject. A
+// zero Status means the first request is still running, holding the key
+// until LockedUntil.
+type IdempotencyKey struct {
+	Key         string `gorm:"column:idempotency_key;primaryKey;size:255"`
+	Scope       string `gorm:"primaryKey;size:36"`
+	Fingerprint string `gorm:"size:64"`
+	Status      int    `gorm:"not null;default:0"`
+	Header      []byte `gorm:"column:response_header"`
+	Body        []byte `gorm:"column:response_body"`
+	CreatedAt   time.Time
+	ExpiresAt   time.Time `gorm:"index"`
+	LockedUntil time.Time
+}
+
+func NewIdempotencyKey(scope string, key string, fingerprint string, ttl time.Duration) (*IdempotencyKey, error) {
+	now := time.Now()
+
+	k := &IdempotencyKey{
+		Key:         key,
+		Scope:       scope,
+		Fingerprint: fingerprint,
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(ttl),
+		LockedUntil: now.Add(IdempotencyKeyLease),
+	}
+
+	err := k.Validate()
+
+	if err != nil {
+		k = nil
+	}
+
+	return k, err
+}
+
+func (k *IdempotencyKey) Validate() error {
+	if k.Key == "" {
+		return ErrIdempotencyKeyIsRequired
+	}
+
+	if len(k.Key) > maxIdempotencyKeyLength {
+		return ErrIdempotencyKeyIsTooLong
+	}
+
+	return nil
+}
+
+func (k *IdempotencyKey) IsCompleted() bool {
+	return k.Status != 0
+}
+
+func (k *IdempotencyKey) IsExpired(now time.Time) bool {
+	return !now.Before(k.ExpiresAt)
+}
+
+// IsAbandoned reports whether the request running with k stopped renewing
+// its reservation before completing.
+func (k *IdempotencyKey) IsAbandoned(now time.Time) bool {
+	return !k.IsCompleted() && !now.Before(k.LockedUntil)
+}
@@ -0,0 +1,40 @@
+package entity
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewIdempotencyKey(t *testing.T) {
+	k, err := NewIdempotencyKey("user-1", "key-1", "abc", time.Hour)
+
+	assert.Nil(t, err)
+	assert.Equal(t, "key-1", k.Key)
+	assert.Equal(t, "user-1", k.Scope)
+	assert.False(t, k.IsCompleted())
+	assert.False(t, k.IsExpired(time.Now()))
+	assert.True(t, k.IsExpired(time.Now().Add(time.Hour)))
+}
+
+func TestIdempotencyKeyIsAbandoned(t *testing.T) {
+	k, _ := NewIdempotencyKey("user-1", "key-1", "abc", time.Hour)
+
+	assert.False(t, k.IsAbandoned(time.Now()))
+	assert.True(t, k.IsAbandoned(time.Now().Add(IdempotencyKeyLease)))
+
+	k.Status = 201
+	assert.False(t, k.IsAbandoned(time.Now().Add(IdempotencyKeyLease)))
+}
+
+func TestIdempotencyKeyValidate(t *testing.T) {
+	k, err := NewIdempotencyKey("user-1", "", "abc", time.Hour)
+	assert.Nil(t, k)
+	assert.ErrorIs(t, err, ErrIdempotencyKeyIsRequired)
+
+	k, err = NewIdempotencyKey("user-1", strings.Repeat("k", 256), "abc", time.Hour)
+	assert.Nil(t, k)
+	assert.ErrorIs(t, err, ErrIdempotencyKeyIsTooLong)
+}
@@ -0,0 +1,222 @@
+package entity
+
+import (
+	"encoding/json"
+	"errors"
+	"math/big"
+	"strings"
+)
+
+var (
+	ErrInvalidCurrency  = errors.New("invalid currency")
+	ErrInvalidAmount    = errors.New("invalid amount")
+	ErrCurrencyMismatch = errors.New("currency mismatch")
+)
+
+const DefaultCurrency = "BRL"
+
+// currencyExponents holds the number of minor unit digits of the supported
+// ISO 4217 currencies.
+var currencyExponents = map[string]int{
+	"BRL": 2,
+	"USD": 2,
+	"EUR": 2,
+	"GBP": 2,
+	"ARS": 2,
+	"MXN": 2,
+	"CAD": 2,
+	"CHF": 2,
+	"JPY": 0,
+	"CLP": 0,
+	"PYG": 0,
+	"KWD": 3,
+	"BHD": 3,
+}
+
+type RoundingMode int
+
+const (
+	// RoundHalfUp rounds ties away from zero.
+	RoundHalfUp RoundingMode = iota
+	// RoundHalfEven rounds ties to the nearest even minor unit.
+	RoundHalfEven
+	// RoundDown truncates towards zero.
+	RoundDown
+	// RoundUp rounds away from zero.
+	RoundUp
+)
+
+// Money is an amount in the minor units of a currency, e.g. 1050 BRL is
+// R$ 10,50.
+type Money struct {
+	Amount   int64  `gorm:"column:amount"`
+	Currency string `gorm:"column:currency;size:3"`
+}
+
+type moneyJSON struct {
+	Amount   string `json:"amount"`
+	Currency string `json:"currency"`
+}
+
+func NewMoney(amount int64, currency string) (Money, error) {
+	currency = strings.ToUpper(currency)
+
+	if _, ok := currencyExponents[currency]; !ok {
+		return Money{}, ErrInvalidCurrency
+	}
+
+	return Money{Amount: amount, Currency: currency}, nil
+}
+
+// ParseMoney parses a decimal string like "10.50" in the given currency. It
+// fails when the value has more decimal places than the currency allows.
+func ParseMoney(s string, currency string) (Money, error) {
+	m, err := NewMoney(0, currency)
+	if err != nil {
+		return m, err
+	}
+
+	r, ok := new(big.Rat).SetString(strings.TrimSpace(s))
+	if !ok || strings.ContainsAny(s, "/eE") {
+		return Money{}, ErrInvalidAmount
+	}
+
+	r.Mul(r, new(big.Rat).SetInt(m.scale()))
+	if !r.IsInt() || !r.Num().IsInt64() {
+		return Money{}, ErrInvalidAmount
+	}
+
+	m.Amount = r.Num().Int64()
+
+	return m, nil
+}
+
+// MustParseMoney is like ParseMoney but panics on error. It is meant for
+// constants and tests.
+func MustParseMoney(s string, currency string) Money {
+	m, err := ParseMoney(s, currency)
+	if err != nil {
+		panic(err)
+	}
+
+	return m
+}
+
+func (m Money) IsValid() bool {
+	_, ok := currencyExponents[m.Currency]
+	return ok
+}
+
+func (m Money) IsZero() bool {
+	return m.Amount == 0
+}
+
+func (m Money) IsNegative() bool {
+	return m.Amount < 0
+}
+
+func (m Money) Add(o Money) (Money, error) {
+	if m.Currency != o.Currency {
+		return Money{}, ErrCurrencyMismatch
+	}
+
+	return Money{Amount: m.Amount + o.Amount, Currency: m.Currency}, nil
+}
+
+func (m Money) Sub(o Money) (Money, error) {
+	if m.Currency != o.Currency {
+		return Money{}, ErrCurrencyMismatch
+	}
+
+	return Money{Amount: m.Amount - o.Amount, Currency: m.Currency}, nil
+}
+
+func (m Money) Mul(n int64) Money {
+	return Money{Amount: m.Amount * n, Currency: m.Currency}
+}
+
+// MulRat multiplies by an arbitrary rational, e.g. a tax rate, rounding the
+// result to a whole minor unit with mode.
+func (m Money) MulRat(r *big.Rat, mode RoundingMode) Money {
+	x := new(big.Rat).Mul(new(big.Rat).SetInt64(m.Amount), r)
+
+	return Money{Amount: roundRat(x, mode), Currency: m.Currency}
+}
+
+func (m Money) Cmp(o Money) (int, error) {
+	if m.Currency != o.Currency {
+		return 0, ErrCurrencyMismatch
+	}
+
+	switch {
+	case m.Amount < o.Amount:
+		return -1, nil
+	case m.Amount > o.Amount:
+		return 1, nil
+	}
+
+	return 0, nil
+}
+
+// String formats the amount as a decimal string, without the currency.
+func (m Money) String() string {
+	exp := currencyExponents[m.Currency]
+
+	return new(big.Rat).SetFrac(big.NewInt(m.Amount), m.scale()).FloatString(exp)
+}
+
+func (m Money) MarshalJSON() ([]byte, error) {
+	return json.Marshal(moneyJSON{Amount: m.String(), Currency: m.Currency})
+}
+
+func (m *Money) UnmarshalJSON(data []byte) error {
+	var v moneyJSON
+
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+
+	parsed, err := ParseMoney(v.Amount, v.Currency)
+	if err != nil {
+		return err
+	}
+
+	*m = parsed
+
+	return nil
+}
+
+func (m Money) scale() *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(currencyExponents[m.Currency])), nil)
+}
+
+func roundRat(x *big.Rat, mode RoundingMode) int64 {
+	num, den := x.Num(), x.Denom()
+	q, r := new(big.Int).QuoRem(num, den, new(big.Int))
+
+	if r.Sign() == 0 {
+		return q.Int64()
+	}
+
+	away := big.NewInt(int64(x.Sign()))
+
+	// cmp compares twice the remainder with the denominator to tell whether
+	// the discarded fraction is below, at or above one half.
+	cmp := new(big.Int).Mul(new(big.Int).Abs(r), big.NewInt(2)).Cmp(den)
+
+	switch mode {
+	case RoundDown:
+	case RoundUp:
+		q.Add(q, away)
+	case RoundHalfEven:
+		if cmp > 0 || (cmp == 0 && q.Bit(0) == 1) {
+			q.Add(q, away)
+		}
+	default:
+		if cmp >= 0 {
+			q.Add(q, away)
+		}
+	}
+
+	return q.Int64()
+}
@@ -0,0 +1,98 @@
+package entity
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseMoney(t *testing.T) {
+	m, err := ParseMoney("10.5", "brl")
+	assert.Nil(t, err)
+	assert.Equal(t, Money{Amount: 1050, Currency: "BRL"}, m)
+	assert.Equal(t, "10.50", m.String())
+
+	m, err = ParseMoney("1500", "JPY")
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1500), m.Amount)
+	assert.Equal(t, "1500", m.String())
+
+	m, err = ParseMoney("-0.001", "KWD")
+	assert.Nil(t, err)
+	assert.Equal(t, int64(-1), m.Amount)
+	assert.Equal(t, "-0.001", m.String())
+
+	_, err = ParseMoney("10.505", "BRL")
+	assert.ErrorIs(t, err, ErrInvalidAmount)
+
+	_, err = ParseMoney("1e3", "BRL")
+	assert.ErrorIs(t, err, ErrInvalidAmount)
+
+	_, err = ParseMoney("abc", "BRL")
+	assert.ErrorIs(t, err, ErrInvalidAmount)
+
+	_, err = ParseMoney("10", "XYZ")
+	assert.ErrorIs(t, err, ErrInvalidCurrency)
+}
+
+func TestMoneyArithmetic(t *testing.T) {
+	a := MustParseMoney("0.10", "BRL")
+	b := MustParseMoney("0.20", "BRL")
+
+	sum, err := a.Add(b)
+	assert.Nil(t, err)
+	assert.Equal(t, "0.30", sum.String())
+
+	diff, err := a.Sub(b)
+	assert.Nil(t, err)
+	assert.Equal(t, "-0.10", diff.String())
+
+	assert.Equal(t, "0.30", a.Mul(3).String())
+
+	_, err = a.Add(MustParseMoney("0.10", "USD"))
+	assert.ErrorIs(t, err, ErrCurrencyMismatch)
+
+	cmp, err := a.Cmp(b)
+	assert.Nil(t, err)
+	assert.Equal(t, -1, cmp)
+}
+
+func TestMoneyMulRatRounding(t *testing.T) {
+	half := big.NewRat(1, 2)
+
+	cases := []struct {
+		amount   string
+		mode     RoundingMode
+		expected string
+	}{
+		{"0.05", RoundHalfUp, "0.03"},
+		{"0.05", RoundHalfEven, "0.02"},
+		{"0.07", RoundHalfEven, "0.04"},
+		{"0.05", RoundDown, "0.02"},
+		{"0.05", RoundUp, "0.03"},
+		{"-0.05", RoundHalfUp, "-0.03"},
+		{"-0.05", RoundDown, "-0.02"},
+		{"0.04", RoundUp, "0.02"},
+	}
+
+	for _, c := range cases {
+		m := MustParseMoney(c.amount, "BRL").MulRat(half, c.mode)
+		assert.Equal(t, c.expected, m.String(), "%s * 1/2 mode %d", c.amount, c.mode)
+	}
+}
+
+func TestMoneyJSON(t *testing.T) {
+	m := MustParseMoney("19.90", "BRL")
+
+	data, err := json.Marshal(m)
+	assert.Nil(t, err)
+	assert.JSONEq(t, `{"amount":"19.90","currency":"BRL"}`, string(data))
+
+	var decoded Money
+	assert.Nil(t, json.Unmarshal(data, &decoded))
+	assert.Equal(t, m, decoded)
+
+	assert.ErrorIs(t, json.Unmarshal([]byte(`{"amount":"1.999","currency":"BRL"}`), &decoded), ErrInvalidAmount)
+}
@@ -0,0 +1,239 @@
+package entity
+
+import (
+	"errors"
+	"time"
+
+	"github.com/rgoncalvesrr/fullcycle-clean-arch/pkg/entity"
+)
+
+var (
+	ErrOrderHasNoItems    = errors.New("order has no items")
+	ErrInvalidQuantity    = errors.New("invalid quantity")
+	ErrInvalidOrderTotal  = errors.New("order total doesn't match its items")
+	ErrProductNotFound    = errors.New("product not found")
+	ErrProductUnavailable = errors.New("product is not available for sale")
+	ErrInsufficientStock  = errors.New("insufficient stock")
+)
+
+// Order totals are kept per line and for the whole order: Subtotal is the
+// sum of the line totals, Tax the sum of the line taxes and Total what the
+// customer pays.
+type Order struct {
+	ID          entity.ID         `json:"id"`
+	Items       []OrderItem       `json:"items" gorm:"constraint:OnDelete:CASCADE"`
+	Region      string            `json:"region" gorm:"size:16"`
+	Subtotal    Money             `json:"subtotal" gorm:"embedded;embeddedPrefix:subtotal_"`
+	Tax         Money             `json:"tax" gorm:"embedded;embeddedPrefix:tax_"`
+	Total       Money             `json:"total" gorm:"embedded;embeddedPrefix:total_"`
+	Status      OrderStatus       `json:"status" gorm:"size:16;index;default:pending"`
+	Transitions []OrderTransition `json:"transitions" gorm:"constraint:OnDelete:CASCADE"`
+	// Version is the number of events in the order's history; see
+	// OrderAggregate.
+	Version   int       `json:"version" gorm:"not null;default:0"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// OrderItem is an order line. SKU, Name, CategoryID and UnitPrice are copied
+// from the product when the order is placed, so later catalogue changes
+// don't alter past orders. Total is before tax.
+type OrderItem struct {
+	ID         entity.ID  `json:"id"`
+	OrderID    entity.ID  `json:"order_id" gorm:"index"`
+	ProductID  entity.ID  `json:"product_id" gorm:"index"`
+	SKU        string     `json:"sku" gorm:"size:64"`
+	Name       string     `json:"name"`
+	CategoryID *entity.ID `json:"category_id"`
+	Quantity   int        `json:"quantity"`
+	UnitPrice  Money      `json:"unit_price" gorm:"embedded;embeddedPrefix:unit_price_"`
+	Total      Money      `json:"total" gorm:"embedded;embeddedPrefix:total_"`
+	TaxRate    string     `json:"tax_rate" gorm:"size:16"`
+	Tax        Money      `json:"tax" gorm:"embedded;embeddedPrefix:tax_"`
+}
+
+func NewOrder(items []OrderItem) (*Order, error) {
+	now := time.Now()
+
+	o := &Order{
+		ID:        entity.NewID(),
+		Items:     items,
+		Status:    OrderPending,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	for i := range o.Items {
+		o.Items[i].OrderID = o.ID
+	}
+
+	if err := o.updateTotals(); err != nil {
+		return nil, err
+	}
+
+	if err := o.Validate(); err != nil {
+		return nil, err
+	}
+
+	return o, nil
+}
+
+// ApplyTax charges the tax policy sets for region on every line and updates
+// the order totals.
+func (o *Order) ApplyTax(policy TaxPolicy, region string) error {
+	o.Region = normalizeRegion(region)
+
+	for i := range o.Items {
+		tax, err := policy.Tax(&o.Items[i], o.Region)
+		if err != nil {
+			return err
+		}
+
+		o.Items[i].TaxRate = tax.Rate
+		o.Items[i].Tax = tax.Amount
+	}
+
+	if err := o.updateTotals(); err != nil {
+		return err
+	}
+
+	return o.Validate()
+}
+
+func (o *Order) updateTotals() error {
+	subtotal, tax, err := o.calculateTotals()
+	if err != nil {
+		return err
+	}
+
+	o.Subtotal, o.Tax = subtotal, tax
+	o.Total, err = subtotal.Add(tax)
+
+	return err
+}
+
+// NewOrderItem creates a line for quantity units of product at its current
+// price. The product must be active.
+func NewOrderItem(product *Product, quantity int) (OrderItem, error) {
+	if product.Status != ProductActive || product.IsDeleted() {
+		return OrderItem{}, ErrProductUnavailable
+	}
+
+	item := OrderItem{
+		ID:         entity.NewID(),
+		ProductID:  product.ID,
+		SKU:        product.SKU,
+		Name:       product.Name,
+		CategoryID: product.CategoryID,
+		Quantity:   quantity,
+		UnitPrice:  product.Price,
+		Total:      product.Price.Mul(int64(quantity)),
+		TaxRate:    "0.00",
+		Tax:        Money{Currency: product.Price.Currency},
+	}
+
+	if err := item.Validate(); err != nil {
+		return OrderItem{}, err
+	}
+
+	return item, nil
+}
+
+func (i *OrderItem) Validate() error {
+	if i.ID.String() == "" {
+		return ErrIDIsRequired
+	}
+
+	if i.Quantity <= 0 {
+		return ErrInvalidQuantity
+	}
+
+	if !i.UnitPrice.IsValid() {
+		return ErrInvalidCurrency
+	}
+
+	if i.UnitPrice.IsNegative() {
+		return ErrInvalidPrice
+	}
+
+	if i.Total != i.UnitPrice.Mul(int64(i.Quantity)) {
+		return ErrInvalidOrderTotal
+	}
+
+	if i.Tax.Currency != i.Total.Currency {
+		return ErrCurrencyMismatch
+	}
+
+	if i.Tax.IsNegative() {
+		return ErrInvalidTaxRate
+	}
+
+	return nil
+}
+
+// CalculateTotal sums the totals and taxes of the order lines. Every line
+// must be in the same currency.
+func (o *Order) CalculateTotal() (Money, error) {
+	subtotal, tax, err := o.calculateTotals()
+	if err != nil {
+		return Money{}, err
+	}
+
+	return subtotal.Add(tax)
+}
+
+func (o *Order) calculateTotals() (subtotal Money, tax Money, err error) {
+	if len(o.Items) == 0 {
+		return Money{}, Money{}, ErrOrderHasNoItems
+	}
+
+	currency := o.Items[0].Total.Currency
+	subtotal, tax = Money{Currency: currency}, Money{Currency: currency}
+
+	for _, item := range o.Items {
+		if subtotal, err = subtotal.Add(item.Total); err != nil {
+			return Money{}, Money{}, err
+		}
+
+		if tax, err = tax.Add(item.Tax); err != nil {
+			return Money{}, Money{}, err
+		}
+	}
+
+	return subtotal, tax, nil
+}
+
+func (o *Order) Validate() error {
+	if o.ID.String() == "" {
+		return ErrIDIsRequired
+	}
+
+	if len(o.Items) == 0 {
+		return ErrOrderHasNoItems
+	}
+
+	if !o.Status.IsValid() {
+		return ErrInvalidOrderStatus
+	}
+
+	for i := range o.Items {
+		if err := o.Items[i].Validate(); err != nil {
+			return err
+		}
+	}
+
+	subtotal, tax, err := o.calculateTotals()
+	if err != nil {
+		return err
+	}
+
+	if subtotal != o.Subtotal || tax != o.Tax {
+		return ErrInvalidOrderTotal
+	}
+
+	if total, _ := subtotal.Add(tax); total != o.Total {
+		return ErrInvalidOrderTotal
+	}
+
+	return nil
+}
@@ -0,0 +1,246 @@
+package entity
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/rgoncalvesrr/fullcycle-clean-arch/pkg/entity"
+)
+
+var (
+	ErrUnknownOrderEvent    = errors.New("unknown order event")
+	ErrOrderExists          = errors.New("order already exists")
+	ErrOrderVersionConflict = errors.New("order was changed by someone else, reload it and retry")
+)
+
+// OrderEvent is a change in the life of an order. An order's events, in
+// order, are its history, and its state is what applying them yields.
+type OrderEvent interface {
+	EventName() string
+	apply(o *Order) error
+}
+
+// OrderCreated starts the history of an order.
+type OrderCreated struct {
+	OrderID   entity.ID `json:"order_id"`
+	Region    string    `json:"region"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (e OrderCreated) EventName() string {
+	return "OrderCreated"
+}
+
+func (e OrderCreated) apply(o *Order) error {
+	if o.ID != (entity.ID{}) {
+		return fmt.Errorf("%w: %s", ErrOrderExists, o.ID)
+	}
+
+	*o = Order{
+		ID:        e.OrderID,
+		Region:    e.Region,
+		Status:    OrderPending,
+		CreatedAt: e.CreatedAt,
+		UpdatedAt: e.CreatedAt,
+	}
+
+	return nil
+}
+
+// ItemAdded adds a line, priced and taxed, to a pending order.
+type ItemAdded struct {
+	Item OrderItem `json:"item"`
+}
+
+func (e ItemAdded) EventName() string {
+	return "ItemAdded"
+}
+
+func (e ItemAdded) apply(o *Order) error {
+	if o.Status != OrderPending {
+		return fmt.Errorf("%w: items can't be added to a %s order", ErrInvalidOrderTransition, o.Status)
+	}
+
+	item := e.Item
+	item.OrderID = o.ID
+
+	if err := item.Validate(); err != nil {
+		return err
+	}
+
+	o.Items = append(o.Items, item)
+
+	return o.updateTotals()
+}
+
+// OrderTransitioned moves an order to another status. Its name tells the
+// new status, e.g. OrderPaid or OrderCancelled.
+type OrderTransitioned struct {
+	Transition OrderTransition `json:"transition"`
+}
+
+// orderTransitionedNames names the OrderTransitioned events by the status
+// they move the order to.
+var orderTransitionedNames = map[OrderStatus]string{
+	OrderPaid:      "OrderPaid",
+	OrderShipped:   "OrderShipped",
+	OrderDelivered: "OrderDelivered",
+	OrderCancelled: "OrderCancelled",
+	OrderRefunded:  "OrderRefunded",
+}
+
+func (e OrderTransitioned) EventName() string {
+	return orderTransitionedNames[e.Transition.To]
+}
+
+func (e OrderTransitioned) apply(o *Order) error {
+	t := e.Transition
+
+	if t.From != o.Status || !o.Status.CanTransitionTo(t.To) {
+		return fmt.Errorf("%w: %s to %s", ErrInvalidOrderTransition, o.Status, t.To)
+	}
+
+	t.OrderID = o.ID
+
+	o.Status = t.To
+	o.UpdatedAt = t.CreatedAt
+	o.Transitions = append(o.Transitions, t)
+
+	return nil
+}
+
+// UnmarshalOrderEvent reads an order event stored as JSON under its name.
+func UnmarshalOrderEvent(name string, data []byte) (OrderEvent, error) {
+	switch name {
+	case OrderCreated{}.EventName():
+		return unmarshalOrderEvent[OrderCreated](data)
+	case ItemAdded{}.EventName():
+		return unmarshalOrderEvent[ItemAdded](data)
+	}
+
+	event, err := unmarshalOrderEvent[OrderTransitioned](data)
+	if err != nil {
+		return nil, err
+	}
+
+	if event.EventName() != name {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownOrderEvent, name)
+	}
+
+	return event, nil
+}
+
+func unmarshalOrderEvent[E OrderEvent](data []byte) (OrderEvent, error) {
+	var event E
+
+	if err := json.Unmarshal(data, &event); err != nil {
+		return nil, err
+	}
+
+	return event, nil
+}
+
+// OrderAggregate is an order kept as the events that made it. Version is
+// the number of events applied, those recorded and not yet stored included.
+type OrderAggregate struct {
+	Order   Order
+	Version int
+
+	changes []OrderEvent
+}
+
+// NewOrderAggregate starts from a snapshot of the order at version, or from
+// nothing when snapshot is nil.
+func NewOrderAggregate(snapshot *Order, version int) *OrderAggregate {
+	a := &OrderAggregate{}
+
+	if snapshot != nil {
+		a.Order = *snapshot
+		a.Version = version
+		a.Order.Version = version
+	}
+
+	return a
+}
+
+// NewOrderEvents tells the history of order: its creation, its lines and
+// its transitions. Orders placed with NewOrder are stored this way.
+func NewOrderEvents(order *Order) []OrderEvent {
+	events := make([]OrderEvent, 0, 1+len(order.Items)+len(order.Transitions))
+
+	events = append(events, OrderCreated{OrderID: order.ID, Region: order.Region, CreatedAt: order.CreatedAt})
+
+	for _, item := range order.Items {
+		events = append(events, ItemAdded{Item: item})
+	}
+
+	for _, t := range order.Transitions {
+		events = append(events, OrderTransitioned{Transition: t})
+	}
+
+	return events
+}
+
+// Load applies events read from the order's stream.
+func (a *OrderAggregate) Load(events ...OrderEvent) error {
+	for _, e := range events {
+		if err := e.apply(&a.Order); err != nil {
+			return err
+		}
+
+		a.Version++
+		a.Order.Version = a.Version
+	}
+
+	return nil
+}
+
+// Record applies new events, which Changes returns until they're stored.
+// The order must be valid afterwards, so a new order is recorded with all
+// its lines at once.
+func (a *OrderAggregate) Record(events ...OrderEvent) error {
+	order := a.Order
+	order.Items = append([]OrderItem(nil), a.Order.Items...)
+	order.Transitions = append([]OrderTransition(nil), a.Order.Transitions...)
+
+	// All or nothing: on failure the aggregate is left as it was.
+	for _, e := range events {
+		if err := e.apply(&order); err != nil {
+			return err
+		}
+	}
+
+	if err := order.Validate(); err != nil {
+		return err
+	}
+
+	a.Order = order
+	a.Version += len(events)
+	a.Order.Version = a.Version
+	a.changes = append(a.changes, events...)
+
+	return nil
+}
+
+// Transition records the status change made by Order.TransitionTo.
+func (a *OrderAggregate) Transition(t *OrderTransition) error {
+	return a.Record(OrderTransitioned{Transition: *t})
+}
+
+// Changes returns the events recorded since the aggregate was loaded.
+func (a *OrderAggregate) Changes() []OrderEvent {
+	return a.changes
+}
+
+// StoredVersion is the version of the order as stored, before the recorded
+// changes; they must be stored right after it.
+func (a *OrderAggregate) StoredVersion() int {
+	return a.Version - len(a.changes)
+}
+
+// MarkStored clears the changes once they're stored.
+func (a *OrderAggregate) MarkStored() {
+	a.changes = nil
+}
@@ -0,0 +1,119 @@
+package entity
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOrderAggregateRecordsNewOrder(t *testing.T) {
+	order := newTestOrder(t)
+
+	aggregate := NewOrderAggregate(nil, 0)
+	assert.Nil(t, aggregate.Record(NewOrderEvents(order)...))
+
+	assert.Equal(t, 2, aggregate.Version)
+	assert.Equal(t, 0, aggregate.StoredVersion())
+	assert.Len(t, aggregate.Changes(), 2)
+	assert.Equal(t, "OrderCreated", aggregate.Changes()[0].EventName())
+	assert.Equal(t, "ItemAdded", aggregate.Changes()[1].EventName())
+
+	assert.Equal(t, order.ID, aggregate.Order.ID)
+	assert.Equal(t, order.Total, aggregate.Order.Total)
+	assert.Equal(t, OrderPending, aggregate.Order.Status)
+	assert.Equal(t, 2, aggregate.Order.Version)
+
+	aggregate.MarkStored()
+	assert.Empty(t, aggregate.Changes())
+	assert.Equal(t, 2, aggregate.StoredVersion())
+
+	// An order can't be created twice.
+	assert.ErrorIs(t, aggregate.Record(OrderCreated{OrderID: order.ID}), ErrOrderExists)
+}
+
+func TestOrderAggregateRejectsInvalidOrders(t *testing.T) {
+	order := newTestOrder(t)
+
+	aggregate := NewOrderAggregate(nil, 0)
+	assert.ErrorIs(t, aggregate.Record(NewOrderEvents(order)[0]), ErrOrderHasNoItems)
+	assert.Equal(t, 0, aggregate.Version)
+	assert.Empty(t, aggregate.Changes())
+}
+
+func TestOrderAggregateTransitions(t *testing.T) {
+	order := newTestOrder(t)
+
+	aggregate := NewOrderAggregate(nil, 0)
+	assert.Nil(t, aggregate.Load(NewOrderEvents(order)...))
+	assert.Empty(t, aggregate.Changes())
+
+	paid, _ := order.Pay("user-1")
+	assert.Nil(t, aggregate.Transition(paid))
+	assert.Equal(t, OrderPaid, aggregate.Order.Status)
+	assert.Equal(t, "OrderPaid", aggregate.Changes()[0].EventName())
+
+	// Items can only be added to pending orders.
+	item := order.Items[0]
+	assert.ErrorIs(t, aggregate.Record(ItemAdded{Item: item}), ErrInvalidOrderTransition)
+
+	// A transition from a status the order has left is refused, and
+	// leaves the aggregate as it was.
+	cancelled := &OrderTransition{From: OrderPending, To: OrderCancelled}
+	assert.ErrorIs(t, aggregate.Transition(cancelled), ErrInvalidOrderTransition)
+	assert.Equal(t, OrderPaid, aggregate.Order.Status)
+	assert.Len(t, aggregate.Order.Transitions, 1)
+	assert.Equal(t, 3, aggregate.Version)
+}
+
+func TestOrderAggregateFromSnapshot(t *testing.T) {
+	order := newTestOrder(t)
+	paid, _ := order.Pay("user-1")
+	shipped, _ := order.Ship("user-1")
+
+	full := NewOrderAggregate(nil, 0)
+	assert.Nil(t, full.Load(NewOrderEvents(order)...))
+
+	// A snapshot taken after the payment, then the shipment.
+	snapshot := NewOrderAggregate(nil, 0)
+	assert.Nil(t, snapshot.Load(OrderCreated{OrderID: order.ID, CreatedAt: order.CreatedAt}, ItemAdded{Item: order.Items[0]}, OrderTransitioned{Transition: *paid}))
+
+	data, err := json.Marshal(snapshot.Order)
+	assert.Nil(t, err)
+
+	var state Order
+	assert.Nil(t, json.Unmarshal(data, &state))
+
+	resumed := NewOrderAggregate(&state, snapshot.Version)
+	assert.Nil(t, resumed.Load(OrderTransitioned{Transition: *shipped}))
+
+	assert.Equal(t, full.Version, resumed.Version)
+	assert.Equal(t, full.Order.Status, resumed.Order.Status)
+	assert.Equal(t, full.Order.Total, resumed.Order.Total)
+	assert.Equal(t, 4, resumed.Order.Version)
+}
+
+func TestUnmarshalOrderEvent(t *testing.T) {
+	order := newTestOrder(t)
+	transition, _ := order.Cancel("user-1")
+
+	for _, event := range NewOrderEvents(order) {
+		data, err := json.Marshal(event)
+		assert.Nil(t, err)
+
+		decoded, err := UnmarshalOrderEvent(event.EventName(), data)
+		assert.Nil(t, err)
+		assert.Equal(t, event.EventName(), decoded.EventName())
+	}
+
+	data, _ := json.Marshal(OrderTransitioned{Transition: *transition})
+	decoded, err := UnmarshalOrderEvent("OrderCancelled", data)
+	assert.Nil(t, err)
+	assert.Equal(t, OrderCancelled, decoded.(OrderTransitioned).Transition.To)
+
+	_, err = UnmarshalOrderEvent("OrderPaid", data)
+	assert.ErrorIs(t, err, ErrUnknownOrderEvent)
+
+	_, err = UnmarshalOrderEvent("OrderArchived", []byte(`{}`))
+	assert.ErrorIs(t, err, ErrUnknownOrderEvent)
+}
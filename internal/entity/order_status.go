@@ -0,0 +1,111 @@
+package entity
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/rgoncalvesrr/fullcycle-clean-arch/pkg/entity"
+)
+
+var (
+	ErrInvalidOrderStatus     = errors.New("invalid order status")
+	ErrInvalidOrderTransition = errors.New("invalid order transition")
+)
+
+type OrderStatus string
+
+const (
+	OrderPending   OrderStatus = "pending"
+	OrderPaid      OrderStatus = "paid"
+	OrderShipped   OrderStatus = "shipped"
+	OrderDelivered OrderStatus = "delivered"
+	OrderCancelled OrderStatus = "cancelled"
+	OrderRefunded  OrderStatus = "refunded"
+)
+
+// orderTransitions lists the statuses each status can move to. Cancelled
+// and refunded orders are final.
+var orderTransitions = map[OrderStatus][]OrderStatus{
+	OrderPending:   {OrderPaid, OrderCancelled},
+	OrderPaid:      {OrderShipped, OrderRefunded},
+	OrderShipped:   {OrderDelivered},
+	OrderDelivered: {OrderRefunded},
+}
+
+func (s OrderStatus) IsValid() bool {
+	switch s {
+	case OrderPending, OrderPaid, OrderShipped, OrderDelivered, OrderCancelled, OrderRefunded:
+		return true
+	}
+
+	return false
+}
+
+func (s OrderStatus) CanTransitionTo(to OrderStatus) bool {
+	for _, allowed := range orderTransitions[s] {
+		if allowed == to {
+			return true
+		}
+	}
+
+	return false
+}
+
+// OrderTransition is an append-only record of an order status change.
+type OrderTransition struct {
+	ID        entity.ID   `json:"id"`
+	OrderID   entity.ID   `json:"order_id" gorm:"index"`
+	From      OrderStatus `json:"from" gorm:"size:16"`
+	To        OrderStatus `json:"to" gorm:"size:16"`
+	Actor     string      `json:"actor" gorm:"size:36"`
+	CreatedAt time.Time   `json:"created_at"`
+}
+
+// TransitionTo moves the order to status, failing with
+// ErrInvalidOrderTransition when the current status doesn't allow it. The
+// returned transition is also appended to the order.
+func (o *Order) TransitionTo(status OrderStatus, actor string) (*OrderTransition, error) {
+	if !status.IsValid() {
+		return nil, ErrInvalidOrderStatus
+	}
+
+	if !o.Status.CanTransitionTo(status) {
+		return nil, fmt.Errorf("%w: %s to %s", ErrInvalidOrderTransition, o.Status, status)
+	}
+
+	t := &OrderTransition{
+		ID:        entity.NewID(),
+		OrderID:   o.ID,
+		From:      o.Status,
+		To:        status,
+		Actor:     actor,
+		CreatedAt: time.Now(),
+	}
+
+	o.Status = status
+	o.UpdatedAt = t.CreatedAt
+	o.Transitions = append(o.Transitions, *t)
+
+	return t, nil
+}
+
+func (o *Order) Pay(actor string) (*OrderTransition, error) {
+	return o.TransitionTo(OrderPaid, actor)
+}
+
+func (o *Order) Ship(actor string) (*OrderTransition, error) {
+	return o.TransitionTo(OrderShipped, actor)
+}
+
+func (o *Order) Deliver(actor string) (*OrderTransition, error) {
+	return o.TransitionTo(OrderDelivered, actor)
+}
+
+func (o *Order) Cancel(actor string) (*OrderTransition, error) {
+	return o.TransitionTo(OrderCancelled, actor)
+}
+
+func (o *Order) Refund(actor string) (*OrderTransition, error) {
+	return o.TransitionTo(OrderRefunded, actor)
+}
@@ -0,0 +1,71 @@
+package entity
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestOrder(t *testing.T) *Order {
+	pen, _ := NewProduct("PEN", "Caneta", MustParseMoney("2.50", "BRL"))
+	item, _ := NewOrderItem(pen, 1)
+
+	order, err := NewOrder([]OrderItem{item})
+	assert.Nil(t, err)
+
+	return order
+}
+
+func TestNewOrderIsPending(t *testing.T) {
+	order := newTestOrder(t)
+
+	assert.Equal(t, OrderPending, order.Status)
+	assert.Empty(t, order.Transitions)
+}
+
+func TestOrderLifecycle(t *testing.T) {
+	order := newTestOrder(t)
+
+	transition, err := order.Pay("user-1")
+	assert.Nil(t, err)
+	assert.Equal(t, OrderPending, transition.From)
+	assert.Equal(t, OrderPaid, transition.To)
+	assert.Equal(t, "user-1", transition.Actor)
+	assert.Equal(t, order.ID, transition.OrderID)
+	assert.False(t, transition.CreatedAt.IsZero())
+
+	_, err = order.Ship("user-2")
+	assert.Nil(t, err)
+
+	_, err = order.Deliver("user-2")
+	assert.Nil(t, err)
+
+	_, err = order.Refund("user-1")
+	assert.Nil(t, err)
+
+	assert.Equal(t, OrderRefunded, order.Status)
+	assert.Len(t, order.Transitions, 4)
+	assert.Nil(t, order.Validate())
+}
+
+func TestOrderInvalidTransitions(t *testing.T) {
+	order := newTestOrder(t)
+
+	_, err := order.Ship("user-1")
+	assert.ErrorIs(t, err, ErrInvalidOrderTransition)
+	assert.Equal(t, OrderPending, order.Status)
+
+	_, err = order.Cancel("user-1")
+	assert.Nil(t, err)
+
+	for _, status := range []OrderStatus{OrderPending, OrderPaid, OrderShipped, OrderDelivered, OrderCancelled, OrderRefunded} {
+		_, err = order.TransitionTo(status, "user-1")
+		assert.ErrorIs(t, err, ErrInvalidOrderTransition)
+	}
+
+	_, err = order.TransitionTo("lost", "user-1")
+	assert.ErrorIs(t, err, ErrInvalidOrderStatus)
+
+	assert.Equal(t, OrderCancelled, order.Status)
+	assert.Len(t, order.Transitions, 1)
+}
@@ -0,0 +1,64 @@
+package entity
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewOrder(t *testing.T) {
+	pen, _ := NewProduct("PEN", "Caneta", MustParseMoney("2.50", "BRL"))
+	book, _ := NewProduct("BOOK", "Caderno", MustParseMoney("15.90", "BRL"))
+
+	penItem, err := NewOrderItem(pen, 4)
+	assert.Nil(t, err)
+	assert.Equal(t, MustParseMoney("10", "BRL"), penItem.Total)
+	assert.Equal(t, "PEN", penItem.SKU)
+
+	bookItem, err := NewOrderItem(book, 1)
+	assert.Nil(t, err)
+
+	order, err := NewOrder([]OrderItem{penItem, bookItem})
+
+	assert.Nil(t, err)
+	assert.NotEmpty(t, order.ID)
+	assert.Equal(t, MustParseMoney("25.90", "BRL"), order.Total)
+	assert.Equal(t, order.ID, order.Items[0].OrderID)
+	assert.Equal(t, order.ID, order.Items[1].OrderID)
+}
+
+func TestNewOrderItemValidation(t *testing.T) {
+	pen, _ := NewProduct("PEN", "Caneta", MustParseMoney("2.50", "BRL"))
+
+	_, err := NewOrderItem(pen, 0)
+	assert.ErrorIs(t, err, ErrInvalidQuantity)
+
+	pen.Archive()
+	_, err = NewOrderItem(pen, 1)
+	assert.ErrorIs(t, err, ErrProductUnavailable)
+}
+
+func TestNewOrderValidation(t *testing.T) {
+	_, err := NewOrder(nil)
+	assert.ErrorIs(t, err, ErrOrderHasNoItems)
+
+	pen, _ := NewProduct("PEN", "Caneta", MustParseMoney("2.50", "BRL"))
+	book, _ := NewProduct("BOOK", "Book", MustParseMoney("15.90", "USD"))
+
+	penItem, _ := NewOrderItem(pen, 1)
+	bookItem, _ := NewOrderItem(book, 1)
+
+	_, err = NewOrder([]OrderItem{penItem, bookItem})
+	assert.ErrorIs(t, err, ErrCurrencyMismatch)
+}
+
+func TestOrderValidateTotal(t *testing.T) {
+	pen, _ := NewProduct("PEN", "Caneta", MustParseMoney("2.50", "BRL"))
+	item, _ := NewOrderItem(pen, 2)
+
+	order, err := NewOrder([]OrderItem{item})
+	assert.Nil(t, err)
+
+	order.Total = MustParseMoney("1", "BRL")
+	assert.ErrorIs(t, order.Validate(), ErrInvalidOrderTotal)
+}
@@ -2,32 +2,69 @@ package entity
 
 import (
 	"errors"
+	"regexp"
 	"time"
 
 	"github.com/rgoncalvesrr/fullcycle-clean-arch/pkg/entity"
+	"gorm.io/gorm"
 )
 
 var (
-	ErrIDIsRequired    = errors.New("id is required")
-	ErrInvalidID       = errors.New("invalid id")
-	ErrNameIsRequired  = errors.New("name is required")
-	ErrPriceIsRequired = errors.New("price is required")
-	ErrInvalidPrice    = errors.New("invalid price")
+	ErrIDIsRequired         = errors.New("id is required")
+	ErrInvalidID            = errors.New("invalid id")
+	ErrSKUIsRequired        = errors.New("sku is required")
+	ErrInvalidSKU           = errors.New("invalid sku")
+	ErrNameIsRequired       = errors.New("name is required")
+	ErrDescriptionIsTooLong = errors.New("description is too long")
+	ErrCategoryIsTooLong    = errors.New("category is too long")
+	ErrPriceIsRequired      = errors.New("price is required")
+	ErrInvalidPrice         = errors.New("invalid price")
+	ErrInvalidStock         = errors.New("invalid stock")
+	ErrInvalidProductStatus = errors.New("invalid product status")
 )
 
+type ProductStatus string
+
+const (
+	ProductActive   ProductStatus = "active"
+	ProductArchived ProductStatus = "archived"
+)
+
+const (
+	maxDescriptionLength = 2000
+	maxCategoryLength    = 100
+)
+
+var skuPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]{0,63}$`)
+
 type Product struct {
-	ID        entity.ID `json:"id"`
-	Name      string    `json:"name"`
-	Price     float64   `json:"price"`
-	CreatedAt time.Time `json:"created_at"`
+	ID          entity.ID      `json:"id"`
+	SKU         string         `json:"sku" gorm:"size:64;uniqueIndex"`
+	Name        string         `json:"name"`
+	Description string         `json:"description"`
+	CategoryID  *entity.ID     `json:"category_id" gorm:"index"`
+	Price       Money          `json:"price" gorm:"embedded;embeddedPrefix:price_"`
+	Stock       int            `json:"stock"`
+	Status      ProductStatus  `json:"status" gorm:"size:20;index"`
+	Version     int            `json:"version" gorm:"not null;default:1"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+	DeletedAt   gorm.DeletedAt `json:"deleted_at" gorm:"index" swaggertype:"string" format:"date-time"`
+	DeletedBy   string         `json:"deleted_by,omitempty" gorm:"size:36"`
 }
 
-func NewProduct(name string, price float64) (*Product, error) {
+func NewProduct(sku string, name string, price Money) (*Product, error) {
+	now := time.Now()
+
 	p := &Product{
 		ID:        entity.NewID(),
+		SKU:       sku,
 		Name:      name,
 		Price:     price,
-		CreatedAt: time.Now(),
+		Status:    ProductActive,
+		Version:   1,
+		CreatedAt: now,
+		UpdatedAt: now,
 	}
 
 	err := p.Validate()
@@ -48,17 +85,57 @@ func (p *Product) Validate() error {
 		return ErrInvalidID
 	}
 
+	if p.SKU == "" {
+		return ErrSKUIsRequired
+	}
+
+	if !skuPattern.MatchString(p.SKU) {
+		return ErrInvalidSKU
+	}
+
 	if p.Name == "" {
 		return ErrNameIsRequired
 	}
 
-	if p.Price == 0 {
+	if len(p.Description) > maxDescriptionLength {
+		return ErrDescriptionIsTooLong
+	}
+
+	if !p.Price.IsValid() {
+		return ErrInvalidCurrency
+	}
+
+	if p.Price.IsZero() {
 		return ErrPriceIsRequired
 	}
 
-	if p.Price < 0 {
+	if p.Price.IsNegative() {
 		return ErrInvalidPrice
 	}
 
+	if p.Stock < 0 {
+		return ErrInvalidStock
+	}
+
+	if !p.Status.IsValid() {
+		return ErrInvalidProductStatus
+	}
+
 	return nil
 }
+
+func (p *Product) Archive() {
+	p.Status = ProductArchived
+}
+
+func (p *Product) Activate() {
+	p.Status = ProductActive
+}
+
+func (p *Product) IsDeleted() bool {
+	return p.DeletedAt.Valid
+}
+
+func (s ProductStatus) IsValid() bool {
+	return s == ProductActive || s == ProductArchived
+}
@@ -0,0 +1,125 @@
+package entity
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/rgoncalvesrr/fullcycle-clean-arch/pkg/entity"
+)
+
+var (
+	ErrInvalidHistoryAction = errors.New("invalid history action")
+	ErrProductIsRequired    = errors.New("product is required")
+)
+
+type ProductHistoryAction string
+
+const (
+	ProductCreated  ProductHistoryAction = "created"
+	ProductUpdated  ProductHistoryAction = "updated"
+	ProductDeleted  ProductHistoryAction = "deleted"
+	ProductRestored ProductHistoryAction = "restored"
+	// ProductBaseline records the state of products that existed before
+	// history was kept.
+	ProductBaseline ProductHistoryAction = "baseline"
+)
+
+func (a ProductHistoryAction) IsValid() bool {
+	switch a {
+	case ProductCreated, ProductUpdated, ProductDeleted, ProductRestored, ProductBaseline:
+		return true
+	}
+
+	return false
+}
+
+// ProductHistory is an append-only record of a change to a product, with its
+// state before and after the change as JSON snapshots. Before is empty for
+// creations and baselines; the After snapshot of a deletion is the trashed
+// product.
+type ProductHistory struct {
+	ID        entity.ID            `json:"id"`
+	ProductID entity.ID            `json:"product_id" gorm:"index:idx_product_histories_product_version"`
+	Version   int                  `json:"version" gorm:"index:idx_product_histories_product_version"`
+	Action    ProductHistoryAction `json:"action" gorm:"size:20"`
+	Actor     string               `json:"actor" gorm:"size:36"`
+	Before    []byte               `json:"before"`
+	After     []byte               `json:"after"`
+	CreatedAt time.Time            `json:"created_at" gorm:"index"`
+}
+
+func NewProductHistory(action ProductHistoryAction, actor string, before *Product, after *Product) (*ProductHistory, error) {
+	h := &ProductHistory{
+		ID:        entity.NewID(),
+		Action:    action,
+		Actor:     actor,
+		CreatedAt: time.Now(),
+	}
+
+	var err error
+
+	for _, s := range []struct {
+		product  *Product
+		snapshot *[]byte
+	}{{before, &h.Before}, {after, &h.After}} {
+		if s.product == nil {
+			continue
+		}
+
+		h.ProductID = s.product.ID
+		h.Version = s.product.Version
+
+		if *s.snapshot, err = json.Marshal(s.product); err != nil {
+			return nil, err
+		}
+	}
+
+	if err = h.Validate(); err != nil {
+		return nil, err
+	}
+
+	return h, nil
+}
+
+func (h *ProductHistory) Validate() error {
+	if h.ID.String() == "" {
+		return ErrIDIsRequired
+	}
+
+	if !h.Action.IsValid() {
+		return ErrInvalidHistoryAction
+	}
+
+	if h.Before == nil && h.After == nil {
+		return ErrProductIsRequired
+	}
+
+	return nil
+}
+
+// BeforeProduct decodes the snapshot taken before the change, returning nil
+// when there is none.
+func (h *ProductHistory) BeforeProduct() (*Product, error) {
+	return decodeSnapshot(h.Before)
+}
+
+// AfterProduct decodes the snapshot taken after the change, returning nil
+// when there is none.
+func (h *ProductHistory) AfterProduct() (*Product, error) {
+	return decodeSnapshot(h.After)
+}
+
+func decodeSnapshot(snapshot []byte) (*Product, error) {
+	if len(snapshot) == 0 {
+		return nil, nil
+	}
+
+	var p Product
+
+	if err := json.Unmarshal(snapshot, &p); err != nil {
+		return nil, err
+	}
+
+	return &p, nil
+}
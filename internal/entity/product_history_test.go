@@ -0,0 +1,48 @@
+package entity
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewProductHistory(t *testing.T) {
+	before, _ := NewProduct("PRD-1", "Produto", MustParseMoney("10", "BRL"))
+
+	after := *before
+	after.Price = MustParseMoney("12.50", "BRL")
+	after.Version = 2
+
+	h, err := NewProductHistory(ProductUpdated, "user-1", before, &after)
+
+	assert.Nil(t, err)
+	assert.Equal(t, before.ID, h.ProductID)
+	assert.Equal(t, 2, h.Version)
+	assert.Equal(t, "user-1", h.Actor)
+
+	b, err := h.BeforeProduct()
+	assert.Nil(t, err)
+	assert.Equal(t, "10.00", b.Price.String())
+
+	a, err := h.AfterProduct()
+	assert.Nil(t, err)
+	assert.Equal(t, "12.50", a.Price.String())
+	assert.Equal(t, before.ID, a.ID)
+}
+
+func TestNewProductHistoryWithoutSnapshots(t *testing.T) {
+	created, _ := NewProduct("PRD-1", "Produto", MustParseMoney("10", "BRL"))
+
+	h, err := NewProductHistory(ProductCreated, "user-1", nil, created)
+	assert.Nil(t, err)
+
+	b, err := h.BeforeProduct()
+	assert.Nil(t, err)
+	assert.Nil(t, b)
+
+	_, err = NewProductHistory(ProductDeleted, "user-1", nil, nil)
+	assert.ErrorIs(t, err, ErrProductIsRequired)
+
+	_, err = NewProductHistory("renamed", "user-1", nil, created)
+	assert.ErrorIs(t, err, ErrInvalidHistoryAction)
+}
@@ -1,41 +1,92 @@
 package entity
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 )
 
 func TestNewProduct(t *testing.T) {
+	expectedSKU := "PRD-001"
 	expectedName := "Produto 1"
-	expectedPrice := 10.50
-	p, err := NewProduct(expectedName, expectedPrice)
+	expectedPrice := MustParseMoney("10.50", "BRL")
+	p, err := NewProduct(expectedSKU, expectedName, expectedPrice)
 
 	assert.Nil(t, err)
 	assert.NotNil(t, p)
 	assert.NotEmpty(t, p.ID)
+	assert.Equal(t, expectedSKU, p.SKU)
 	assert.Equal(t, expectedName, p.Name)
 	assert.Equal(t, expectedPrice, p.Price)
+	assert.Equal(t, ProductActive, p.Status)
+	assert.Equal(t, 0, p.Stock)
+	assert.Equal(t, 1, p.Version)
+	assert.Equal(t, p.CreatedAt, p.UpdatedAt)
+}
+
+func TestProductWhenSKUIsRequired(t *testing.T) {
+	p, err := NewProduct("", "Produto 1", MustParseMoney("10.50", "BRL"))
+
+	assert.Nil(t, p)
+	assert.ErrorIs(t, err, ErrSKUIsRequired)
+}
+
+func TestProductWhenSKUIsInvalid(t *testing.T) {
+	p, err := NewProduct("PRD 001", "Produto 1", MustParseMoney("10.50", "BRL"))
+
+	assert.Nil(t, p)
+	assert.ErrorIs(t, err, ErrInvalidSKU)
 }
 
 func TestProductWhenNameIsRequired(t *testing.T) {
-	expectedPrice := 10.50
-	p, err := NewProduct("", expectedPrice)
+	expectedPrice := MustParseMoney("10.50", "BRL")
+	p, err := NewProduct("PRD-001", "", expectedPrice)
 
 	assert.Nil(t, p)
 	assert.ErrorIs(t, err, ErrNameIsRequired)
 }
 
 func TestProductWhenPriceIsRequired(t *testing.T) {
-	p, err := NewProduct("Produto 1", 0)
+	p, err := NewProduct("PRD-001", "Produto 1", Money{Currency: "BRL"})
 
 	assert.Nil(t, p)
 	assert.ErrorIs(t, err, ErrPriceIsRequired)
 }
 
 func TestProductWhenPriceIsInvalid(t *testing.T) {
-	p, err := NewProduct("Produto 1", -1)
+	p, err := NewProduct("PRD-001", "Produto 1", MustParseMoney("-1", "BRL"))
 
 	assert.Nil(t, p)
 	assert.ErrorIs(t, err, ErrInvalidPrice)
 }
+
+func TestProductWhenCurrencyIsInvalid(t *testing.T) {
+	p, err := NewProduct("PRD-001", "Produto 1", Money{Amount: 1050, Currency: "XYZ"})
+
+	assert.Nil(t, p)
+	assert.ErrorIs(t, err, ErrInvalidCurrency)
+}
+
+func TestProductValidate(t *testing.T) {
+	p, err := NewProduct("PRD-001", "Produto 1", MustParseMoney("10.50", "BRL"))
+	assert.Nil(t, err)
+
+	p.Stock = -1
+	assert.ErrorIs(t, p.Validate(), ErrInvalidStock)
+	p.Stock = 10
+
+	p.Description = strings.Repeat("a", 2001)
+	assert.ErrorIs(t, p.Validate(), ErrDescriptionIsTooLong)
+	p.Description = "Descrição"
+
+	p.Status = "deleted"
+	assert.ErrorIs(t, p.Validate(), ErrInvalidProductStatus)
+
+	p.Archive()
+	assert.Nil(t, p.Validate())
+	assert.Equal(t, ProductArchived, p.Status)
+
+	p.Activate()
+	assert.Equal(t, ProductActive, p.Status)
+}
@@ -0,0 +1,34 @@
+package entity
+
+import "time"
+
+// StoredEvent is a row of the append-only event log. Sequence is assigned
+// by the database; the payload is kept as JSON. Events of a stream have its
+// name and their version in it, and a stream can't have two events with the
+// same version; the other events leave both null.
+type StoredEvent struct {
+	Sequence      int64   `gorm:"primaryKey;autoIncrement"`
+	EventName     string  `gorm:"size:100;index"`
+	EventKey      string  `gorm:"size:255;index"`
+	Stream        *string `gorm:"size:255;uniqueIndex:idx_stored_events_stream"`
+	StreamVersion *int    `gorm:"uniqueIndex:idx_stored_events_stream"`
+	Payload       []byte
+	OccurredAt    time.Time `gorm:"index"`
+	RecordedAt    time.Time
+}
+
+// EventSnapshot is the latest snapshot of a stream's state.
+type EventSnapshot struct {
+	Stream    string `gorm:"primaryKey;size:255"`
+	Version   int
+	State     []byte
+	CreatedAt time.Time
+}
+
+// EventCheckpoint is the sequence of the last stored event a consumer
+// handled.
+type EventCheckpoint struct {
+	Consumer  string `gorm:"primaryKey;size:100"`
+	Sequence  int64
+	UpdatedAt time.Time
+}
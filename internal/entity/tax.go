@@ -0,0 +1,180 @@
+package entity
+
+import (
+	"encoding/json"
+	"errors"
+	"math/big"
+	"strings"
+)
+
+var (
+	ErrInvalidTaxRate      = errors.New("invalid tax rate")
+	ErrInvalidRoundingMode = errors.New("invalid rounding mode")
+)
+
+// TaxPolicy works out the tax due on an order line sold to a region.
+type TaxPolicy interface {
+	Tax(item *OrderItem, region string) (ItemTax, error)
+}
+
+// ItemTax is the tax on an order line. Rate is a percentage, e.g. "18.00".
+type ItemTax struct {
+	Rate   string
+	Amount Money
+}
+
+// TaxRule sets the tax rate of the products of a category, sold to a
+// region, or both. Empty fields match anything; exempt rules charge no tax.
+type TaxRule struct {
+	Category string `json:"category"`
+	Region   string `json:"region"`
+	Rate     string `json:"rate"`
+	Exempt   bool   `json:"exempt"`
+}
+
+// CategoryAncestry lists the ids of the categories above a category, its
+// parent first.
+type CategoryAncestry func(categoryID string) ([]string, error)
+
+// RuleTaxPolicy applies the most specific rule matching each order line:
+// category and region beat category alone, which beats region alone, which
+// beats a catch-all rule. Rules on a category also apply to its
+// subcategories, the nearest category with a rule winning, when Ancestors is
+// set. Among equally specific rules the first one wins, and lines no rule
+// matches aren't taxed.
+type RuleTaxPolicy struct {
+	Ancestors CategoryAncestry
+
+	rules    []TaxRule
+	rates    []*big.Rat
+	rounding RoundingMode
+}
+
+type ruleTaxPolicyJSON struct {
+	Rounding string    `json:"rounding"`
+	Rules    []TaxRule `json:"rules"`
+}
+
+func NewRuleTaxPolicy(rules []TaxRule, rounding RoundingMode) (*RuleTaxPolicy, error) {
+	p := &RuleTaxPolicy{
+		rules:    make([]TaxRule, 0, len(rules)),
+		rates:    make([]*big.Rat, 0, len(rules)),
+		rounding: rounding,
+	}
+
+	for _, rule := range rules {
+		rate := new(big.Rat)
+
+		if !rule.Exempt {
+			if _, ok := rate.SetString(rule.Rate); !ok || rate.Sign() < 0 {
+				return nil, ErrInvalidTaxRate
+			}
+		}
+
+		rule.Region = normalizeRegion(rule.Region)
+
+		p.rules = append(p.rules, rule)
+		p.rates = append(p.rates, rate)
+	}
+
+	return p, nil
+}
+
+// ParseRuleTaxPolicy reads a policy from JSON such as
+//
+//	{"rounding": "half_up", "rules": [{"region": "SP", "rate": "18"}]}
+func ParseRuleTaxPolicy(data []byte) (*RuleTaxPolicy, error) {
+	var v ruleTaxPolicyJSON
+
+	if err := json.Unmarshal(data, &v); err != nil {
+		return nil, err
+	}
+
+	rounding, err := ParseRoundingMode(v.Rounding)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewRuleTaxPolicy(v.Rules, rounding)
+}
+
+func (p *RuleTaxPolicy) Tax(item *OrderItem, region string) (ItemTax, error) {
+	region = normalizeRegion(region)
+
+	var categories []string
+
+	if item.CategoryID != nil {
+		categories = append(categories, item.CategoryID.String())
+
+		if p.Ancestors != nil {
+			ancestors, err := p.Ancestors(item.CategoryID.String())
+			if err != nil {
+				return ItemTax{}, err
+			}
+
+			categories = append(categories, ancestors...)
+		}
+	}
+
+	best := p.match(categories, region)
+
+	rate := new(big.Rat)
+	if best >= 0 {
+		rate = p.rates[best]
+	}
+
+	return ItemTax{
+		Rate:   rate.FloatString(2),
+		Amount: item.Total.MulRat(new(big.Rat).Quo(rate, big.NewRat(100, 1)), p.rounding),
+	}, nil
+}
+
+// match returns the index of the rule for a line of the first of categories
+// that has one, falling back to the rules on no category, or -1.
+func (p *RuleTaxPolicy) match(categories []string, region string) int {
+	for _, category := range append(categories, "") {
+		best, bestScore := -1, -1
+
+		for i, rule := range p.rules {
+			if rule.Category != category || (rule.Region != "" && rule.Region != region) {
+				continue
+			}
+
+			score := 0
+			if rule.Region != "" {
+				score++
+			}
+
+			if score > bestScore {
+				best, bestScore = i, score
+			}
+		}
+
+		if best >= 0 {
+			return best
+		}
+	}
+
+	return -1
+}
+
+// ParseRoundingMode reads half_up, half_even, down or up. Empty means
+// half_up.
+func ParseRoundingMode(s string) (RoundingMode, error) {
+	switch strings.ToLower(s) {
+	case "", "half_up":
+		return RoundHalfUp, nil
+	case "half_even":
+		return RoundHalfEven, nil
+	case "down":
+		return RoundDown, nil
+	case "up":
+		return RoundUp, nil
+	}
+
+	return 0, ErrInvalidRoundingMode
+}
+
+func normalizeRegion(region string) string {
+	return strings.ToUpper(strings.TrimSpace(region))
+}
@@ -0,0 +1,151 @@
+package entity
+
+import (
+	"testing"
+
+	"github.com/rgoncalvesrr/fullcycle-clean-arch/pkg/entity"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRuleTaxPolicy(t *testing.T) {
+	books := entity.NewID()
+	food := entity.NewID()
+
+	policy, err := NewRuleTaxPolicy([]TaxRule{
+		{Rate: "10"},
+		{Region: "SP", Rate: "18"},
+		{Category: food.String(), Rate: "7.5"},
+		{Category: food.String(), Region: "sp", Rate: "12"},
+		{Category: books.String(), Exempt: true},
+	}, RoundHalfUp)
+	assert.Nil(t, err)
+
+	cases := []struct {
+		category *entity.ID
+		region   string
+		rate     string
+		tax      string
+	}{
+		{nil, "RJ", "10.00", "1.00"},
+		{nil, "SP", "18.00", "1.80"},
+		{&food, "RJ", "7.50", "0.75"},
+		{&food, " sp ", "12.00", "1.20"},
+		{&books, "SP", "0.00", "0.00"},
+	}
+
+	for _, c := range cases {
+		item := OrderItem{CategoryID: c.category, Total: MustParseMoney("10", "BRL")}
+
+		tax, err := policy.Tax(&item, c.region)
+		assert.Nil(t, err)
+		assert.Equal(t, c.rate, tax.Rate)
+		assert.Equal(t, MustParseMoney(c.tax, "BRL"), tax.Amount)
+	}
+}
+
+func TestRuleTaxPolicyAppliesToSubcategories(t *testing.T) {
+	food := entity.NewID()
+	drinks := entity.NewID()
+	juices := entity.NewID()
+	wines := entity.NewID()
+
+	parents := map[string]string{
+		drinks.String(): food.String(),
+		juices.String(): drinks.String(),
+		wines.String():  drinks.String(),
+	}
+
+	policy, err := NewRuleTaxPolicy([]TaxRule{
+		{Region: "SP", Rate: "18"},
+		{Category: food.String(), Rate: "7"},
+		{Category: food.String(), Region: "SP", Rate: "8"},
+		{Category: wines.String(), Rate: "25"},
+	}, RoundHalfUp)
+	assert.Nil(t, err)
+
+	policy.Ancestors = func(id string) ([]string, error) {
+		var ancestors []string
+		for parent, ok := parents[id]; ok; parent, ok = parents[parent] {
+			ancestors = append(ancestors, parent)
+		}
+		return ancestors, nil
+	}
+
+	cases := []struct {
+		category entity.ID
+		region   string
+		rate     string
+	}{
+		{juices, "RJ", "7.00"},
+		{juices, "SP", "8.00"},
+		{drinks, "RJ", "7.00"},
+		{wines, "SP", "25.00"},
+	}
+
+	for _, c := range cases {
+		item := OrderItem{CategoryID: &c.category, Total: MustParseMoney("10", "BRL")}
+
+		tax, err := policy.Tax(&item, c.region)
+		assert.Nil(t, err)
+		assert.Equal(t, c.rate, tax.Rate)
+	}
+
+	// Without the ancestry only exact categories match.
+	policy.Ancestors = nil
+
+	tax, err := policy.Tax(&OrderItem{CategoryID: &juices, Total: MustParseMoney("10", "BRL")}, "SP")
+	assert.Nil(t, err)
+	assert.Equal(t, "18.00", tax.Rate)
+}
+
+func TestRuleTaxPolicyWithoutRules(t *testing.T) {
+	policy, err := NewRuleTaxPolicy(nil, RoundHalfUp)
+	assert.Nil(t, err)
+
+	tax, err := policy.Tax(&OrderItem{Total: MustParseMoney("10", "BRL")}, "SP")
+	assert.Nil(t, err)
+	assert.Equal(t, "0.00", tax.Rate)
+	assert.Equal(t, MustParseMoney("0", "BRL"), tax.Amount)
+}
+
+func TestParseRuleTaxPolicy(t *testing.T) {
+	policy, err := ParseRuleTaxPolicy([]byte(`{"rounding": "down", "rules": [{"rate": "17"}]}`))
+	assert.Nil(t, err)
+
+	tax, _ := policy.Tax(&OrderItem{Total: MustParseMoney("0.99", "BRL")}, "")
+	assert.Equal(t, MustParseMoney("0.16", "BRL"), tax.Amount)
+
+	_, err = ParseRuleTaxPolicy([]byte(`{"rounding": "sideways"}`))
+	assert.ErrorIs(t, err, ErrInvalidRoundingMode)
+
+	_, err = ParseRuleTaxPolicy([]byte(`{"rules": [{"rate": "-1"}]}`))
+	assert.ErrorIs(t, err, ErrInvalidTaxRate)
+
+	_, err = ParseRuleTaxPolicy([]byte(`{"rules": [{"rate": "abc"}]}`))
+	assert.ErrorIs(t, err, ErrInvalidTaxRate)
+}
+
+func TestOrderApplyTax(t *testing.T) {
+	pen, _ := NewProduct("PEN", "Caneta", MustParseMoney("2.50", "BRL"))
+	book, _ := NewProduct("BOOK", "Caderno", MustParseMoney("15.90", "BRL"))
+	penItem, _ := NewOrderItem(pen, 4)
+	bookItem, _ := NewOrderItem(book, 1)
+
+	order, err := NewOrder([]OrderItem{penItem, bookItem})
+	assert.Nil(t, err)
+	assert.Equal(t, MustParseMoney("25.90", "BRL"), order.Subtotal)
+	assert.Equal(t, MustParseMoney("0", "BRL"), order.Tax)
+
+	policy, _ := NewRuleTaxPolicy([]TaxRule{{Region: "SP", Rate: "18"}}, RoundHalfUp)
+
+	assert.Nil(t, order.ApplyTax(policy, "sp"))
+	assert.Equal(t, "SP", order.Region)
+	assert.Equal(t, MustParseMoney("1.80", "BRL"), order.Items[0].Tax)
+	assert.Equal(t, MustParseMoney("2.86", "BRL"), order.Items[1].Tax)
+	assert.Equal(t, MustParseMoney("25.90", "BRL"), order.Subtotal)
+	assert.Equal(t, MustParseMoney("4.66", "BRL"), order.Tax)
+	assert.Equal(t, MustParseMoney("30.56", "BRL"), order.Total)
+
+	order.Tax = MustParseMoney("1", "BRL")
+	assert.ErrorIs(t, order.Validate(), ErrInvalidOrderTotal)
+}
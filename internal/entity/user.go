@@ -10,6 +10,7 @@ type User struct {
 	Name     string    `json:"name"`
 	EMail    string    `json:"email"`
 	Password string    `json:"-"`
+	Admin    bool      `json:"admin"`
 }
 
 func NewUser(name string, email string, password string) (*User, error) {
@@ -0,0 +1,44 @@
+package event
+
+import "time"
+
+// Names of the events raised on category changes.
+const (
+	CategoryCreated = "category.created"
+	CategoryUpdated = "category.updated"
+	CategoryDeleted = "category.deleted"
+)
+
+// CategoryChanged is raised once a category change is stored, named after
+// the change, such as "category.created". Its key is the category ID.
+type CategoryChanged struct {
+	Name       string
+	CategoryID string
+	Payload    any
+	DateTime   time.Time
+}
+
+func NewCategoryChanged(name, categoryID string, payload any) *CategoryChanged {
+	return &CategoryChanged{
+		Name:       name,
+		CategoryID: categoryID,
+		Payload:    payload,
+		DateTime:   time.Now(),
+	}
+}
+
+func (e *CategoryChanged) GetName() string {
+	return e.Name
+}
+
+func (e *CategoryChanged) GetKey() string {
+	return e.CategoryID
+}
+
+func (e *CategoryChanged) GetDateTime() time.Time {
+	return e.DateTime
+}
+
+func (e *CategoryChanged) GetPayLoad() any {
+	return e.Payload
+}
@@ -0,0 +1,47 @@
+package event
+
+import (
+	"time"
+
+	"github.com/rgoncalvesrr/fullcycle-clean-arch/internal/entity"
+)
+
+// OrderStatusChanged is raised when an order moves to another status. Its
+// name is "order." followed by the new status, such as "order.paid", so
+// handlers subscribe to the changes they care about. Its key is the order
+// ID, so async dispatchers handle the changes of an order in order.
+type OrderStatusChanged struct {
+	Name     string
+	OrderID  string
+	Payload  any
+	DateTime time.Time
+}
+
+func NewOrderStatusChanged(orderID string, status entity.OrderStatus, payload any) *OrderStatusChanged {
+	return &OrderStatusChanged{
+		Name:     OrderStatusEventName(status),
+		OrderID:  orderID,
+		Payload:  payload,
+		DateTime: time.Now(),
+	}
+}
+
+func OrderStatusEventName(status entity.OrderStatus) string {
+	return "order." + string(status)
+}
+
+func (e *OrderStatusChanged) GetName() string {
+	return e.Name
+}
+
+func (e *OrderStatusChanged) GetKey() string {
+	return e.OrderID
+}
+
+func (e *OrderStatusChanged) GetDateTime() time.Time {
+	return e.DateTime
+}
+
+func (e *OrderStatusChanged) GetPayLoad() any {
+	return e.Payload
+}
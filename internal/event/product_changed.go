@@ -0,0 +1,46 @@
+package event
+
+import "time"
+
+// Names of the events raised on product changes.
+const (
+	ProductCreated  = "product.created"
+	ProductUpdated  = "product.updated"
+	ProductDeleted  = "product.deleted"
+	ProductRestored = "product.restored"
+)
+
+// ProductChanged is raised once a product change is stored, named after the
+// change, such as "product.updated". Its key is the product ID, so async
+// dispatchers handle the changes of a product in order.
+type ProductChanged struct {
+	Name      string
+	ProductID string
+	Payload   any
+	DateTime  time.Time
+}
+
+func NewProductChanged(name, productID string, payload any) *ProductChanged {
+	return &ProductChanged{
+		Name:      name,
+		ProductID: productID,
+		Payload:   payload,
+		DateTime:  time.Now(),
+	}
+}
+
+func (e *ProductChanged) GetName() string {
+	return e.Name
+}
+
+func (e *ProductChanged) GetKey() string {
+	return e.ProductID
+}
+
+func (e *ProductChanged) GetDateTime() time.Time {
+	return e.DateTime
+}
+
+func (e *ProductChanged) GetPayLoad() any {
+	return e.Payload
+}
@@ -0,0 +1,31 @@
+package event
+
+import (
+	"github.com/rgoncalvesrr/fullcycle-clean-arch/pkg/events"
+)
+
+// Subscription is a handler of the events matching Pattern. Name identifies
+// it in the dead letter queue, so it must not change across releases.
+type Subscription struct {
+	Name    string
+	Pattern string
+	Handler events.IEventHandler
+}
+
+// Subscribe registers the subscriptions on dispatcher, each retried with
+// policy. Events a handler still fails on go to deadLetters, which can
+// replay them to the same handler.
+func Subscribe(dispatcher events.IEventDispacher, deadLetters *events.DeadLetterQueue, policy events.RetryPolicy, subscriptions ...Subscription) error {
+	for _, s := range subscriptions {
+		handler, err := deadLetters.WithRetry(s.Name, s.Handler, policy)
+		if err != nil {
+			return err
+		}
+
+		if err := dispatcher.Register(s.Pattern, handler); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,81 @@
+package event
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/rgoncalvesrr/fullcycle-clean-arch/internal/entity"
+	"github.com/rgoncalvesrr/fullcycle-clean-arch/internal/infra/database"
+	"github.com/rgoncalvesrr/fullcycle-clean-arch/pkg/events"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// flakyHandler fails until it is fixed, keeping the payloads it handled.
+type flakyHandler struct {
+	broken   bool
+	calls    int
+	payloads []string
+}
+
+func (h *flakyHandler) Handle(ctx context.Context, e events.IEvent) error {
+	h.calls++
+
+	if h.broken {
+		return errors.New("broker unavailable")
+	}
+
+	payload, err := json.Marshal(e.GetPayLoad())
+	if err != nil {
+		return err
+	}
+
+	h.payloads = append(h.payloads, string(payload))
+
+	return nil
+}
+
+func TestSubscribeDeadLettersAndReplays(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+	db.AutoMigrate(&entity.DeadLetter{})
+
+	ctx := context.Background()
+	dispatcher := events.NewEventDispatcher()
+	deadLetters := events.NewDeadLetterQueue(database.NewDeadLetterGateway(db))
+
+	handler := &flakyHandler{broken: true}
+	policy := events.RetryPolicy{MaxAttempts: 2, InitialBackoff: time.Millisecond}
+
+	err = Subscribe(dispatcher, deadLetters, policy, Subscription{Name: "publisher", Pattern: "order.*", Handler: handler})
+	assert.NoError(t, err)
+
+	// Names identify handlers in stored letters, so they can't be reused.
+	err = Subscribe(dispatcher, deadLetters, policy, Subscription{Name: "publisher", Pattern: "#", Handler: handler})
+	assert.ErrorIs(t, err, events.ErrHandlerAlreadyRegistered)
+
+	changed := NewOrderStatusChanged("order-1", entity.OrderPaid, map[string]string{"id": "order-1"})
+	assert.ErrorIs(t, dispatcher.Dispatch(ctx, changed), events.ErrDeadLettered)
+	assert.Equal(t, 2, handler.calls)
+
+	letters, err := deadLetters.List(ctx, 0, 10)
+	assert.NoError(t, err)
+	assert.Len(t, letters, 1)
+	assert.Equal(t, "publisher", letters[0].Handler)
+	assert.Equal(t, "order.paid", letters[0].Event.GetName())
+
+	// Replayed from the database, the event reaches the same handler.
+	handler.broken = false
+	assert.NoError(t, deadLetters.Replay(ctx, letters[0].ID))
+	assert.Equal(t, []string{`{"id":"order-1"}`}, handler.payloads)
+
+	letters, err = deadLetters.List(ctx, 0, 10)
+	assert.NoError(t, err)
+	assert.Empty(t, letters)
+}
@@ -0,0 +1,199 @@
+package database
+
+import (
+	"context"
+	"errors"
+
+	"github.com/rgoncalvesrr/fullcycle-clean-arch/internal/entity"
+	"github.com/rgoncalvesrr/fullcycle-clean-arch/pkg/events"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrCategoryHasChildren = errors.New("category has subcategories")
+	ErrCategoryCycle       = errors.New("category cannot be moved under one of its descendants")
+)
+
+// descendantCategoriesSQL selects the id of a category and of every category
+// below it.
+const descendantCategoriesSQL = `WITH RECURSIVE category_tree(id) AS (
+	SELECT id FROM categories WHERE id = ?
+	UNION ALL
+	SELECT c.id FROM categories c JOIN category_tree t ON c.parent_id = t.id
+) SELECT id FROM category_tree`
+
+// ancestorCategoriesSQL selects the id of every category above a category,
+// its parent first.
+const ancestorCategoriesSQL = `WITH RECURSIVE category_ancestors(id, parent_id, depth) AS (
+	SELECT id, parent_id, 0 FROM categories WHERE id = ?
+	UNION ALL
+	SELECT c.id, c.parent_id, a.depth + 1 FROM categories c JOIN category_ancestors a ON c.id = a.parent_id
+) SELECT id FROM category_ancestors WHERE depth > 0 ORDER BY depth`
+
+type CategoryGateway struct {
+	DB *gorm.DB
+	// Actor is the user recorded in the history of the products changed
+	// through the gateway.
+	Actor string
+}
+
+func NewCategoryGateway(db *gorm.DB) *CategoryGateway {
+	return &CategoryGateway{DB: db}
+}
+
+// WithActor returns a gateway that records actor as the author of the
+// changes it makes to products.
+func (c *CategoryGateway) WithActor(actor string) CategoryInterface {
+	return &CategoryGateway{DB: c.DB, Actor: actor}
+}
+
+func (c *CategoryGateway) Create(category *entity.Category) error {
+	if category.ParentID != nil {
+		if _, err := c.FindByID(category.ParentID.String()); err != nil {
+			return err
+		}
+	}
+
+	return c.DB.Create(category).Error
+}
+
+// Transaction runs fn with a gateway bound to a single database transaction,
+// committing when fn returns nil and rolling back otherwise.
+func (c *CategoryGateway) Transaction(fn func(tx CategoryInterface) error) error {
+	return c.DB.Transaction(func(tx *gorm.DB) error {
+		return fn(&CategoryGateway{DB: tx, Actor: c.Actor})
+	})
+}
+
+// RecordEvent appends event to the event store. Called on the gateway handed
+// to Transaction, the event is stored with the change it tells of or not at
+// all.
+func (c *CategoryGateway) RecordEvent(ctx context.Context, event events.IEvent) error {
+	_, err := NewEventStoreGateway(c.DB).Append(ctx, event)
+	return err
+}
+
+func (c *CategoryGateway) FindAll() ([]entity.Category, error) {
+	var categories []entity.Category
+
+	err := c.DB.Order("name").Find(&categories).Error
+
+	if err != nil {
+		categories = nil
+	}
+
+	return categories, err
+}
+
+func (c *CategoryGateway) FindByID(id string) (*entity.Category, error) {
+	var category *entity.Category
+
+	err := c.DB.First(&category, "id = ?", id).Error
+
+	if err != nil {
+		category = nil
+	}
+
+	return category, err
+}
+
+func (c *CategoryGateway) FindChildren(id string) ([]entity.Category, error) {
+	var categories []entity.Category
+
+	err := c.DB.Where("parent_id = ?", id).Order("name").Find(&categories).Error
+
+	if err != nil {
+		categories = nil
+	}
+
+	return categories, err
+}
+
+// FindDescendants returns every category below id, at any depth.
+func (c *CategoryGateway) FindDescendants(id string) ([]entity.Category, error) {
+	if _, err := c.FindByID(id); err != nil {
+		return nil, err
+	}
+
+	var categories []entity.Category
+
+	err := c.DB.
+		Where("id IN (?) AND id <> ?", gorm.Expr(descendantCategoriesSQL, id), id).
+		Order("name").
+		Find(&categories).Error
+
+	if err != nil {
+		categories = nil
+	}
+
+	return categories, err
+}
+
+// AncestorIDs returns the ids of the categories above id, its parent first.
+func (c *CategoryGateway) AncestorIDs(id string) ([]string, error) {
+	var ids []string
+
+	err := c.DB.Raw(ancestorCategoriesSQL, id).Scan(&ids).Error
+
+	if err != nil {
+		ids = nil
+	}
+
+	return ids, err
+}
+
+func (c *CategoryGateway) Update(category *entity.Category) error {
+	if _, err := c.FindByID(category.ID.String()); err != nil {
+		return err
+	}
+
+	if category.ParentID != nil {
+		if _, err := c.FindByID(category.ParentID.String()); err != nil {
+			return err
+		}
+
+		var count int64
+
+		err := c.DB.Model(&entity.Category{}).
+			Where("id IN (?) AND id = ?", gorm.Expr(descendantCategoriesSQL, category.ID.String()), category.ParentID.String()).
+			Count(&count).Error
+		if err != nil {
+			return err
+		}
+
+		if count > 0 {
+			return ErrCategoryCycle
+		}
+	}
+
+	return c.DB.Save(category).Error
+}
+
+// Delete removes a leaf category. Products in it are left uncategorized,
+// through the product gateway so that their versions and history follow.
+func (c *CategoryGateway) Delete(id string) error {
+	category, err := c.FindByID(id)
+	if err != nil {
+		return err
+	}
+
+	var children int64
+
+	if err = c.DB.Model(&entity.Category{}).Where("parent_id = ?", id).Count(&children).Error; err != nil {
+		return err
+	}
+
+	if children > 0 {
+		return ErrCategoryHasChildren
+	}
+
+	return c.DB.Transaction(func(tx *gorm.DB) error {
+		products := &ProductGateway{DB: tx, Actor: c.Actor}
+
+		if _, err := products.Uncategorize(id); err != nil {
+			return err
+		}
+
+		return tx.Delete(&category).Error
+	})
+}
@@ -0,0 +1,169 @@
+package database
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/rgoncalvesrr/fullcycle-clean-arch/internal/entity"
+	pkgEntity "github.com/rgoncalvesrr/fullcycle-clean-arch/pkg/entity"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func newCategoryTestDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+	db.AutoMigrate(&entity.Category{}, &entity.Product{}, &entity.ProductHistory{})
+
+	return db
+}
+
+func createCategory(t *testing.T, gateway *CategoryGateway, name string, parent *entity.Category) *entity.Category {
+	var parentID *pkgEntity.ID
+	if parent != nil {
+		parentID = &parent.ID
+	}
+
+	category, err := entity.NewCategory(name, "", parentID)
+	assert.NoError(t, err)
+	assert.NoError(t, gateway.Create(category))
+
+	return category
+}
+
+func TestCategoryCreate(t *testing.T) {
+	categoryGateway := NewCategoryGateway(newCategoryTestDB(t))
+
+	root := createCategory(t, categoryGateway, "Papelaria", nil)
+	child := createCategory(t, categoryGateway, "Canetas", root)
+
+	found, err := categoryGateway.FindByID(child.ID.String())
+	assert.NoError(t, err)
+	assert.Equal(t, root.ID, *found.ParentID)
+
+	unknownParent := pkgEntity.NewID()
+	orphan, err := entity.NewCategory("Órfã", "", &unknownParent)
+	assert.NoError(t, err)
+	assert.ErrorIs(t, categoryGateway.Create(orphan), gorm.ErrRecordNotFound)
+}
+
+func TestCategoryFindDescendants(t *testing.T) {
+	categoryGateway := NewCategoryGateway(newCategoryTestDB(t))
+
+	root := createCategory(t, categoryGateway, "Papelaria", nil)
+	pens := createCategory(t, categoryGateway, "Canetas", root)
+	ballpoint := createCategory(t, categoryGateway, "Esferográficas", pens)
+	createCategory(t, categoryGateway, "Cadernos", root)
+	createCategory(t, categoryGateway, "Informática", nil)
+
+	descendants, err := categoryGateway.FindDescendants(root.ID.String())
+	assert.NoError(t, err)
+	assert.Len(t, descendants, 3)
+
+	children, err := categoryGateway.FindChildren(root.ID.String())
+	assert.NoError(t, err)
+	assert.Len(t, children, 2)
+	assert.Equal(t, "Cadernos", children[0].Name)
+
+	ancestors, err := categoryGateway.AncestorIDs(ballpoint.ID.String())
+	assert.NoError(t, err)
+	assert.Equal(t, []string{pens.ID.String(), root.ID.String()}, ancestors)
+
+	ancestors, err = categoryGateway.AncestorIDs(root.ID.String())
+	assert.NoError(t, err)
+	assert.Empty(t, ancestors)
+
+	all, err := categoryGateway.FindAll()
+	assert.NoError(t, err)
+	assert.Len(t, all, 5)
+}
+
+func TestCategoryUpdateRejectsCycles(t *testing.T) {
+	categoryGateway := NewCategoryGateway(newCategoryTestDB(t))
+
+	root := createCategory(t, categoryGateway, "Papelaria", nil)
+	pens := createCategory(t, categoryGateway, "Canetas", root)
+	ballpoint := createCategory(t, categoryGateway, "Esferográficas", pens)
+
+	root.ParentID = &ballpoint.ID
+	assert.ErrorIs(t, categoryGateway.Update(root), ErrCategoryCycle)
+
+	ballpoint.ParentID = &root.ID
+	ballpoint.Name = "Canetas esferográficas"
+	assert.NoError(t, categoryGateway.Update(ballpoint))
+
+	found, err := categoryGateway.FindByID(ballpoint.ID.String())
+	assert.NoError(t, err)
+	assert.Equal(t, root.ID, *found.ParentID)
+	assert.Equal(t, "Canetas esferográficas", found.Name)
+}
+
+func TestCategoryDelete(t *testing.T) {
+	db := newCategoryTestDB(t)
+	categoryGateway := NewCategoryGateway(db)
+	productGateway := NewProductGateway(db)
+
+	root := createCategory(t, categoryGateway, "Papelaria", nil)
+	pens := createCategory(t, categoryGateway, "Canetas", root)
+
+	product, err := entity.NewProduct("PRD-1", "Caneta", entity.MustParseMoney("2.50", "BRL"))
+	assert.NoError(t, err)
+	product.CategoryID = &pens.ID
+	assert.NoError(t, productGateway.Create(product))
+
+	trashed, err := entity.NewProduct("PRD-2", "Caneta velha", entity.MustParseMoney("1.50", "BRL"))
+	assert.NoError(t, err)
+	trashed.CategoryID = &pens.ID
+	assert.NoError(t, productGateway.Create(trashed))
+	assert.NoError(t, productGateway.Delete(trashed.ID.String(), "user-1", 0))
+
+	assert.ErrorIs(t, categoryGateway.Delete(root.ID.String()), ErrCategoryHasChildren)
+	assert.NoError(t, categoryGateway.WithActor("user-2").Delete(pens.ID.String()))
+
+	product, err = productGateway.FindByID(product.ID.String())
+	assert.NoError(t, err)
+	assert.Nil(t, product.CategoryID)
+	assert.Equal(t, 2, product.Version)
+
+	// The change is in the product's history, so stale ETags and If-Match
+	// versions no longer match.
+	history, err := productGateway.History(product.ID.String(), 0, 10)
+	assert.NoError(t, err)
+	assert.Len(t, history, 2)
+	assert.Equal(t, entity.ProductUpdated, history[1].Action)
+	assert.Equal(t, "user-2", history[1].Actor)
+	assert.Equal(t, 2, history[1].Version)
+
+	var uncategorized entity.Product
+	assert.NoError(t, db.Unscoped().First(&uncategorized, "id = ?", trashed.ID).Error)
+	assert.Nil(t, uncategorized.CategoryID)
+	assert.Equal(t, 3, uncategorized.Version)
+}
+
+func TestProductFindByCategoryIncludesSubcategories(t *testing.T) {
+	db := newCategoryTestDB(t)
+	categoryGateway := NewCategoryGateway(db)
+	productGateway := NewProductGateway(db)
+
+	root := createCategory(t, categoryGateway, "Papelaria", nil)
+	pens := createCategory(t, categoryGateway, "Canetas", root)
+	other := createCategory(t, categoryGateway, "Informática", nil)
+
+	for i, category := range []*entity.Category{root, pens, pens, other} {
+		product, err := entity.NewProduct(fmt.Sprintf("PRD-%d", i+1), "Produto", entity.MustParseMoney("1", "BRL"))
+		assert.NoError(t, err)
+		product.CategoryID = &category.ID
+		assert.NoError(t, productGateway.Create(product))
+	}
+
+	products, err := productGateway.FindByQuery(ProductQuery{CategoryID: root.ID.String()})
+	assert.NoError(t, err)
+	assert.Len(t, products, 3)
+
+	products, err = productGateway.FindByQuery(ProductQuery{CategoryID: pens.ID.String()})
+	assert.NoError(t, err)
+	assert.Len(t, products, 2)
+}
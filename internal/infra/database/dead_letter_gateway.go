@@ -0,0 +1,102 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"github.com/rgoncalvesrr/fullcycle-clean-arch/internal/entity"
+	"github.com/rgoncalvesrr/fullcycle-clean-arch/pkg/events"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// DeadLetterGateway is the database backed events.DeadLetterSink. Events
+// are stored with their payload as JSON and read back as events.RawEvent.
+type DeadLetterGateway struct {
+	DB *gorm.DB
+}
+
+func NewDeadLetterGateway(db *gorm.DB) *DeadLetterGateway {
+	return &DeadLetterGateway{DB: db}
+}
+
+func (g *DeadLetterGateway) Put(ctx context.Context, letter *events.DeadLetter) error {
+	payload, err := json.Marshal(letter.Event.GetPayLoad())
+	if err != nil {
+		return err
+	}
+
+	record := &entity.DeadLetter{
+		ID:        letter.ID,
+		Handler:   letter.Handler,
+		EventName: letter.Event.GetName(),
+		Payload:   payload,
+		EventTime: letter.Event.GetDateTime(),
+		Error:     letter.Error,
+		Attempts:  letter.Attempts,
+		FailedAt:  letter.FailedAt,
+	}
+
+	if keyed, ok := letter.Event.(events.IKeyedEvent); ok {
+		record.EventKey = keyed.GetKey()
+	}
+
+	return g.DB.WithContext(ctx).Clauses(clause.OnConflict{UpdateAll: true}).Create(record).Error
+}
+
+func (g *DeadLetterGateway) Find(ctx context.Context, id string) (*events.DeadLetter, error) {
+	var record entity.DeadLetter
+
+	err := g.DB.WithContext(ctx).First(&record, "id = ?", id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, events.ErrDeadLetterNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return toDeadLetter(&record), nil
+}
+
+func (g *DeadLetterGateway) FindAll(ctx context.Context, offset, limit int) ([]events.DeadLetter, error) {
+	offset, limit = searchPage(offset, limit)
+
+	var records []entity.DeadLetter
+
+	err := g.DB.WithContext(ctx).
+		Order("failed_at").
+		Offset(offset).
+		Limit(limit).
+		Find(&records).Error
+	if err != nil {
+		return nil, err
+	}
+
+	letters := make([]events.DeadLetter, 0, len(records))
+	for i := range records {
+		letters = append(letters, *toDeadLetter(&records[i]))
+	}
+
+	return letters, nil
+}
+
+func (g *DeadLetterGateway) Delete(ctx context.Context, id string) error {
+	return g.DB.WithContext(ctx).Delete(&entity.DeadLetter{}, "id = ?", id).Error
+}
+
+func toDeadLetter(record *entity.DeadLetter) *events.DeadLetter {
+	return &events.DeadLetter{
+		ID:      record.ID,
+		Handler: record.Handler,
+		Event: &events.RawEvent{
+			Name:     record.EventName,
+			Key:      record.EventKey,
+			Payload:  record.Payload,
+			DateTime: record.EventTime,
+		},
+		Error:    record.Error,
+		Attempts: record.Attempts,
+		FailedAt: record.FailedAt,
+	}
+}
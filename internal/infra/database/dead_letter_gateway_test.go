@@ -0,0 +1,113 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/rgoncalvesrr/fullcycle-clean-arch/internal/entity"
+	"github.com/rgoncalvesrr/fullcycle-clean-arch/pkg/events"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+type testOrderEvent struct {
+	OrderID string
+}
+
+func (e *testOrderEvent) GetName() string        { return "order.paid" }
+func (e *testOrderEvent) GetKey() string         { return e.OrderID }
+func (e *testOrderEvent) GetDateTime() time.Time { return time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC) }
+func (e *testOrderEvent) GetPayLoad() any        { return map[string]string{"order_id": e.OrderID} }
+
+func TestDeadLetterGateway(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+	db.AutoMigrate(&entity.DeadLetter{})
+
+	ctx := context.Background()
+	gateway := NewDeadLetterGateway(db)
+
+	letter := &events.DeadLetter{
+		ID:       "letter-1",
+		Handler:  "audit",
+		Event:    &testOrderEvent{OrderID: "order-1"},
+		Error:    "timeout",
+		Attempts: 3,
+		FailedAt: time.Now(),
+	}
+	assert.NoError(t, gateway.Put(ctx, letter))
+
+	found, err := gateway.Find(ctx, "letter-1")
+	assert.NoError(t, err)
+	assert.Equal(t, "audit", found.Handler)
+	assert.Equal(t, 3, found.Attempts)
+	assert.Equal(t, "order.paid", found.Event.GetName())
+	assert.True(t, found.Event.GetDateTime().Equal(letter.Event.GetDateTime()))
+	assert.Equal(t, "order-1", found.Event.(events.IKeyedEvent).GetKey())
+	assert.JSONEq(t, `{"order_id": "order-1"}`, string(found.Event.GetPayLoad().(json.RawMessage)))
+
+	// Storing a read back letter again keeps the payload as it was.
+	found.Attempts = 5
+	assert.NoError(t, gateway.Put(ctx, found))
+
+	letters, err := gateway.FindAll(ctx, 0, 10)
+	assert.NoError(t, err)
+	assert.Len(t, letters, 1)
+	assert.Equal(t, 5, letters[0].Attempts)
+	assert.JSONEq(t, `{"order_id": "order-1"}`, string(letters[0].Event.GetPayLoad().(json.RawMessage)))
+
+	assert.NoError(t, gateway.Delete(ctx, "letter-1"))
+
+	_, err = gateway.Find(ctx, "letter-1")
+	assert.ErrorIs(t, err, events.ErrDeadLetterNotFound)
+}
+
+func TestDeadLetterQueueWithDatabase(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+	db.AutoMigrate(&entity.DeadLetter{})
+
+	ctx := context.Background()
+	queue := events.NewDeadLetterQueue(NewDeadLetterGateway(db))
+
+	fail := true
+	var replayed []byte
+
+	handler, err := queue.WithRetry("audit", &funcHandler{fn: func(ctx context.Context, event events.IEvent) error {
+		if fail {
+			return errors.New("broker down")
+		}
+
+		replayed, err = json.Marshal(event.GetPayLoad())
+		return err
+	}}, events.RetryPolicy{MaxAttempts: 1})
+	assert.NoError(t, err)
+
+	assert.ErrorIs(t, handler.Handle(ctx, &testOrderEvent{OrderID: "order-1"}), events.ErrDeadLettered)
+
+	letters, _ := queue.List(ctx, 0, 10)
+	assert.Len(t, letters, 1)
+
+	fail = false
+	assert.NoError(t, queue.Replay(ctx, letters[0].ID))
+	assert.JSONEq(t, `{"order_id": "order-1"}`, string(replayed))
+
+	letters, _ = queue.List(ctx, 0, 10)
+	assert.Empty(t, letters)
+}
+
+type funcHandler struct {
+	fn func(ctx context.Context, event events.IEvent) error
+}
+
+func (h *funcHandler) Handle(ctx context.Context, event events.IEvent) error {
+	return h.fn(ctx, event)
+}
@@ -0,0 +1,243 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/rgoncalvesrr/fullcycle-clean-arch/internal/entity"
+	"github.com/rgoncalvesrr/fullcycle-clean-arch/pkg/events"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// EventStoreGateway is the database backed events.StreamStore,
+// events.SnapshotStore and events.CheckpointStore. Events are stored with
+// their payload as JSON and read back as events.RawEvent. Event rows are
+// only ever inserted.
+//
+// Sequences come from an auto-increment column. On MySQL an append may
+// commit after a later one, so a consumer reading the very end of the log
+// could skip it; consumers catching up on history aren't affected.
+type EventStoreGateway struct {
+	DB *gorm.DB
+}
+
+func NewEventStoreGateway(db *gorm.DB) *EventStoreGateway {
+	return &EventStoreGateway{DB: db}
+}
+
+func (g *EventStoreGateway) Append(ctx context.Context, event events.IEvent) (int64, error) {
+	record, err := newStoredEvent(event)
+	if err != nil {
+		return 0, err
+	}
+
+	if err = g.DB.WithContext(ctx).Create(record).Error; err != nil {
+		return 0, err
+	}
+
+	return record.Sequence, nil
+}
+
+// AppendToStream checks the version of stream with its last event locked,
+// so that of two concurrent appends the second waits for the first and then
+// sees its events. A conflict is thus reported before anything is inserted,
+// which on PostgreSQL would abort the caller's transaction; the unique index
+// on the stream's versions still catches appends that race to create it.
+func (g *EventStoreGateway) AppendToStream(ctx context.Context, stream string, expectedVersion int, evts ...events.IEvent) (int, error) {
+	if stream == "" {
+		return 0, events.ErrStreamRequired
+	}
+
+	conflict := func(version int) error {
+		return fmt.Errorf("%w: %s is at version %d, not %d", events.ErrStreamVersionConflict, stream, version, expectedVersion)
+	}
+
+	records := make([]*entity.StoredEvent, 0, len(evts))
+
+	for i, event := range evts {
+		record, err := newStoredEvent(event)
+		if err != nil {
+			return 0, err
+		}
+
+		version := expectedVersion + i + 1
+		record.Stream, record.StreamVersion = &stream, &version
+		records = append(records, record)
+	}
+
+	err := g.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var last []entity.StoredEvent
+
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("stream = ?", stream).
+			Order("stream_version DESC").
+			Limit(1).
+			Find(&last).Error
+		if err != nil {
+			return err
+		}
+
+		// Read again: waiting for the lock doesn't refresh the locked row.
+		var version int
+
+		err = tx.Model(&entity.StoredEvent{}).
+			Where("stream = ?", stream).
+			Select("COALESCE(MAX(stream_version), 0)").
+			Scan(&version).Error
+		if err != nil {
+			return err
+		}
+
+		if version != expectedVersion {
+			return conflict(version)
+		}
+
+		if len(records) == 0 {
+			return nil
+		}
+
+		if err = tx.Create(&records).Error; err != nil {
+			if translator, ok := tx.Dialector.(gorm.ErrorTranslator); ok && errors.Is(translator.Translate(err), gorm.ErrDuplicatedKey) {
+				return conflict(expectedVersion + 1)
+			}
+
+			return err
+		}
+
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return expectedVersion + len(records), nil
+}
+
+func (g *EventStoreGateway) LoadStream(ctx context.Context, stream string, after int) ([]events.StoredEvent, error) {
+	var records []entity.StoredEvent
+
+	err := g.DB.WithContext(ctx).
+		Where("stream = ? AND stream_version > ?", stream, after).
+		Order("stream_version").
+		Find(&records).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return toStoredEvents(records), nil
+}
+
+func (g *EventStoreGateway) LoadSnapshot(ctx context.Context, stream string) (*events.Snapshot, error) {
+	var record entity.EventSnapshot
+
+	err := g.DB.WithContext(ctx).First(&record, "stream = ?", stream).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &events.Snapshot{Stream: record.Stream, Version: record.Version, State: record.State, CreatedAt: record.CreatedAt}, nil
+}
+
+func (g *EventStoreGateway) SaveSnapshot(ctx context.Context, snapshot events.Snapshot) error {
+	record := &entity.EventSnapshot{Stream: snapshot.Stream, Version: snapshot.Version, State: snapshot.State, CreatedAt: snapshot.CreatedAt}
+
+	return g.DB.WithContext(ctx).Clauses(clause.OnConflict{UpdateAll: true}).Create(record).Error
+}
+
+func newStoredEvent(event events.IEvent) (*entity.StoredEvent, error) {
+	payload, err := json.Marshal(event.GetPayLoad())
+	if err != nil {
+		return nil, err
+	}
+
+	record := &entity.StoredEvent{
+		EventName:  event.GetName(),
+		Payload:    payload,
+		OccurredAt: event.GetDateTime().UTC(),
+		RecordedAt: time.Now().UTC(),
+	}
+
+	if keyed, ok := event.(events.IKeyedEvent); ok {
+		record.EventKey = keyed.GetKey()
+	}
+
+	return record, nil
+}
+
+func (g *EventStoreGateway) Load(ctx context.Context, query events.EventQuery) ([]events.StoredEvent, error) {
+	tx := g.DB.WithContext(ctx).Where("sequence > ?", query.After)
+
+	if !query.From.IsZero() {
+		tx = tx.Where("occurred_at >= ?", query.From.UTC())
+	}
+	if !query.To.IsZero() {
+		tx = tx.Where("occurred_at < ?", query.To.UTC())
+	}
+	if !query.Streams {
+		tx = tx.Where("stream IS NULL")
+	}
+	if query.Limit > 0 {
+		tx = tx.Limit(query.Limit)
+	}
+
+	var records []entity.StoredEvent
+
+	if err := tx.Order("sequence").Find(&records).Error; err != nil {
+		return nil, err
+	}
+
+	return toStoredEvents(records), nil
+}
+
+func toStoredEvents(records []entity.StoredEvent) []events.StoredEvent {
+	stored := make([]events.StoredEvent, 0, len(records))
+	for _, record := range records {
+		s := events.StoredEvent{
+			Sequence: record.Sequence,
+			Event: &events.RawEvent{
+				Name:     record.EventName,
+				Key:      record.EventKey,
+				Payload:  record.Payload,
+				DateTime: record.OccurredAt,
+			},
+			RecordedAt: record.RecordedAt,
+		}
+
+		if record.Stream != nil && record.StreamVersion != nil {
+			s.Stream, s.StreamVersion = *record.Stream, *record.StreamVersion
+		}
+
+		stored = append(stored, s)
+	}
+
+	return stored
+}
+
+func (g *EventStoreGateway) Checkpoint(ctx context.Context, consumer string) (int64, error) {
+	var checkpoint entity.EventCheckpoint
+
+	err := g.DB.WithContext(ctx).First(&checkpoint, "consumer = ?", consumer).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	return checkpoint.Sequence, nil
+}
+
+func (g *EventStoreGateway) SaveCheckpoint(ctx context.Context, consumer string, sequence int64) error {
+	checkpoint := &entity.EventCheckpoint{Consumer: consumer, Sequence: sequence}
+
+	return g.DB.WithContext(ctx).
+		Clauses(clause.OnConflict{Columns: []clause.Column{{Name: "consumer"}}, DoUpdates: clause.AssignmentColumns([]string{"sequence", "updated_at"})}).
+		Create(checkpoint).Error
+}
@@ -0,0 +1,150 @@
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/rgoncalvesrr/fullcycle-clean-arch/internal/entity"
+	"github.com/rgoncalvesrr/fullcycle-clean-arch/pkg/events"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestEventStoreGateway(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+	db.AutoMigrate(&entity.StoredEvent{}, &entity.EventCheckpoint{})
+
+	ctx := context.Background()
+	gateway := NewEventStoreGateway(db)
+
+	sequence, err := gateway.Append(ctx, &testOrderEvent{OrderID: "order-1"})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), sequence)
+
+	later := events.NewEvent("product.created", map[string]int{"price": 10})
+	later.DateTime = time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	sequence, err = gateway.Append(ctx, later)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), sequence)
+
+	stored, err := gateway.Load(ctx, events.EventQuery{})
+	assert.NoError(t, err)
+	assert.Len(t, stored, 2)
+
+	first := stored[0].Event.(*events.RawEvent)
+	assert.Equal(t, int64(1), stored[0].Sequence)
+	assert.Equal(t, "order.paid", first.Name)
+	assert.Equal(t, "order-1", first.Key)
+	assert.JSONEq(t, `{"order_id":"order-1"}`, string(first.Payload))
+	assert.True(t, first.DateTime.Equal(time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)))
+
+	stored, err = gateway.Load(ctx, events.EventQuery{After: 1})
+	assert.NoError(t, err)
+	assert.Len(t, stored, 1)
+	assert.Equal(t, "product.created", stored[0].Event.GetName())
+
+	stored, err = gateway.Load(ctx, events.EventQuery{From: time.Date(2024, 5, 15, 0, 0, 0, 0, time.UTC)})
+	assert.NoError(t, err)
+	assert.Len(t, stored, 1)
+	assert.Equal(t, int64(2), stored[0].Sequence)
+
+	stored, err = gateway.Load(ctx, events.EventQuery{To: time.Date(2024, 5, 15, 0, 0, 0, 0, time.UTC)})
+	assert.NoError(t, err)
+	assert.Len(t, stored, 1)
+	assert.Equal(t, int64(1), stored[0].Sequence)
+
+	stored, err = gateway.Load(ctx, events.EventQuery{Limit: 1})
+	assert.NoError(t, err)
+	assert.Len(t, stored, 1)
+
+	// Stored events replay to typed handlers.
+	var prices []int
+	dispatcher := events.NewEventDispatcher()
+	typed, _ := events.Subscribe(dispatcher, "product.created", func(ctx context.Context, event *events.Event[map[string]int]) error {
+		prices = append(prices, event.Payload["price"])
+		return nil
+	})
+
+	last, err := events.Replay(ctx, gateway, events.EventQuery{After: 1}, typed)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), last)
+	assert.Equal(t, []int{10}, prices)
+
+	checkpoint, err := gateway.Checkpoint(ctx, "projection")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), checkpoint)
+
+	assert.NoError(t, gateway.SaveCheckpoint(ctx, "projection", 1))
+	assert.NoError(t, gateway.SaveCheckpoint(ctx, "projection", 2))
+
+	checkpoint, err = gateway.Checkpoint(ctx, "projection")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), checkpoint)
+
+	var count int64
+	db.Model(&entity.EventCheckpoint{}).Count(&count)
+	assert.Equal(t, int64(1), count)
+}
+
+func TestEventStoreGatewayStreams(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+	db.AutoMigrate(&entity.StoredEvent{}, &entity.EventSnapshot{})
+
+	ctx := context.Background()
+	gateway := NewEventStoreGateway(db)
+
+	_, err = gateway.Append(ctx, &testOrderEvent{OrderID: "order-1"})
+	assert.NoError(t, err)
+
+	// The event keyed order-1 above isn't part of the stream.
+	version, err := gateway.AppendToStream(ctx, "order-1", 0, events.NewEvent("OrderCreated", 1), events.NewEvent("OrderPaid", 2))
+	assert.NoError(t, err)
+	assert.Equal(t, 2, version)
+
+	_, err = gateway.AppendToStream(ctx, "order-1", 1, events.NewEvent("OrderCancelled", 3))
+	assert.ErrorIs(t, err, events.ErrStreamVersionConflict)
+
+	// Each stream is versioned on its own.
+	version, err = gateway.AppendToStream(ctx, "order-2", 0, events.NewEvent("OrderCreated", 1))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, version)
+
+	stored, err := gateway.LoadStream(ctx, "order-1", 1)
+	assert.NoError(t, err)
+	assert.Len(t, stored, 1)
+	assert.Equal(t, "OrderPaid", stored[0].Event.GetName())
+	assert.Equal(t, "order-1", stored[0].Stream)
+	assert.Equal(t, 2, stored[0].StreamVersion)
+	assert.Equal(t, int64(3), stored[0].Sequence)
+
+	all, err := gateway.Load(ctx, events.EventQuery{})
+	assert.NoError(t, err)
+	assert.Len(t, all, 1)
+	assert.Empty(t, all[0].Stream)
+
+	all, err = gateway.Load(ctx, events.EventQuery{Streams: true})
+	assert.NoError(t, err)
+	assert.Len(t, all, 4)
+	assert.Equal(t, "order-2", all[3].Stream)
+
+	snapshot, err := gateway.LoadSnapshot(ctx, "order-1")
+	assert.NoError(t, err)
+	assert.Nil(t, snapshot)
+
+	assert.NoError(t, gateway.SaveSnapshot(ctx, events.Snapshot{Stream: "order-1", Version: 1, State: []byte(`{}`)}))
+	assert.NoError(t, gateway.SaveSnapshot(ctx, events.Snapshot{Stream: "order-1", Version: 2, State: []byte(`{"paid":true}`)}))
+
+	snapshot, err = gateway.LoadSnapshot(ctx, "order-1")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, snapshot.Version)
+	assert.JSONEq(t, `{"paid":true}`, string(snapshot.State))
+}
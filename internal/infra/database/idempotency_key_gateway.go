@@ -0,0 +1,113 @@
+package database
+
+import (
+	"errors"
+	"time"
+
+	"github.com/rgoncalvesrr/fullcycle-clean-arch/internal/entity"
+	"gorm.io/gorm"
+)
+
+type IdempotencyKeyGateway struct {
+	DB *gorm.DB
+}
+
+func NewIdempotencyKeyGateway(db *gorm.DB) *IdempotencyKeyGateway {
+	return &IdempotencyKeyGateway{DB: db}
+}
+
+// Reserve stores key unless an unexpired record with the same scope and key
+// exists, in which case that record is returned instead. Only the caller
+// that gets a nil record back may run the request; the primary key makes
+// sure concurrent reservations can't both succeed. A pending record whose
+// lease ran out is taken over by key.
+func (g *IdempotencyKeyGateway) Reserve(key *entity.IdempotencyKey) (*entity.IdempotencyKey, error) {
+	now := time.Now()
+	existing, err := g.Find(key.Scope, key.Key)
+
+	switch {
+	case err == nil && existing.IsAbandoned(now) && !existing.IsExpired(now):
+		return g.takeOver(key, now)
+	case err == nil && !existing.IsExpired(now):
+		return existing, nil
+	case err == nil:
+		if err = g.Release(key.Scope, key.Key); err != nil {
+			return nil, err
+		}
+	case !errors.Is(err, gorm.ErrRecordNotFound):
+		return nil, err
+	}
+
+	if err = g.DB.Create(key).Error; err != nil {
+		// Lost a race with a concurrent reservation of the same key.
+		if existing, findErr := g.Find(key.Scope, key.Key); findErr == nil {
+			return existing, nil
+		}
+
+		return nil, err
+	}
+
+	return nil, nil
+}
+
+// takeOver moves an abandoned reservation to key. The update only matches
+// while the lease is still over, so of concurrent retries only one gets it.
+func (g *IdempotencyKeyGateway) takeOver(key *entity.IdempotencyKey, now time.Time) (*entity.IdempotencyKey, error) {
+	result := g.DB.Model(&entity.IdempotencyKey{}).
+		Where("scope = ? AND idempotency_key = ? AND status = 0 AND (locked_until IS NULL OR locked_until <= ?)", key.Scope, key.Key, now).
+		Updates(map[string]any{
+			"fingerprint":  key.Fingerprint,
+			"created_at":   key.CreatedAt,
+			"expires_at":   key.ExpiresAt,
+			"locked_until": key.LockedUntil,
+		})
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	if result.RowsAffected == 1 {
+		return nil, nil
+	}
+
+	// Another retry took it over first, or the request completed.
+	return g.Find(key.Scope, key.Key)
+}
+
+func (g *IdempotencyKeyGateway) Find(scope string, key string) (*entity.IdempotencyKey, error) {
+	var k *entity.IdempotencyKey
+
+	err := g.DB.First(&k, "scope = ? AND idempotency_key = ?", scope, key).Error
+
+	if err != nil {
+		k = nil
+	}
+
+	return k, err
+}
+
+// Complete stores the response of a reserved key.
+func (g *IdempotencyKeyGateway) Complete(key *entity.IdempotencyKey) error {
+	return g.DB.Model(key).
+		Select("Status", "Header", "Body").
+		Updates(key).Error
+}
+
+// Renew extends the lease of a pending reservation until the given time.
+func (g *IdempotencyKeyGateway) Renew(key *entity.IdempotencyKey, until time.Time) error {
+	return g.DB.Model(&entity.IdempotencyKey{}).
+		Where("scope = ? AND idempotency_key = ? AND fingerprint = ? AND status = 0", key.Scope, key.Key, key.Fingerprint).
+		Update("locked_until", until).Error
+}
+
+// Release forgets a key, letting the next request with it run again.
+func (g *IdempotencyKeyGateway) Release(scope string, key string) error {
+	return g.DB.Delete(&entity.IdempotencyKey{}, "scope = ? AND idempotency_key = ?", scope, key).Error
+}
+
+// Purge removes the keys that expired before t and returns how many were
+// removed.
+func (g *IdempotencyKeyGateway) Purge(expiredBefore time.Time) (int64, error) {
+	result := g.DB.Delete(&entity.IdempotencyKey{}, "expires_at < ?", expiredBefore)
+
+	return result.RowsAffected, result.Error
+}
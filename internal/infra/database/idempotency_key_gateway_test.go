@@ -0,0 +1,132 @@
+package database
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/rgoncalvesrr/fullcycle-clean-arch/internal/entity"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestIdempotencyKeyReserve(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+	db.AutoMigrate(&entity.IdempotencyKey{})
+
+	gateway := NewIdempotencyKeyGateway(db)
+
+	key, _ := entity.NewIdempotencyKey("user-1", "key-1", "abc", time.Hour)
+
+	existing, err := gateway.Reserve(key)
+	assert.NoError(t, err)
+	assert.Nil(t, existing)
+
+	retry, _ := entity.NewIdempotencyKey("user-1", "key-1", "abc", time.Hour)
+	existing, err = gateway.Reserve(retry)
+	assert.NoError(t, err)
+	assert.NotNil(t, existing)
+	assert.False(t, existing.IsCompleted())
+
+	// Keys are scoped by subject.
+	other, _ := entity.NewIdempotencyKey("user-2", "key-1", "abc", time.Hour)
+	existing, err = gateway.Reserve(other)
+	assert.NoError(t, err)
+	assert.Nil(t, existing)
+
+	key.Status = http.StatusCreated
+	key.Body = []byte(`{"id":"1"}`)
+	assert.NoError(t, gateway.Complete(key))
+
+	existing, err = gateway.Reserve(retry)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusCreated, existing.Status)
+	assert.Equal(t, `{"id":"1"}`, string(existing.Body))
+}
+
+func TestIdempotencyKeyReserveExpired(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+	db.AutoMigrate(&entity.IdempotencyKey{})
+
+	gateway := NewIdempotencyKeyGateway(db)
+
+	expired, _ := entity.NewIdempotencyKey("user-1", "key-1", "abc", -time.Minute)
+	assert.NoError(t, db.Create(expired).Error)
+
+	key, _ := entity.NewIdempotencyKey("user-1", "key-1", "def", time.Hour)
+	existing, err := gateway.Reserve(key)
+	assert.NoError(t, err)
+	assert.Nil(t, existing)
+
+	found, err := gateway.Find("user-1", "key-1")
+	assert.NoError(t, err)
+	assert.Equal(t, "def", found.Fingerprint)
+}
+
+func TestIdempotencyKeyReserveAbandoned(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+	db.AutoMigrate(&entity.IdempotencyKey{})
+
+	gateway := NewIdempotencyKeyGateway(db)
+
+	// The request holding the key died without completing or releasing it.
+	stale, _ := entity.NewIdempotencyKey("user-1", "key-1", "abc", time.Hour)
+	stale.LockedUntil = time.Now().Add(-time.Second)
+	assert.NoError(t, db.Create(stale).Error)
+
+	retry, _ := entity.NewIdempotencyKey("user-1", "key-1", "abc", time.Hour)
+	existing, err := gateway.Reserve(retry)
+	assert.NoError(t, err)
+	assert.Nil(t, existing)
+
+	// The retry holds the key now, so the next one has to wait for it.
+	other, _ := entity.NewIdempotencyKey("user-1", "key-1", "abc", time.Hour)
+	existing, err = gateway.Reserve(other)
+	assert.NoError(t, err)
+	assert.NotNil(t, existing)
+	assert.False(t, existing.IsAbandoned(time.Now()))
+
+	// Renewing pushes the lease further.
+	until := time.Now().Add(time.Hour)
+	assert.NoError(t, gateway.Renew(retry, until))
+
+	found, err := gateway.Find("user-1", "key-1")
+	assert.NoError(t, err)
+	assert.WithinDuration(t, until, found.LockedUntil, time.Millisecond)
+}
+
+func TestIdempotencyKeyReleaseAndPurge(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+	db.AutoMigrate(&entity.IdempotencyKey{})
+
+	gateway := NewIdempotencyKeyGateway(db)
+
+	key, _ := entity.NewIdempotencyKey("user-1", "key-1", "abc", time.Hour)
+	gateway.Reserve(key)
+
+	assert.NoError(t, gateway.Release("user-1", "key-1"))
+	_, err = gateway.Find("user-1", "key-1")
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+
+	old, _ := entity.NewIdempotencyKey("user-1", "key-2", "abc", -time.Hour)
+	fresh, _ := entity.NewIdempotencyKey("user-1", "key-3", "abc", time.Hour)
+	gateway.Reserve(old)
+	gateway.Reserve(fresh)
+
+	n, err := gateway.Purge(time.Now())
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+}
@@ -1,6 +1,12 @@
 package database
 
-import "github.com/rgoncalvesrr/fullcycle-clean-arch/internal/entity"
+import (
+	"context"
+	"time"
+
+	"github.com/rgoncalvesrr/fullcycle-clean-arch/internal/entity"
+	"github.com/rgoncalvesrr/fullcycle-clean-arch/pkg/events"
+)
 
 type UserInterface interface {
 	Create(user *entity.User) error
@@ -10,7 +16,52 @@ type UserInterface interface {
 type ProductInterface interface {
 	Create(product *entity.Product) error
 	FindAll(offset, limit int, sort string) ([]entity.Product, error)
+	FindByQuery(query ProductQuery) ([]entity.Product, error)
 	FindByID(id string) (*entity.Product, error)
+	FindBySKU(sku string) (*entity.Product, error)
+	Each(query ProductQuery, fn func(*entity.Product) error) error
+	Search(q string, offset, limit int) ([]entity.Product, error)
 	Update(*entity.Product) error
+	DecrementStock(id string, quantity int) (*entity.Product, error)
+	IncrementStock(id string, quantity int) (*entity.Product, error)
+	Delete(id string, deletedBy string, version int) error
+	Uncategorize(categoryID string) (int64, error)
+	Restore(id string) (*entity.Product, error)
+	Purge(deletedBefore time.Time) (int64, error)
+	Transaction(fn func(tx ProductInterface) error) error
+	RecordEvent(ctx context.Context, event events.IEvent) error
+	WithActor(actor string) ProductInterface
+	History(id string, offset, limit int) ([]entity.ProductHistory, error)
+	FindAsOf(id string, t time.Time) (*entity.Product, error)
+}
+
+type CategoryInterface interface {
+	Create(category *entity.Category) error
+	FindAll() ([]entity.Category, error)
+	FindByID(id string) (*entity.Category, error)
+	FindChildren(id string) ([]entity.Category, error)
+	FindDescendants(id string) ([]entity.Category, error)
+	AncestorIDs(id string) ([]string, error)
+	Update(category *entity.Category) error
 	Delete(id string) error
+	Transaction(fn func(tx CategoryInterface) error) error
+	RecordEvent(ctx context.Context, event events.IEvent) error
+	WithActor(actor string) CategoryInterface
+}
+
+type IdempotencyKeyInterface interface {
+	Reserve(key *entity.IdempotencyKey) (*entity.IdempotencyKey, error)
+	Find(scope string, key string) (*entity.IdempotencyKey, error)
+	Complete(key *entity.IdempotencyKey) error
+	Renew(key *entity.IdempotencyKey, until time.Time) error
+	Release(scope string, key string) error
+	Purge(expiredBefore time.Time) (int64, error)
+}
+
+type OrderInterface interface {
+	Create(order *entity.Order) error
+	FindAll(offset, limit int) ([]entity.Order, error)
+	FindByID(id string) (*entity.Order, error)
+	Transition(order *entity.Order, transition *entity.OrderTransition) error
+	WithActor(actor string) OrderInterface
 }
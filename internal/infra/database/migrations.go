@@ -0,0 +1,292 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/rgoncalvesrr/fullcycle-clean-arch/internal/entity"
+	"github.com/rgoncalvesrr/fullcycle-clean-arch/pkg/events"
+	"gorm.io/gorm"
+)
+
+// Migrate brings the schema up to date. Data migrations that AutoMigrate
+// can't express run first, against the tables as they were.
+func Migrate(db *gorm.DB) error {
+	if err := migrateProductCatalogue(db); err != nil {
+		return err
+	}
+
+	if err := migrateProductPrice(db); err != nil {
+		return err
+	}
+
+	if err := migrateProductCategories(db); err != nil {
+		return err
+	}
+
+	hasHistory := db.Migrator().HasTable(&entity.ProductHistory{})
+	untaxedOrders := db.Migrator().HasTable(&entity.Order{}) && !db.Migrator().HasColumn(&entity.Order{}, "subtotal_amount")
+	orderStreamTables := db.Migrator().HasTable(&legacyOrderStreamEvent{})
+	unsourcedOrders := db.Migrator().HasTable(&entity.Order{}) && !orderStreamTables && !db.Migrator().HasColumn(&entity.StoredEvent{}, "stream")
+
+	err := db.AutoMigrate(&entity.User{}, &entity.Category{}, &entity.Product{}, &entity.ProductHistory{}, &entity.Order{}, &entity.OrderItem{}, &entity.OrderTransition{}, &entity.IdempotencyKey{}, &entity.DeadLetter{}, &entity.StoredEvent{}, &entity.EventCheckpoint{}, &entity.EventSnapshot{})
+	if err != nil {
+		return err
+	}
+
+	if orderStreamTables {
+		if err = migrateOrderStreamTables(db); err != nil {
+			return err
+		}
+	}
+
+	if untaxedOrders {
+		if err = migrateOrderTax(db); err != nil {
+			return err
+		}
+	}
+
+	if unsourcedOrders {
+		if err = migrateOrderStreams(db); err != nil {
+			return err
+		}
+	}
+
+	if hasHistory {
+		return nil
+	}
+
+	return migrateProductHistory(db)
+}
+
+// migrateOrderTax fills the tax columns of orders placed before taxes were
+// charged: they were untaxed, so their subtotal is their total.
+func migrateOrderTax(db *gorm.DB) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Exec(
+			"UPDATE orders SET subtotal_amount = total_amount, subtotal_currency = total_currency, tax_amount = 0, tax_currency = total_currency",
+		).Error
+		if err != nil {
+			return err
+		}
+
+		return tx.Exec(
+			"UPDATE order_items SET tax_rate = '0.00', tax_amount = 0, tax_currency = total_currency",
+		).Error
+	})
+}
+
+// migrateOrderStreams writes to the event store the history of the orders
+// placed before they were event sourced, from their rows: their creation,
+// their lines and their transitions.
+func migrateOrderStreams(db *gorm.DB) error {
+	var orders []entity.Order
+
+	store := NewEventStoreGateway(db)
+
+	return db.Preload("Items").Preload("Transitions", orderTransitionsByDate).FindInBatches(&orders, 100, func(tx *gorm.DB, batch int) error {
+		for i := range orders {
+			id := orders[i].ID.String()
+			history := entity.NewOrderEvents(&orders[i])
+			stream := make([]events.IEvent, 0, len(history))
+
+			for _, event := range history {
+				occurredAt := orders[i].CreatedAt
+				if t, ok := event.(entity.OrderTransitioned); ok {
+					occurredAt = t.Transition.CreatedAt
+				}
+
+				stream = append(stream, &orderStreamEvent{orderID: id, event: event, occurredAt: occurredAt})
+			}
+
+			version, err := store.AppendToStream(context.Background(), orderStream(id), 0, stream...)
+			if err != nil {
+				return err
+			}
+
+			if err := db.Model(&entity.Order{}).Where("id = ?", id).Update("version", version).Error; err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}).Error
+}
+
+// legacyOrderStreamEvent is a row of order_stream_events, where the orders'
+// events were kept before they moved to the event store.
+type legacyOrderStreamEvent struct {
+	OrderID    string
+	Version    int
+	EventName  string
+	Payload    []byte
+	OccurredAt time.Time
+}
+
+func (legacyOrderStreamEvent) TableName() string {
+	return "order_stream_events"
+}
+
+// migrateOrderStreamTables moves the orders' events from the tables they
+// had of their own to the event store. Their snapshots are dropped: loading
+// an order without one replays its whole stream, and saves a new one.
+func migrateOrderStreamTables(db *gorm.DB) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		var records []legacyOrderStreamEvent
+
+		if err := tx.Order("order_id, version").Find(&records).Error; err != nil {
+			return err
+		}
+
+		store := NewEventStoreGateway(tx)
+
+		for start := 0; start < len(records); {
+			end := start
+			for end < len(records) && records[end].OrderID == records[start].OrderID {
+				end++
+			}
+
+			stream := make([]events.IEvent, 0, end-start)
+
+			for _, record := range records[start:end] {
+				event, err := entity.UnmarshalOrderEvent(record.EventName, record.Payload)
+				if err != nil {
+					return err
+				}
+
+				stream = append(stream, &orderStreamEvent{orderID: record.OrderID, event: event, occurredAt: record.OccurredAt})
+			}
+
+			if _, err := store.AppendToStream(context.Background(), orderStream(records[start].OrderID), 0, stream...); err != nil {
+				return err
+			}
+
+			start = end
+		}
+
+		return tx.Migrator().DropTable("order_stream_events", "order_snapshots")
+	})
+}
+
+// migrateProductHistory records a baseline of the products that existed
+// before history was kept, so point-in-time lookups find them.
+func migrateProductHistory(db *gorm.DB) error {
+	var products []entity.Product
+
+	return db.Unscoped().FindInBatches(&products, 100, func(tx *gorm.DB, batch int) error {
+		for i := range products {
+			history, err := entity.NewProductHistory(entity.ProductBaseline, "", nil, &products[i])
+			if err != nil {
+				return err
+			}
+
+			history.CreatedAt = products[i].UpdatedAt
+
+			if err = db.Create(history).Error; err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}).Error
+}
+
+// migrateProductCatalogue backfills the catalogue columns on products created
+// before they existed. SKU is unique, so existing rows get their ID as SKU.
+func migrateProductCatalogue(db *gorm.DB) error {
+	m := db.Migrator()
+
+	if !m.HasTable(&entity.Product{}) || m.HasColumn(&entity.Product{}, "SKU") {
+		return nil
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		for _, field := range []string{"SKU", "Description", "CategoryID", "Stock", "Status", "UpdatedAt"} {
+			if err := tx.Migrator().AddColumn(&entity.Product{}, field); err != nil {
+				return err
+			}
+		}
+
+		return tx.Exec(
+			"UPDATE products SET sku = id, description = '', stock = 0, status = ?, updated_at = created_at",
+			entity.ProductActive,
+		).Error
+	})
+}
+
+// migrateProductPrice moves the legacy float price column to integer minor
+// units in the default currency.
+func migrateProductPrice(db *gorm.DB) error {
+	m := db.Migrator()
+
+	if !m.HasTable(&entity.Product{}) || !m.HasColumn(&entity.Product{}, "price") {
+		return nil
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		for _, column := range []string{"price_amount", "price_currency"} {
+			if !tx.Migrator().HasColumn(&entity.Product{}, column) {
+				if err := tx.Migrator().AddColumn(&entity.Product{}, column); err != nil {
+					return err
+				}
+			}
+		}
+
+		err := tx.Exec(
+			"UPDATE products SET price_amount = ROUND(price * 100), price_currency = ?",
+			entity.DefaultCurrency,
+		).Error
+		if err != nil {
+			return err
+		}
+
+		return tx.Migrator().DropColumn(&entity.Product{}, "price")
+	})
+}
+
+// migrateProductCategories turns the free text category column into root
+// categories and links the products to them.
+func migrateProductCategories(db *gorm.DB) error {
+	m := db.Migrator()
+
+	if !m.HasTable(&entity.Product{}) || !m.HasColumn(&entity.Product{}, "category") {
+		return nil
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.AutoMigrate(&entity.Category{}); err != nil {
+			return err
+		}
+
+		if !tx.Migrator().HasColumn(&entity.Product{}, "category_id") {
+			if err := tx.Migrator().AddColumn(&entity.Product{}, "CategoryID"); err != nil {
+				return err
+			}
+		}
+
+		var names []string
+
+		err := tx.Table("products").Distinct("category").Where("category <> ''").Pluck("category", &names).Error
+		if err != nil {
+			return err
+		}
+
+		for _, name := range names {
+			category, err := entity.NewCategory(name, "", nil)
+			if err != nil {
+				return err
+			}
+
+			if err = tx.Create(category).Error; err != nil {
+				return err
+			}
+
+			err = tx.Table("products").Where("category = ?", name).Update("category_id", category.ID).Error
+			if err != nil {
+				return err
+			}
+		}
+
+		return tx.Migrator().DropColumn(&entity.Product{}, "category")
+	})
+}
@@ -0,0 +1,224 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/rgoncalvesrr/fullcycle-clean-arch/internal/entity"
+	pkgEntity "github.com/rgoncalvesrr/fullcycle-clean-arch/pkg/entity"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestMigrateBackfillsProductCatalogue(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+
+	type legacyProduct struct {
+		ID        pkgEntity.ID
+		Name      string
+		Price     float64
+		CreatedAt time.Time
+	}
+
+	legacy := db.Table("products")
+	assert.NoError(t, legacy.AutoMigrate(&legacyProduct{}))
+
+	ids := []pkgEntity.ID{pkgEntity.NewID(), pkgEntity.NewID()}
+	for _, id := range ids {
+		err = db.Table("products").Create(&legacyProduct{ID: id, Name: "Produto", Price: 10.99, CreatedAt: time.Now()}).Error
+		assert.NoError(t, err)
+	}
+
+	assert.NoError(t, Migrate(db))
+
+	product, err := NewProductGateway(db).FindByID(ids[0].String())
+	assert.NoError(t, err)
+	assert.Equal(t, ids[0].String(), product.SKU)
+	assert.Equal(t, entity.ProductActive, product.Status)
+	assert.Equal(t, entity.MustParseMoney("10.99", "BRL"), product.Price)
+	assert.NoError(t, product.Validate())
+
+	assert.False(t, db.Migrator().HasColumn(&entity.Product{}, "price"))
+
+	history, err := NewProductGateway(db).History(ids[0].String(), 0, 10)
+	assert.NoError(t, err)
+	assert.Len(t, history, 1)
+	assert.Equal(t, entity.ProductBaseline, history[0].Action)
+
+	// Running again on an up to date schema is a no-op.
+	assert.NoError(t, Migrate(db))
+
+	history, err = NewProductGateway(db).History(ids[0].String(), 0, 10)
+	assert.NoError(t, err)
+	assert.Len(t, history, 1)
+}
+
+func TestMigrateLinksProductCategories(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+
+	type legacyProduct struct {
+		ID            pkgEntity.ID
+		SKU           string
+		Name          string
+		Category      string
+		PriceAmount   int64
+		PriceCurrency string
+		Status        string
+		CreatedAt     time.Time
+		UpdatedAt     time.Time
+	}
+
+	assert.NoError(t, db.Table("products").AutoMigrate(&legacyProduct{}))
+
+	for i, category := range []string{"Papelaria", "Papelaria", "", "Informática"} {
+		err = db.Table("products").Create(&legacyProduct{
+			ID:            pkgEntity.NewID(),
+			SKU:           fmt.Sprintf("PRD-%d", i),
+			Name:          "Produto",
+			Category:      category,
+			PriceAmount:   100,
+			PriceCurrency: "BRL",
+			Status:        "active",
+		}).Error
+		assert.NoError(t, err)
+	}
+
+	assert.NoError(t, Migrate(db))
+	assert.False(t, db.Migrator().HasColumn(&entity.Product{}, "category"))
+
+	categories, err := NewCategoryGateway(db).FindAll()
+	assert.NoError(t, err)
+	assert.Len(t, categories, 2)
+	assert.Equal(t, "Informática", categories[0].Name)
+	assert.Equal(t, "Papelaria", categories[1].Name)
+
+	products, err := NewProductGateway(db).FindByQuery(ProductQuery{CategoryID: categories[1].ID.String()})
+	assert.NoError(t, err)
+	assert.Len(t, products, 2)
+}
+
+func TestMigrateFillsOrderTax(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+
+	type legacyOrder struct {
+		ID            pkgEntity.ID
+		TotalAmount   int64
+		TotalCurrency string
+		CreatedAt     time.Time
+	}
+
+	type legacyOrderItem struct {
+		ID            pkgEntity.ID
+		OrderID       pkgEntity.ID
+		Quantity      int
+		TotalAmount   int64
+		TotalCurrency string
+	}
+
+	assert.NoError(t, db.Table("orders").AutoMigrate(&legacyOrder{}))
+	assert.NoError(t, db.Table("order_items").AutoMigrate(&legacyOrderItem{}))
+
+	order := legacyOrder{ID: pkgEntity.NewID(), TotalAmount: 1050, TotalCurrency: "BRL", CreatedAt: time.Now()}
+	assert.NoError(t, db.Table("orders").Create(&order).Error)
+	assert.NoError(t, db.Table("order_items").Create(&legacyOrderItem{
+		ID: pkgEntity.NewID(), OrderID: order.ID, Quantity: 1, TotalAmount: 1050, TotalCurrency: "BRL",
+	}).Error)
+
+	assert.NoError(t, Migrate(db))
+
+	found, err := NewOrderGateway(db).FindByID(order.ID.String())
+	assert.NoError(t, err)
+	assert.Equal(t, entity.MustParseMoney("10.50", "BRL"), found.Subtotal)
+	assert.Equal(t, entity.MustParseMoney("0", "BRL"), found.Tax)
+	assert.Equal(t, "0.00", found.Items[0].TaxRate)
+	assert.Equal(t, entity.MustParseMoney("0", "BRL"), found.Items[0].Tax)
+}
+
+func TestMigrateWritesOrderStreams(t *testing.T) {
+	db := newOrderTestDB(t)
+	assert.NoError(t, db.Migrator().DropTable(&entity.StoredEvent{}, &entity.EventSnapshot{}))
+
+	pen := newStockedProduct(t, db, "PEN", "2.50", 10)
+	item, _ := entity.NewOrderItem(pen, 2)
+	order, _ := entity.NewOrder([]entity.OrderItem{item})
+	transition, _ := order.Pay("user-1")
+
+	// Stored as rows, the way orders were before they had a history.
+	assert.NoError(t, db.Create(order).Error)
+
+	assert.NoError(t, Migrate(db))
+
+	stored, err := NewEventStoreGateway(db).LoadStream(context.Background(), "order-"+order.ID.String(), 0)
+	assert.NoError(t, err)
+	assert.Len(t, stored, 3)
+	assert.Equal(t, "OrderCreated", stored[0].Event.GetName())
+	assert.Equal(t, "ItemAdded", stored[1].Event.GetName())
+	assert.Equal(t, "OrderPaid", stored[2].Event.GetName())
+	assert.Equal(t, 3, stored[2].StreamVersion)
+	assert.True(t, stored[2].Event.GetDateTime().Equal(transition.CreatedAt))
+
+	orderGateway := NewOrderGateway(db)
+
+	found, err := orderGateway.FindByID(order.ID.String())
+	assert.NoError(t, err)
+	assert.Equal(t, 3, found.Version)
+
+	transition, err = found.Ship("user-1")
+	assert.NoError(t, err)
+	assert.NoError(t, orderGateway.Transition(found, transition))
+	assert.Equal(t, 4, found.Version)
+}
+
+func TestMigrateMovesOrderStreamTables(t *testing.T) {
+	db := newOrderTestDB(t)
+
+	pen := newStockedProduct(t, db, "PEN", "2.50", 10)
+	item, _ := entity.NewOrderItem(pen, 2)
+	order, _ := entity.NewOrder([]entity.OrderItem{item})
+	order.Pay("user-1")
+	order.Version = 3
+	assert.NoError(t, db.Create(order).Error)
+
+	// The events in the tables orders had of their own.
+	assert.NoError(t, db.Migrator().CreateTable(&legacyOrderStreamEvent{}))
+	assert.NoError(t, db.Exec("CREATE TABLE order_snapshots (order_id text, version integer, state blob, created_at datetime)").Error)
+
+	for i, event := range entity.NewOrderEvents(order) {
+		payload, _ := json.Marshal(event)
+		record := &legacyOrderStreamEvent{OrderID: order.ID.String(), Version: i + 1, EventName: event.EventName(), Payload: payload, OccurredAt: order.CreatedAt}
+		assert.NoError(t, db.Create(record).Error)
+	}
+
+	assert.NoError(t, Migrate(db))
+
+	assert.False(t, db.Migrator().HasTable("order_stream_events"))
+	assert.False(t, db.Migrator().HasTable("order_snapshots"))
+
+	stored, err := NewEventStoreGateway(db).LoadStream(context.Background(), "order-"+order.ID.String(), 0)
+	assert.NoError(t, err)
+	assert.Len(t, stored, 3)
+	assert.Equal(t, "OrderPaid", stored[2].Event.GetName())
+
+	orderGateway := NewOrderGateway(db)
+
+	found, err := orderGateway.FindByID(order.ID.String())
+	assert.NoError(t, err)
+
+	transition, err := found.Ship("user-1")
+	assert.NoError(t, err)
+	assert.NoError(t, orderGateway.Transition(found, transition))
+	assert.Equal(t, 4, found.Version)
+}
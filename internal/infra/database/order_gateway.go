@@ -0,0 +1,365 @@
+package database
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/rgoncalvesrr/fullcycle-clean-arch/internal/entity"
+	"github.com/rgoncalvesrr/fullcycle-clean-arch/pkg/events"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// OrderGateway stores orders as their history of events (see
+// entity.OrderAggregate), one stream per order in the event store, and keeps
+// the orders tables, which FindAll and FindByID read, up to date from the
+// events in the same transaction.
+type OrderGateway struct {
+	DB *gorm.DB
+	// Actor is recorded in the history of the products whose stock the
+	// orders take.
+	Actor string
+	// SnapshotEvery is how many events an order takes between snapshots of
+	// its state; zero means defaultOrderSnapshotEvery.
+	SnapshotEvery int
+}
+
+const defaultOrderSnapshotEvery = 10
+
+func NewOrderGateway(db *gorm.DB) *OrderGateway {
+	return &OrderGateway{DB: db}
+}
+
+func (o *OrderGateway) WithActor(actor string) OrderInterface {
+	return &OrderGateway{DB: o.DB, Actor: actor, SnapshotEvery: o.SnapshotEvery}
+}
+
+// Create stores order and takes its items out of stock in the same
+// transaction, so either the whole order is placed or nothing changes. It
+// fails with entity.ErrProductNotFound or entity.ErrInsufficientStock
+// naming the offending product.
+func (o *OrderGateway) Create(order *entity.Order) error {
+	aggregate := entity.NewOrderAggregate(nil, 0)
+	if err := aggregate.Record(entity.NewOrderEvents(order)...); err != nil {
+		return err
+	}
+
+	err := o.DB.Transaction(func(tx *gorm.DB) error {
+		products := &ProductGateway{DB: tx, Actor: o.Actor}
+
+		for _, item := range order.Items {
+			_, err := products.DecrementStock(item.ProductID.String(), item.Quantity)
+
+			switch {
+			case errors.Is(err, gorm.ErrRecordNotFound):
+				return fmt.Errorf("%w: %s", entity.ErrProductNotFound, item.ProductID)
+			case errors.Is(err, entity.ErrInsufficientStock):
+				return fmt.Errorf("%w: %s", entity.ErrInsufficientStock, item.SKU)
+			case err != nil:
+				return err
+			}
+		}
+
+		return o.save(tx, aggregate, 0)
+	})
+	if err != nil {
+		return err
+	}
+
+	order.Version = aggregate.Version
+
+	return nil
+}
+
+// Transition stores an order status change made by order.TransitionTo. The
+// order must be at the version stored, so of two concurrent changes the
+// second fails with entity.ErrOrderVersionConflict, and the change must
+// follow from the stored status, or it fails with
+// entity.ErrInvalidOrderTransition. Cancelling an order puts its items back
+// in stock.
+func (o *OrderGateway) Transition(order *entity.Order, transition *entity.OrderTransition) error {
+	var version int
+
+	err := o.DB.Transaction(func(tx *gorm.DB) error {
+		aggregate, snapshotVersion, err := o.load(tx, order.ID.String())
+		if err != nil {
+			return err
+		}
+
+		if aggregate.Version != order.Version {
+			return fmt.Errorf("%w: order is at version %d, not %d", entity.ErrOrderVersionConflict, aggregate.Version, order.Version)
+		}
+
+		if err = aggregate.Transition(transition); err != nil {
+			return err
+		}
+
+		if err = o.save(tx, aggregate, snapshotVersion); err != nil {
+			return err
+		}
+
+		version = aggregate.Version
+
+		if transition.To != entity.OrderCancelled {
+			return nil
+		}
+
+		products := &ProductGateway{DB: tx, Actor: o.Actor}
+
+		for _, item := range aggregate.Order.Items {
+			_, err := products.IncrementStock(item.ProductID.String(), item.Quantity)
+
+			// A purged product has no stock to return to.
+			if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	order.Version = version
+
+	return nil
+}
+
+// RebuildProjection rewrites the orders tables from the orders' events, e.g.
+// after the way they are projected changed.
+func (o *OrderGateway) RebuildProjection() error {
+	return o.DB.Transaction(func(tx *gorm.DB) error {
+		for _, model := range []any{&entity.OrderTransition{}, &entity.OrderItem{}, &entity.Order{}} {
+			if err := tx.Session(&gorm.Session{AllowGlobalUpdate: true}).Delete(model).Error; err != nil {
+				return err
+			}
+		}
+
+		var streams []string
+
+		err := tx.Model(&entity.StoredEvent{}).Where("stream LIKE ?", orderStreamPrefix+"%").Distinct("stream").Pluck("stream", &streams).Error
+		if err != nil {
+			return err
+		}
+
+		for _, stream := range streams {
+			aggregate, _, err := o.load(tx, strings.TrimPrefix(stream, orderStreamPrefix))
+			if err != nil {
+				return err
+			}
+
+			if err = projectOrder(tx, &aggregate.Order, entity.NewOrderEvents(&aggregate.Order)); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// orderStreamPrefix starts the names of the orders' streams in the event
+// store, followed by the order ID.
+const orderStreamPrefix = "order-"
+
+func orderStream(id string) string {
+	return orderStreamPrefix + id
+}
+
+// orderStreamEvent is an order event as appended to the order's stream.
+type orderStreamEvent struct {
+	orderID    string
+	event      entity.OrderEvent
+	occurredAt time.Time
+}
+
+func (e *orderStreamEvent) GetName() string {
+	return e.event.EventName()
+}
+
+func (e *orderStreamEvent) GetKey() string {
+	return e.orderID
+}
+
+func (e *orderStreamEvent) GetDateTime() time.Time {
+	return e.occurredAt
+}
+
+func (e *orderStreamEvent) GetPayLoad() any {
+	return e.event
+}
+
+// load rebuilds an order from its latest snapshot and the events after it.
+// It also returns the version of the snapshot, 0 if there is none.
+func (o *OrderGateway) load(tx *gorm.DB, id string) (*entity.OrderAggregate, int, error) {
+	store := NewEventStoreGateway(tx)
+	ctx := tx.Statement.Context
+
+	aggregate := entity.NewOrderAggregate(nil, 0)
+	snapshotVersion := 0
+
+	snapshot, err := store.LoadSnapshot(ctx, orderStream(id))
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if snapshot != nil {
+		var state entity.Order
+
+		if err = json.Unmarshal(snapshot.State, &state); err != nil {
+			return nil, 0, err
+		}
+
+		aggregate = entity.NewOrderAggregate(&state, snapshot.Version)
+		snapshotVersion = snapshot.Version
+	}
+
+	stored, err := store.LoadStream(ctx, orderStream(id), aggregate.Version)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if aggregate.Version == 0 && len(stored) == 0 {
+		return nil, 0, gorm.ErrRecordNotFound
+	}
+
+	for _, s := range stored {
+		raw := s.Event.(*events.RawEvent)
+
+		event, err := entity.UnmarshalOrderEvent(raw.Name, raw.Payload)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		if err = aggregate.Load(event); err != nil {
+			return nil, 0, err
+		}
+	}
+
+	return aggregate, snapshotVersion, nil
+}
+
+// save appends the changes of aggregate to its stream, projects them and
+// takes a snapshot when enough events went by since the one at
+// snapshotVersion.
+func (o *OrderGateway) save(tx *gorm.DB, aggregate *entity.OrderAggregate, snapshotVersion int) error {
+	changes := aggregate.Changes()
+	if len(changes) == 0 {
+		return nil
+	}
+
+	store := NewEventStoreGateway(tx)
+	ctx := tx.Statement.Context
+	id := aggregate.Order.ID.String()
+	now := time.Now()
+
+	stream := make([]events.IEvent, 0, len(changes))
+	for _, change := range changes {
+		stream = append(stream, &orderStreamEvent{orderID: id, event: change, occurredAt: now})
+	}
+
+	_, err := store.AppendToStream(ctx, orderStream(id), aggregate.StoredVersion(), stream...)
+	if errors.Is(err, events.ErrStreamVersionConflict) {
+		// Lost a race with a concurrent change of the same order.
+		return fmt.Errorf("%w: %w", entity.ErrOrderVersionConflict, err)
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := projectOrder(tx, &aggregate.Order, changes); err != nil {
+		return err
+	}
+
+	aggregate.MarkStored()
+
+	snapshotEvery := o.SnapshotEvery
+	if snapshotEvery <= 0 {
+		snapshotEvery = defaultOrderSnapshotEvery
+	}
+
+	if aggregate.Version-snapshotVersion < snapshotEvery {
+		return nil
+	}
+
+	state, err := json.Marshal(aggregate.Order)
+	if err != nil {
+		return err
+	}
+
+	return store.SaveSnapshot(ctx, events.Snapshot{
+		Stream:    orderStream(id),
+		Version:   aggregate.Version,
+		State:     state,
+		CreatedAt: now,
+	})
+}
+
+// projectOrder brings the orders tables up to date with the changes of
+// order, whose state is the one after them.
+func projectOrder(tx *gorm.DB, order *entity.Order, changes []entity.OrderEvent) error {
+	row := *order
+	row.Items, row.Transitions = nil, nil
+
+	for _, change := range changes {
+		var err error
+
+		switch e := change.(type) {
+		case entity.OrderCreated:
+			err = tx.Omit(clause.Associations).Create(&row).Error
+		case entity.ItemAdded:
+			item := e.Item
+			item.OrderID = order.ID
+			err = tx.Create(&item).Error
+		case entity.OrderTransitioned:
+			transition := e.Transition
+			transition.OrderID = order.ID
+			err = tx.Create(&transition).Error
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Model(&row).Select("*").Omit(clause.Associations, "ID", "CreatedAt").Updates(&row).Error
+}
+
+func (o *OrderGateway) FindAll(offset, limit int) ([]entity.Order, error) {
+	offset, limit = searchPage(offset, limit)
+
+	var orders []entity.Order
+
+	err := o.DB.
+		Preload("Items").
+		Preload("Transitions", orderTransitionsByDate).
+		Order("created_at").
+		Offset(offset).
+		Limit(limit).
+		Find(&orders).Error
+
+	if err != nil {
+		orders = nil
+	}
+
+	return orders, err
+}
+
+func (o *OrderGateway) FindByID(id string) (*entity.Order, error) {
+	var order *entity.Order
+
+	err := o.DB.Preload("Items").Preload("Transitions", orderTransitionsByDate).First(&order, "id = ?", id).Error
+
+	if err != nil {
+		order = nil
+	}
+
+	return order, err
+}
+
+func orderTransitionsByDate(db *gorm.DB) *gorm.DB {
+	return db.Order("created_at")
+}
@@ -0,0 +1,302 @@
+package database
+
+import (
+	"testing"
+
+	"github.com/rgoncalvesrr/fullcycle-clean-arch/internal/entity"
+	"github.com/rgoncalvesrr/fullcycle-clean-arch/pkg/events"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func newOrderTestDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+	db.AutoMigrate(&entity.Product{}, &entity.ProductHistory{}, &entity.Order{}, &entity.OrderItem{}, &entity.OrderTransition{}, &entity.StoredEvent{}, &entity.EventSnapshot{})
+
+	return db
+}
+
+func newStockedProduct(t *testing.T, db *gorm.DB, sku string, price string, stock int) *entity.Product {
+	product, err := entity.NewProduct(sku, "Produto "+sku, entity.MustParseMoney(price, "BRL"))
+	assert.NoError(t, err)
+
+	product.Stock = stock
+	assert.NoError(t, NewProductGateway(db).Create(product))
+
+	return product
+}
+
+func TestOrderCreate(t *testing.T) {
+	db := newOrderTestDB(t)
+
+	pen := newStockedProduct(t, db, "PEN", "2.50", 10)
+	book := newStockedProduct(t, db, "BOOK", "15.90", 1)
+
+	penItem, _ := entity.NewOrderItem(pen, 4)
+	bookItem, _ := entity.NewOrderItem(book, 1)
+	order, err := entity.NewOrder([]entity.OrderItem{penItem, bookItem})
+	assert.NoError(t, err)
+
+	orderGateway := NewOrderGateway(db)
+	assert.NoError(t, orderGateway.Create(order))
+
+	found, err := orderGateway.FindByID(order.ID.String())
+	assert.NoError(t, err)
+	assert.Len(t, found.Items, 2)
+	assert.Equal(t, entity.MustParseMoney("25.90", "BRL"), found.Total)
+
+	pen, _ = NewProductGateway(db).FindByID(pen.ID.String())
+	book, _ = NewProductGateway(db).FindByID(book.ID.String())
+	assert.Equal(t, 6, pen.Stock)
+	assert.Equal(t, 0, book.Stock)
+
+	orders, err := orderGateway.FindAll(0, 10)
+	assert.NoError(t, err)
+	assert.Len(t, orders, 1)
+}
+
+func TestOrderCreateRollsBackOnInsufficientStock(t *testing.T) {
+	db := newOrderTestDB(t)
+
+	pen := newStockedProduct(t, db, "PEN", "2.50", 10)
+	book := newStockedProduct(t, db, "BOOK", "15.90", 1)
+
+	penItem, _ := entity.NewOrderItem(pen, 4)
+	bookItem, _ := entity.NewOrderItem(book, 2)
+	order, _ := entity.NewOrder([]entity.OrderItem{penItem, bookItem})
+
+	err := NewOrderGateway(db).Create(order)
+	assert.ErrorIs(t, err, entity.ErrInsufficientStock)
+	assert.Contains(t, err.Error(), "BOOK")
+
+	pen, _ = NewProductGateway(db).FindByID(pen.ID.String())
+	assert.Equal(t, 10, pen.Stock)
+
+	_, err = NewOrderGateway(db).FindByID(order.ID.String())
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+}
+
+func TestOrderCreateWithMissingProduct(t *testing.T) {
+	db := newOrderTestDB(t)
+
+	pen := newStockedProduct(t, db, "PEN", "2.50", 10)
+	penItem, _ := entity.NewOrderItem(pen, 1)
+	order, _ := entity.NewOrder([]entity.OrderItem{penItem})
+
+	assert.NoError(t, NewProductGateway(db).Delete(pen.ID.String(), "user-1", 0))
+
+	err := NewOrderGateway(db).Create(order)
+	assert.ErrorIs(t, err, entity.ErrProductNotFound)
+}
+
+func TestOrderTransition(t *testing.T) {
+	db := newOrderTestDB(t)
+
+	pen := newStockedProduct(t, db, "PEN", "2.50", 10)
+	item, _ := entity.NewOrderItem(pen, 4)
+	order, _ := entity.NewOrder([]entity.OrderItem{item})
+
+	orderGateway := NewOrderGateway(db)
+	assert.NoError(t, orderGateway.Create(order))
+
+	transition, err := order.Pay("user-1")
+	assert.NoError(t, err)
+	assert.NoError(t, orderGateway.Transition(order, transition))
+
+	found, err := orderGateway.FindByID(order.ID.String())
+	assert.NoError(t, err)
+	assert.Equal(t, entity.OrderPaid, found.Status)
+	assert.Len(t, found.Transitions, 1)
+	assert.Equal(t, "user-1", found.Transitions[0].Actor)
+	assert.Equal(t, entity.OrderPending, found.Transitions[0].From)
+
+	// A copy loaded before the payment can't be cancelled anymore.
+	stale := *order
+	stale.Status = entity.OrderPending
+	transition, err = stale.Cancel("user-2")
+	assert.NoError(t, err)
+	assert.ErrorIs(t, orderGateway.Transition(&stale, transition), entity.ErrInvalidOrderTransition)
+
+	found, _ = orderGateway.FindByID(order.ID.String())
+	assert.Equal(t, entity.OrderPaid, found.Status)
+	assert.Len(t, found.Transitions, 1)
+}
+
+func TestOrderCancelRestocks(t *testing.T) {
+	db := newOrderTestDB(t)
+
+	pen := newStockedProduct(t, db, "PEN", "2.50", 10)
+	item, _ := entity.NewOrderItem(pen, 4)
+	order, _ := entity.NewOrder([]entity.OrderItem{item})
+
+	orderGateway := NewOrderGateway(db)
+	assert.NoError(t, orderGateway.Create(order))
+
+	transition, _ := order.Cancel("user-1")
+	assert.NoError(t, orderGateway.Transition(order, transition))
+
+	pen, _ = NewProductGateway(db).FindByID(pen.ID.String())
+	assert.Equal(t, 10, pen.Stock)
+}
+
+func TestOrderTransitionDetectsConcurrentChanges(t *testing.T) {
+	db := newOrderTestDB(t)
+
+	pen := newStockedProduct(t, db, "PEN", "2.50", 10)
+	item, _ := entity.NewOrderItem(pen, 1)
+	order, _ := entity.NewOrder([]entity.OrderItem{item})
+
+	orderGateway := NewOrderGateway(db)
+	assert.NoError(t, orderGateway.Create(order))
+	assert.Equal(t, 2, order.Version)
+
+	first, _ := orderGateway.FindByID(order.ID.String())
+	second, _ := orderGateway.FindByID(order.ID.String())
+
+	transition, _ := first.Pay("user-1")
+	assert.NoError(t, orderGateway.Transition(first, transition))
+	assert.Equal(t, 3, first.Version)
+
+	// second was loaded at version 2 and is pending as far as it knows.
+	transition, err := second.Cancel("user-2")
+	assert.NoError(t, err)
+	assert.ErrorIs(t, orderGateway.Transition(second, transition), entity.ErrOrderVersionConflict)
+
+	found, _ := orderGateway.FindByID(order.ID.String())
+	assert.Equal(t, entity.OrderPaid, found.Status)
+	assert.Equal(t, 3, found.Version)
+
+	pen, _ = NewProductGateway(db).FindByID(pen.ID.String())
+	assert.Equal(t, 9, pen.Stock)
+}
+
+func TestOrderSaveDetectsConcurrentAppends(t *testing.T) {
+	db := newOrderTestDB(t)
+
+	pen := newStockedProduct(t, db, "PEN", "2.50", 10)
+	item, _ := entity.NewOrderItem(pen, 1)
+	order, _ := entity.NewOrder([]entity.OrderItem{item})
+
+	orderGateway := NewOrderGateway(db)
+	assert.NoError(t, orderGateway.Create(order))
+
+	// Loaded before another change is stored, as by a concurrent request
+	// that got past the version check.
+	stale, _, err := orderGateway.load(db, order.ID.String())
+	assert.NoError(t, err)
+
+	transition, _ := order.Pay("user-1")
+	assert.NoError(t, orderGateway.Transition(order, transition))
+
+	pending := stale.Order
+	cancel, _ := pending.Cancel("user-2")
+	assert.NoError(t, stale.Transition(cancel))
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		err := orderGateway.save(tx, stale, 0)
+		assert.ErrorIs(t, err, entity.ErrOrderVersionConflict)
+		assert.ErrorIs(t, err, events.ErrStreamVersionConflict)
+
+		// Nothing was inserted, so the transaction can go on.
+		var count int64
+		return tx.Model(&entity.StoredEvent{}).Count(&count).Error
+	})
+	assert.NoError(t, err)
+
+	found, _ := orderGateway.FindByID(order.ID.String())
+	assert.Equal(t, entity.OrderPaid, found.Status)
+	assert.Equal(t, 3, found.Version)
+}
+
+func TestOrderTransitionUnknownOrder(t *testing.T) {
+	db := newOrderTestDB(t)
+
+	pen := newStockedProduct(t, db, "PEN", "2.50", 10)
+	item, _ := entity.NewOrderItem(pen, 1)
+	order, _ := entity.NewOrder([]entity.OrderItem{item})
+
+	transition, _ := order.Pay("user-1")
+	assert.ErrorIs(t, NewOrderGateway(db).Transition(order, transition), gorm.ErrRecordNotFound)
+}
+
+func TestOrderSnapshots(t *testing.T) {
+	db := newOrderTestDB(t)
+
+	pen := newStockedProduct(t, db, "PEN", "2.50", 10)
+	book := newStockedProduct(t, db, "BOOK", "15.90", 5)
+	penItem, _ := entity.NewOrderItem(pen, 1)
+	bookItem, _ := entity.NewOrderItem(book, 1)
+	order, _ := entity.NewOrder([]entity.OrderItem{penItem, bookItem})
+
+	orderGateway := &OrderGateway{DB: db, SnapshotEvery: 4}
+	assert.NoError(t, orderGateway.Create(order))
+
+	// Three events so far: no snapshot yet.
+	var snapshots int64
+	db.Model(&entity.EventSnapshot{}).Count(&snapshots)
+	assert.Equal(t, int64(0), snapshots)
+
+	transition, _ := order.Pay("user-1")
+	assert.NoError(t, orderGateway.Transition(order, transition))
+
+	var snapshot entity.EventSnapshot
+	assert.NoError(t, db.First(&snapshot, "stream = ?", "order-"+order.ID.String()).Error)
+	assert.Equal(t, 4, snapshot.Version)
+
+	transition, _ = order.Ship("user-1")
+	assert.NoError(t, orderGateway.Transition(order, transition))
+
+	// Loaded from the snapshot and the one event after it.
+	aggregate, snapshotVersion, err := orderGateway.load(db, order.ID.String())
+	assert.NoError(t, err)
+	assert.Equal(t, 4, snapshotVersion)
+	assert.Equal(t, 5, aggregate.Version)
+	assert.Equal(t, entity.OrderShipped, aggregate.Order.Status)
+	assert.Len(t, aggregate.Order.Items, 2)
+	assert.Len(t, aggregate.Order.Transitions, 2)
+	assert.Equal(t, entity.MustParseMoney("18.40", "BRL"), aggregate.Order.Total)
+
+	// The same as replaying every event.
+	db.Delete(&entity.EventSnapshot{}, "stream = ?", "order-"+order.ID.String())
+	replayed, _, err := orderGateway.load(db, order.ID.String())
+	assert.NoError(t, err)
+	assert.Equal(t, aggregate.Order.Status, replayed.Order.Status)
+	assert.Equal(t, aggregate.Order.Total, replayed.Order.Total)
+	assert.Equal(t, aggregate.Version, replayed.Version)
+}
+
+func TestOrderRebuildProjection(t *testing.T) {
+	db := newOrderTestDB(t)
+
+	pen := newStockedProduct(t, db, "PEN", "2.50", 10)
+	item, _ := entity.NewOrderItem(pen, 3)
+	order, _ := entity.NewOrder([]entity.OrderItem{item})
+
+	orderGateway := NewOrderGateway(db)
+	assert.NoError(t, orderGateway.Create(order))
+
+	transition, _ := order.Pay("user-1")
+	assert.NoError(t, orderGateway.Transition(order, transition))
+
+	// Lose the read model.
+	db.Exec("DELETE FROM order_transitions")
+	db.Exec("DELETE FROM order_items")
+	db.Exec("UPDATE orders SET status = 'pending'")
+
+	assert.NoError(t, orderGateway.RebuildProjection())
+
+	found, err := orderGateway.FindByID(order.ID.String())
+	assert.NoError(t, err)
+	assert.Equal(t, entity.OrderPaid, found.Status)
+	assert.Equal(t, 3, found.Version)
+	assert.Len(t, found.Items, 1)
+	assert.Len(t, found.Transitions, 1)
+	assert.Equal(t, entity.MustParseMoney("7.50", "BRL"), found.Total)
+
+	orders, _ := orderGateway.FindAll(0, 10)
+	assert.Len(t, orders, 1)
+}
@@ -1,38 +1,80 @@
 package database
 
 import (
+	"context"
+	"errors"
+	"time"
+
 	"github.com/rgoncalvesrr/fullcycle-clean-arch/internal/entity"
+	"github.com/rgoncalvesrr/fullcycle-clean-arch/pkg/events"
 	"gorm.io/gorm"
 )
 
+var (
+	ErrVersionConflict = errors.New("product was modified by another request")
+	ErrSKUTaken        = errors.New("sku is already used by another product")
+	ErrSKUInTrash      = errors.New("sku is used by a deleted product, restore or purge it first")
+)
+
 type ProductGateway struct {
-	DB *gorm.DB
+	DB          *gorm.DB
+	SearchIndex ProductSearchIndex
+	// Actor is the user recorded in the history of the changes made through
+	// the gateway.
+	Actor string
 }
 
 func NewProductGateway(db *gorm.DB) *ProductGateway {
 	return &ProductGateway{DB: db}
 }
 
+// WithActor returns a gateway that records actor as the author of the
+// changes it makes.
+func (p *ProductGateway) WithActor(actor string) ProductInterface {
+	return &ProductGateway{DB: p.DB, SearchIndex: p.SearchIndex, Actor: actor}
+}
+
 func (p *ProductGateway) Create(product *entity.Product) error {
-	return p.DB.Create(product).Error
+	return p.DB.Transaction(func(tx *gorm.DB) error {
+		if err := checkSKU(tx, product); err != nil {
+			return err
+		}
+
+		if err := tx.Create(product).Error; err != nil {
+			return err
+		}
+
+		if err := p.record(tx, entity.ProductCreated, p.Actor, nil, product); err != nil {
+			return err
+		}
+
+		return p.index(tx, product)
+	})
 }
 
 func (p *ProductGateway) FindAll(offset, limit int, sort string) ([]entity.Product, error) {
-	if sort != "" && sort != "asc" && sort != "desc" {
+	if sort != "desc" {
 		sort = "asc"
 	}
 
-	if offset < 0 {
-		offset = 0
-	}
+	fields, _ := ParseProductSort(sort)
+
+	return p.FindByQuery(ProductQuery{
+		Sort:   fields,
+		Offset: offset,
+		Limit:  limit,
+	})
+}
 
-	if limit <= 0 || limit > 50 {
-		limit = 50
+func (p *ProductGateway) FindByQuery(query ProductQuery) ([]entity.Product, error) {
+	db, err := query.apply(p.DB)
+	if err != nil {
+		return nil, err
 	}
 
 	var products []entity.Product
 
-	err := p.DB.Limit(limit).Offset(offset).Order("created_at " + sort).Find(&products).Error
+	err = db.Find(&products).Error
 
 	if err != nil {
 		products = nil
@@ -53,18 +95,432 @@ func (p *ProductGateway) FindByID(id string) (*entity.Product, error) {
 	return product, err
 }
 
+func (p *ProductGateway) FindBySKU(sku string) (*entity.Product, error) {
+	var product *entity.Product
+
+	err := p.DB.First(&product, "sku = ?", sku).Error
+
+	if err != nil {
+		product = nil
+	}
+
+	return product, err
+}
+
+// Each calls fn for every product matching the filters and ordering of
+// query, reading them one at a time so the whole result set is never held
+// in memory. Offset and Limit are ignored. It stops at the first error
+// returned by fn.
+func (p *ProductGateway) Each(query ProductQuery, fn func(*entity.Product) error) error {
+	db, err := query.filter(p.DB.Model(&entity.Product{}))
+	if err != nil {
+		return err
+	}
+
+	rows, err := db.Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var product entity.Product
+
+		if err := p.DB.ScanRows(rows, &product); err != nil {
+			return err
+		}
+
+		if err := fn(&product); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+// Transaction runs fn with a gateway bound to a single database transaction,
+// committing when fn returns nil and rolling back otherwise.
+func (p *ProductGateway) Transaction(fn func(tx ProductInterface) error) error {
+	return p.DB.Transaction(func(tx *gorm.DB) error {
+		return fn(&ProductGateway{DB: tx, SearchIndex: p.SearchIndex, Actor: p.Actor})
+	})
+}
+
+// RecordEvent appends event to the event store. Called on the gateway handed
+// to Transaction, the event is stored with the change it tells of or not at
+// all.
+func (p *ProductGateway) RecordEvent(ctx context.Context, event events.IEvent) error {
+	_, err := NewEventStoreGateway(p.DB).Append(ctx, event)
+	return err
+}
+
+// Update saves product if it still has the version it was read with and
+// bumps that version. It fails with ErrVersionConflict when the product was
+// changed in the meantime.
 func (p *ProductGateway) Update(product *entity.Product) error {
-	if _, err := p.FindByID(product.ID.String()); err != nil {
+	current, err := p.FindByID(product.ID.String())
+	if err != nil {
 		return err
 	}
 
-	return p.DB.Save(product).Error
+	if current.Version != product.Version {
+		return ErrVersionConflict
+	}
+
+	expected := product.Version
+	product.Version++
+
+	err = p.DB.Transaction(func(tx *gorm.DB) error {
+		if product.SKU != current.SKU {
+			if err := checkSKU(tx, product); err != nil {
+				return err
+			}
+		}
+
+		result := tx.Model(product).
+			Where("version = ?", expected).
+			Select("*").
+			Omit("ID", "CreatedAt", "DeletedAt", "DeletedBy").
+			Updates(product)
+		if result.Error != nil {
+			return result.Error
+		}
+
+		if result.RowsAffected == 0 {
+			return ErrVersionConflict
+		}
+
+		if err := p.record(tx, entity.ProductUpdated, p.Actor, current, product); err != nil {
+			return err
+		}
+
+		return p.index(tx, product)
+	})
+
+	if err != nil {
+		product.Version = expected
+	}
+
+	return err
+}
+
+// DecrementStock takes quantity units out of the stock of a product, failing
+// with entity.ErrInsufficientStock rather than letting it go negative. The
+// check and the update are a single statement, so concurrent orders can't
+// oversell.
+func (p *ProductGateway) DecrementStock(id string, quantity int) (*entity.Product, error) {
+	var product *entity.Product
+
+	err := p.DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&entity.Product{}).
+			Where("id = ? AND stock >= ?", id, quantity).
+			Updates(map[string]any{
+				"stock":   gorm.Expr("stock - ?", quantity),
+				"version": gorm.Expr("version + 1"),
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+
+		if err := tx.First(&product, "id = ?", id).Error; err != nil {
+			return err
+		}
+
+		if result.RowsAffected == 0 {
+			return entity.ErrInsufficientStock
+		}
+
+		before := *product
+		before.Stock += quantity
+		before.Version--
+
+		return p.record(tx, entity.ProductUpdated, p.Actor, &before, product)
+	})
+
+	if err != nil {
+		product = nil
+	}
+
+	return product, err
+}
+
+// IncrementStock puts quantity units of a product back in stock, as when
+// an order is cancelled. Products in the trash are restocked too, so they
+// are accurate if restored.
+func (p *ProductGateway) IncrementStock(id string, quantity int) (*entity.Product, error) {
+	var product *entity.Product
+
+	err := p.DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Unscoped().Model(&entity.Product{}).
+			Where("id = ?", id).
+			Updates(map[string]any{
+				"stock":   gorm.Expr("stock + ?", quantity),
+				"version": gorm.Expr("version + 1"),
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+
+		if err := tx.Unscoped().First(&product, "id = ?", id).Error; err != nil {
+			return err
+		}
+
+		before := *product
+		before.Stock -= quantity
+		before.Version--
+
+		return p.record(tx, entity.ProductUpdated, p.Actor, &before, product)
+	})
+
+	if err != nil {
+		product = nil
+	}
+
+	return product, err
 }
 
-func (p *ProductGateway) Delete(id string) error {
+// Uncategorize takes the products in a category, trashed ones included, out
+// of it. Each one gets a new version and a history entry, as with Update.
+func (p *ProductGateway) Uncategorize(categoryID string) (int64, error) {
+	var products []entity.Product
+
+	if err := p.DB.Unscoped().Where("category_id = ?", categoryID).Find(&products).Error; err != nil {
+		return 0, err
+	}
+
+	err := p.DB.Transaction(func(tx *gorm.DB) error {
+		for _, product := range products {
+			after := product
+			after.CategoryID = nil
+			after.Version++
+			after.UpdatedAt = time.Now()
+
+			result := tx.Unscoped().Model(&entity.Product{}).
+				Where("id = ? AND version = ?", product.ID, product.Version).
+				Updates(map[string]any{
+					"category_id": nil,
+					"version":     after.Version,
+					"updated_at":  after.UpdatedAt,
+				})
+			if result.Error != nil {
+				return result.Error
+			}
+
+			if result.RowsAffected == 0 {
+				return ErrVersionConflict
+			}
+
+			if err := p.record(tx, entity.ProductUpdated, p.Actor, &product, &after); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return int64(len(products)), nil
+}
+
+// Delete moves a product to the trash. It stays in the table, hidden from
+// every query, until it is restored or purged. A non zero version must match
+// the current one.
+func (p *ProductGateway) Delete(id string, deletedBy string, version int) error {
 	product, err := p.FindByID(id)
 	if err != nil {
 		return err
 	}
-	return p.DB.Delete(&product).Error
+
+	if version != 0 && product.Version != version {
+		return ErrVersionConflict
+	}
+
+	deleted := *product
+	deleted.DeletedAt = gorm.DeletedAt{Time: time.Now(), Valid: true}
+	deleted.DeletedBy = deletedBy
+	deleted.Version++
+
+	return p.DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(product).
+			Where("version = ?", product.Version).
+			Updates(map[string]any{
+				"deleted_at": deleted.DeletedAt.Time,
+				"deleted_by": deletedBy,
+				"version":    gorm.Expr("version + 1"),
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+
+		if result.RowsAffected == 0 {
+			return ErrVersionConflict
+		}
+
+		if err := p.record(tx, entity.ProductDeleted, deletedBy, product, &deleted); err != nil {
+			return err
+		}
+
+		if p.SearchIndex == nil {
+			return nil
+		}
+
+		return p.SearchIndex.Remove(tx, id)
+	})
+}
+
+// Restore takes a product out of the trash.
+func (p *ProductGateway) Restore(id string) (*entity.Product, error) {
+	var product *entity.Product
+
+	err := p.DB.Unscoped().First(&product, "id = ? AND deleted_at IS NOT NULL", id).Error
+	if err != nil {
+		return nil, err
+	}
+
+	trashed := *product
+	version := product.Version + 1
+
+	err = p.DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Unscoped().Model(product).Updates(map[string]any{
+			"deleted_at": nil,
+			"deleted_by": "",
+			"version":    version,
+		}).Error
+		if err != nil {
+			return err
+		}
+
+		product.DeletedAt = gorm.DeletedAt{}
+		product.DeletedBy = ""
+		product.Version = version
+
+		if err := p.record(tx, entity.ProductRestored, p.Actor, &trashed, product); err != nil {
+			return err
+		}
+
+		return p.index(tx, product)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return product, nil
+}
+
+// Purge permanently removes products deleted before the given time and
+// returns how many were removed.
+func (p *ProductGateway) Purge(deletedBefore time.Time) (int64, error) {
+	result := p.DB.Unscoped().
+		Where("deleted_at IS NOT NULL AND deleted_at < ?", deletedBefore).
+		Delete(&entity.Product{})
+
+	return result.RowsAffected, result.Error
+}
+
+// History lists the recorded changes of a product, oldest first. It is kept
+// after the product is deleted or purged.
+func (p *ProductGateway) History(id string, offset, limit int) ([]entity.ProductHistory, error) {
+	offset, limit = searchPage(offset, limit)
+
+	var history []entity.ProductHistory
+
+	err := p.DB.
+		Where("product_id = ?", id).
+		Order("version").
+		Order("created_at").
+		Offset(offset).
+		Limit(limit).
+		Find(&history).Error
+
+	if err != nil {
+		history = nil
+	}
+
+	return history, err
+}
+
+// FindAsOf returns the product as it was at t, from its history. It fails
+// with gorm.ErrRecordNotFound when the product didn't exist or was in the
+// trash at that time.
+func (p *ProductGateway) FindAsOf(id string, t time.Time) (*entity.Product, error) {
+	var history entity.ProductHistory
+
+	err := p.DB.
+		Where("product_id = ? AND created_at <= ?", id, t).
+		Order("version desc").
+		Order("created_at desc").
+		First(&history).Error
+	if err != nil {
+		return nil, err
+	}
+
+	product, err := history.AfterProduct()
+	if err != nil {
+		return nil, err
+	}
+
+	if product == nil || product.DeletedAt.Valid {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	return product, nil
+}
+
+func (p *ProductGateway) Search(q string, offset, limit int) ([]entity.Product, error) {
+	if p.SearchIndex == nil {
+		return nil, ErrSearchUnavailable
+	}
+
+	return p.SearchIndex.Search(p.DB, q, offset, limit)
+}
+
+func (p *ProductGateway) Reindex() error {
+	if p.SearchIndex == nil {
+		return ErrSearchUnavailable
+	}
+
+	return p.SearchIndex.Reindex(p.DB)
+}
+
+// checkSKU fails with ErrSKUTaken or ErrSKUInTrash when another product has
+// the SKU of product. Deleted products keep their SKU until purged, so they
+// are looked up too rather than left to the unique index.
+func checkSKU(tx *gorm.DB, product *entity.Product) error {
+	var other []entity.Product
+
+	err := tx.Unscoped().
+		Select("id", "deleted_at").
+		Where("sku = ? AND id <> ?", product.SKU, product.ID).
+		Limit(1).
+		Find(&other).Error
+
+	switch {
+	case err != nil:
+		return err
+	case len(other) == 0:
+		return nil
+	case other[0].DeletedAt.Valid:
+		return ErrSKUInTrash
+	}
+
+	return ErrSKUTaken
+}
+
+func (p *ProductGateway) record(tx *gorm.DB, action entity.ProductHistoryAction, actor string, before, after *entity.Product) error {
+	history, err := entity.NewProductHistory(action, actor, before, after)
+	if err != nil {
+		return err
+	}
+
+	return tx.Create(history).Error
+}
+
+func (p *ProductGateway) index(tx *gorm.DB, product *entity.Product) error {
+	if p.SearchIndex == nil {
+		return nil
+	}
+
+	return p.SearchIndex.Index(tx, product)
 }
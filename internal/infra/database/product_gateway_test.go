@@ -3,7 +3,9 @@ package database
 import (
 	"fmt"
 	"math/rand"
+	"slices"
 	"testing"
+	"time"
 
 	"github.com/rgoncalvesrr/fullcycle-clean-arch/internal/entity"
 	"github.com/stretchr/testify/assert"
@@ -16,9 +18,9 @@ func TestProductCreate(t *testing.T) {
 	if err != nil {
 		t.Error(err)
 	}
-	db.AutoMigrate(&entity.Product{})
+	db.AutoMigrate(&entity.Product{}, &entity.ProductHistory{})
 
-	product, err := entity.NewProduct("Produto", 50.0)
+	product, err := entity.NewProduct("PRD-1", "Produto", entity.MustParseMoney("50", "BRL"))
 
 	assert.Nil(t, err)
 	assert.NotNil(t, product)
@@ -36,7 +38,7 @@ func TestProductFindAll(t *testing.T) {
 	if err != nil {
 		t.Error(err)
 	}
-	db.AutoMigrate(&entity.Product{})
+	db.AutoMigrate(&entity.Product{}, &entity.ProductHistory{})
 
 	productGateway := NewProductGateway(db)
 	assert.NotNil(t, productGateway)
@@ -45,7 +47,7 @@ func TestProductFindAll(t *testing.T) {
 	var product *entity.Product
 
 	for i := 0; i < 100; i++ {
-		product, e = entity.NewProduct(fmt.Sprintf("Produto %d", i+1), rand.Float64()*100)
+		product, e = entity.NewProduct(fmt.Sprintf("PRD-%d", i+1), fmt.Sprintf("Produto %d", i+1), randomPrice())
 		assert.NoError(t, e)
 
 		e = productGateway.Create(product)
@@ -72,7 +74,7 @@ func TestProductFindById(t *testing.T) {
 	if err != nil {
 		t.Error(err)
 	}
-	db.AutoMigrate(&entity.Product{})
+	db.AutoMigrate(&entity.Product{}, &entity.ProductHistory{})
 
 	productGateway := NewProductGateway(db)
 	assert.NotNil(t, productGateway)
@@ -81,7 +83,7 @@ func TestProductFindById(t *testing.T) {
 	var product *entity.Product
 
 	for i := 0; i < 10; i++ {
-		product, e = entity.NewProduct(fmt.Sprintf("Produto %d", i+1), rand.Float64()*100)
+		product, e = entity.NewProduct(fmt.Sprintf("PRD-%d", i+1), fmt.Sprintf("Produto %d", i+1), randomPrice())
 		assert.NoError(t, e)
 
 		e = productGateway.Create(product)
@@ -104,13 +106,13 @@ func TestProductUpdate(t *testing.T) {
 	if err != nil {
 		t.Error(err)
 	}
-	db.AutoMigrate(&entity.Product{})
+	db.AutoMigrate(&entity.Product{}, &entity.ProductHistory{})
 
 	productGateway := NewProductGateway(db)
 	assert.NotNil(t, productGateway)
 
 	expectedName := "Produto 1"
-	product, err := entity.NewProduct(expectedName, rand.Float64()*100)
+	product, err := entity.NewProduct("PRD-1", expectedName, randomPrice())
 	assert.NoError(t, err)
 
 	err = productGateway.Create(product)
@@ -134,17 +136,526 @@ func TestProductDelete(t *testing.T) {
 	if err != nil {
 		t.Error(err)
 	}
-	db.AutoMigrate(&entity.Product{})
+	db.AutoMigrate(&entity.Product{}, &entity.ProductHistory{})
 
 	productGateway := NewProductGateway(db)
 	assert.NotNil(t, productGateway)
 
-	product, err := entity.NewProduct("Produto", rand.Float64()*100)
+	product, err := entity.NewProduct("PRD-1", "Produto", randomPrice())
 	assert.NoError(t, err)
 
 	err = productGateway.Create(product)
 	assert.NoError(t, err)
 
-	err = productGateway.Delete(product.ID.String())
+	err = productGateway.Delete(product.ID.String(), "usuario", 0)
 	assert.NoError(t, err)
+
+	_, err = productGateway.FindByID(product.ID.String())
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+
+	products, err := productGateway.FindByQuery(ProductQuery{})
+	assert.NoError(t, err)
+	assert.Len(t, products, 0)
+
+	products, err = productGateway.FindByQuery(ProductQuery{OnlyDeleted: true})
+	assert.NoError(t, err)
+	assert.Len(t, products, 1)
+	assert.Equal(t, "usuario", products[0].DeletedBy)
+	assert.True(t, products[0].IsDeleted())
+}
+
+func TestProductRestore(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+	db.AutoMigrate(&entity.Product{}, &entity.ProductHistory{})
+
+	productGateway := NewProductGateway(db)
+
+	product, err := entity.NewProduct("PRD-1", "Produto", randomPrice())
+	assert.NoError(t, err)
+	assert.NoError(t, productGateway.Create(product))
+
+	_, err = productGateway.Restore(product.ID.String())
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+
+	assert.NoError(t, productGateway.Delete(product.ID.String(), "usuario", 0))
+
+	restored, err := productGateway.Restore(product.ID.String())
+	assert.NoError(t, err)
+	assert.False(t, restored.IsDeleted())
+	assert.Empty(t, restored.DeletedBy)
+
+	products, err := productGateway.FindByQuery(ProductQuery{IncludeDeleted: true})
+	assert.NoError(t, err)
+	assert.Len(t, products, 1)
+	assert.False(t, products[0].IsDeleted())
+}
+
+func TestProductPurge(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+	db.AutoMigrate(&entity.Product{}, &entity.ProductHistory{})
+
+	productGateway := NewProductGateway(db)
+
+	for i := 0; i < 3; i++ {
+		product, err := entity.NewProduct(fmt.Sprintf("PRD-%d", i+1), "Produto", randomPrice())
+		assert.NoError(t, err)
+		assert.NoError(t, productGateway.Create(product))
+
+		if i > 0 {
+			assert.NoError(t, productGateway.Delete(product.ID.String(), "usuario", 0))
+		}
+	}
+
+	n, err := productGateway.Purge(time.Now().Add(-time.Hour))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), n)
+
+	n, err = productGateway.Purge(time.Now().Add(time.Second))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), n)
+
+	products, err := productGateway.FindByQuery(ProductQuery{IncludeDeleted: true})
+	assert.NoError(t, err)
+	assert.Len(t, products, 1)
+}
+
+func TestProductFindByQuery(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+	db.AutoMigrate(&entity.Product{}, &entity.ProductHistory{})
+
+	productGateway := NewProductGateway(db)
+	assert.NotNil(t, productGateway)
+
+	names := []string{"Caneta Azul", "Caneta Preta", "Lapis", "Borracha", "Caderno"}
+	prices := []string{"2.50", "2.50", "1.00", "0.75", "15.00"}
+
+	for i, name := range names {
+		product, e := entity.NewProduct(fmt.Sprintf("PRD-%d", i+1), name, entity.MustParseMoney(prices[i], "BRL"))
+		assert.NoError(t, e)
+
+		e = productGateway.Create(product)
+		assert.NoError(t, e)
+	}
+
+	products, err := productGateway.FindByQuery(ProductQuery{NameContains: "net"})
+	assert.NoError(t, err)
+	assert.Len(t, products, 2)
+
+	products, err = productGateway.FindByQuery(ProductQuery{NamePrefix: "ca"})
+	assert.NoError(t, err)
+	assert.Len(t, products, 3)
+
+	min, max := entity.MustParseMoney("1", "BRL"), entity.MustParseMoney("2.50", "BRL")
+	products, err = productGateway.FindByQuery(ProductQuery{PriceMin: &min, PriceMax: &max})
+	assert.NoError(t, err)
+	assert.Len(t, products, 3)
+
+	sort, err := ParseProductSort("-price,name")
+	assert.NoError(t, err)
+
+	product, err := productGateway.FindByID(products[0].ID.String())
+	assert.NoError(t, err)
+	product.Archive()
+	assert.NoError(t, productGateway.Update(product))
+
+	products, err = productGateway.FindByQuery(ProductQuery{Status: entity.ProductArchived})
+	assert.NoError(t, err)
+	assert.Len(t, products, 1)
+
+	products, err = productGateway.FindByQuery(ProductQuery{Sort: sort})
+	assert.NoError(t, err)
+	assert.Len(t, products, 5)
+	assert.Equal(t, "Caderno", products[0].Name)
+	assert.Equal(t, "Caneta Azul", products[1].Name)
+	assert.Equal(t, "Caneta Preta", products[2].Name)
+	assert.Equal(t, "Borracha", products[4].Name)
+}
+
+func TestProductFindByQueryBreaksTiesByID(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+	db.AutoMigrate(&entity.Product{}, &entity.ProductHistory{})
+
+	productGateway := NewProductGateway(db)
+
+	for i := 1; i <= 5; i++ {
+		product, e := entity.NewProduct(fmt.Sprintf("PRD-%d", i), "Caneta", entity.MustParseMoney("2.50", "BRL"))
+		assert.NoError(t, e)
+		assert.NoError(t, productGateway.Create(product))
+	}
+
+	sort, err := ParseProductSort("price")
+	assert.NoError(t, err)
+
+	var ids []string
+	for offset := 0; offset < 5; offset++ {
+		products, e := productGateway.FindByQuery(ProductQuery{Sort: sort, Offset: offset, Limit: 1})
+		assert.NoError(t, e)
+		assert.Len(t, products, 1)
+		ids = append(ids, products[0].ID.String())
+	}
+
+	assert.True(t, slices.IsSorted(ids))
+	assert.Len(t, slices.Compact(slices.Clone(ids)), 5)
+}
+
+func TestParseProductSort(t *testing.T) {
+	fields, err := ParseProductSort("price,-name")
+	assert.NoError(t, err)
+	assert.Equal(t, []SortField{{Field: "price"}, {Field: "name", Desc: true}}, fields)
+
+	fields, err = ParseProductSort("desc")
+	assert.NoError(t, err)
+	assert.Equal(t, []SortField{{Field: "created_at", Desc: true}}, fields)
+
+	_, err = ParseProductSort("password")
+	assert.ErrorIs(t, err, ErrInvalidSortField)
+}
+
+func TestProductSearchWithoutIndex(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+	db.AutoMigrate(&entity.Product{}, &entity.ProductHistory{})
+
+	productGateway := NewProductGateway(db)
+
+	_, err = productGateway.Search("produto", 0, 10)
+	assert.ErrorIs(t, err, ErrSearchUnavailable)
+}
+
+func TestProductCreateWithDuplicatedSKU(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+	db.AutoMigrate(&entity.Product{}, &entity.ProductHistory{})
+
+	productGateway := NewProductGateway(db)
+
+	product, err := entity.NewProduct("PRD-1", "Produto", entity.MustParseMoney("10", "BRL"))
+	assert.NoError(t, err)
+	assert.NoError(t, productGateway.Create(product))
+
+	product, err = entity.NewProduct("PRD-1", "Outro Produto", entity.MustParseMoney("20", "BRL"))
+	assert.NoError(t, err)
+	assert.ErrorIs(t, productGateway.Create(product), ErrSKUTaken)
+}
+
+func TestProductCreateWithTrashedSKU(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+	db.AutoMigrate(&entity.Product{}, &entity.ProductHistory{})
+
+	productGateway := NewProductGateway(db)
+
+	trashed, err := entity.NewProduct("PRD-1", "Produto", entity.MustParseMoney("10", "BRL"))
+	assert.NoError(t, err)
+	assert.NoError(t, productGateway.Create(trashed))
+	assert.NoError(t, productGateway.Delete(trashed.ID.String(), "admin", 0))
+
+	product, err := entity.NewProduct("PRD-1", "Outro Produto", entity.MustParseMoney("20", "BRL"))
+	assert.NoError(t, err)
+	assert.ErrorIs(t, productGateway.Create(product), ErrSKUInTrash)
+
+	// Moving another product onto the SKU is refused the same way.
+	other, err := entity.NewProduct("PRD-2", "Outro Produto", entity.MustParseMoney("20", "BRL"))
+	assert.NoError(t, err)
+	assert.NoError(t, productGateway.Create(other))
+
+	other.SKU = "PRD-1"
+	assert.ErrorIs(t, productGateway.Update(other), ErrSKUInTrash)
+
+	// Once purged the SKU is free again.
+	_, err = productGateway.Purge(time.Now().Add(time.Second))
+	assert.NoError(t, err)
+	assert.NoError(t, productGateway.Create(product))
+}
+
+func randomPrice() entity.Money {
+	return entity.Money{Amount: rand.Int63n(10000) + 1, Currency: entity.DefaultCurrency}
+}
+
+func TestProductUpdateVersionConflict(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+	db.AutoMigrate(&entity.Product{}, &entity.ProductHistory{})
+
+	productGateway := NewProductGateway(db)
+
+	product, err := entity.NewProduct("PRD-1", "Produto", randomPrice())
+	assert.NoError(t, err)
+	assert.NoError(t, productGateway.Create(product))
+
+	first, err := productGateway.FindByID(product.ID.String())
+	assert.NoError(t, err)
+	second, err := productGateway.FindByID(product.ID.String())
+	assert.NoError(t, err)
+
+	first.Name = "Primeira alteração"
+	assert.NoError(t, productGateway.Update(first))
+	assert.Equal(t, 2, first.Version)
+
+	second.Name = "Segunda alteração"
+	assert.ErrorIs(t, productGateway.Update(second), ErrVersionConflict)
+	assert.Equal(t, 1, second.Version)
+
+	found, err := productGateway.FindByID(product.ID.String())
+	assert.NoError(t, err)
+	assert.Equal(t, "Primeira alteração", found.Name)
+	assert.Equal(t, 2, found.Version)
+
+	assert.ErrorIs(t, productGateway.Delete(product.ID.String(), "usuario", 1), ErrVersionConflict)
+	assert.NoError(t, productGateway.Delete(product.ID.String(), "usuario", 2))
+
+	restored, err := productGateway.Restore(product.ID.String())
+	assert.NoError(t, err)
+	assert.Equal(t, 4, restored.Version)
+}
+
+func TestProductFindBySKU(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+	db.AutoMigrate(&entity.Product{}, &entity.ProductHistory{})
+
+	productGateway := NewProductGateway(db)
+
+	product, _ := entity.NewProduct("PRD-1", "Produto", randomPrice())
+	assert.NoError(t, productGateway.Create(product))
+
+	found, err := productGateway.FindBySKU("PRD-1")
+	assert.NoError(t, err)
+	assert.Equal(t, product.ID, found.ID)
+
+	_, err = productGateway.FindBySKU("PRD-2")
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+}
+
+func TestProductEach(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+	db.AutoMigrate(&entity.Product{}, &entity.ProductHistory{})
+
+	productGateway := NewProductGateway(db)
+
+	for i := 0; i < 80; i++ {
+		product, _ := entity.NewProduct(fmt.Sprintf("PRD-%02d", i+1), fmt.Sprintf("Produto %d", i+1), randomPrice())
+		if i%2 == 1 {
+			product.Archive()
+		}
+		assert.NoError(t, productGateway.Create(product))
+	}
+
+	var skus []string
+
+	// Offset and Limit don't apply to Each.
+	query := ProductQuery{Status: entity.ProductActive, Sort: []SortField{{Field: "sku", Desc: true}}, Limit: 10}
+	err = productGateway.Each(query, func(p *entity.Product) error {
+		skus = append(skus, p.SKU)
+		return nil
+	})
+
+	assert.NoError(t, err)
+	assert.Len(t, skus, 40)
+	assert.Equal(t, "PRD-79", skus[0])
+	assert.Equal(t, "PRD-01", skus[39])
+
+	stop := fmt.Errorf("stop")
+	calls := 0
+	err = productGateway.Each(ProductQuery{}, func(p *entity.Product) error {
+		calls++
+		return stop
+	})
+
+	assert.ErrorIs(t, err, stop)
+	assert.Equal(t, 1, calls)
+}
+
+func TestProductTransaction(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+	db.AutoMigrate(&entity.Product{}, &entity.ProductHistory{})
+
+	productGateway := NewProductGateway(db)
+
+	rollback := fmt.Errorf("rollback")
+	err = productGateway.Transaction(func(tx ProductInterface) error {
+		product, _ := entity.NewProduct("PRD-1", "Produto", randomPrice())
+		assert.NoError(t, tx.Create(product))
+
+		_, err := tx.FindBySKU("PRD-1")
+		assert.NoError(t, err)
+
+		return rollback
+	})
+
+	assert.ErrorIs(t, err, rollback)
+	_, err = productGateway.FindBySKU("PRD-1")
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+
+	err = productGateway.Transaction(func(tx ProductInterface) error {
+		product, _ := entity.NewProduct("PRD-1", "Produto", randomPrice())
+		return tx.Create(product)
+	})
+
+	assert.NoError(t, err)
+	_, err = productGateway.FindBySKU("PRD-1")
+	assert.NoError(t, err)
+}
+
+func TestProductHistory(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+	db.AutoMigrate(&entity.Product{}, &entity.ProductHistory{})
+
+	productGateway := NewProductGateway(db).WithActor("user-1")
+
+	product, _ := entity.NewProduct("PRD-1", "Produto", entity.MustParseMoney("10", "BRL"))
+	assert.NoError(t, productGateway.Create(product))
+
+	product.Price = entity.MustParseMoney("12.50", "BRL")
+	assert.NoError(t, NewProductGateway(db).WithActor("user-2").Update(product))
+
+	assert.NoError(t, productGateway.Delete(product.ID.String(), "user-3", 0))
+
+	_, err = productGateway.Restore(product.ID.String())
+	assert.NoError(t, err)
+
+	history, err := productGateway.History(product.ID.String(), 0, 10)
+	assert.NoError(t, err)
+	assert.Len(t, history, 4)
+
+	actions := []entity.ProductHistoryAction{entity.ProductCreated, entity.ProductUpdated, entity.ProductDeleted, entity.ProductRestored}
+	actors := []string{"user-1", "user-2", "user-3", "user-1"}
+	for i, h := range history {
+		assert.Equal(t, actions[i], h.Action)
+		assert.Equal(t, actors[i], h.Actor)
+		assert.Equal(t, i+1, h.Version)
+	}
+
+	before, _ := history[1].BeforeProduct()
+	after, _ := history[1].AfterProduct()
+	assert.Equal(t, "10.00", before.Price.String())
+	assert.Equal(t, "12.50", after.Price.String())
+}
+
+func TestProductFindAsOf(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+	db.AutoMigrate(&entity.Product{}, &entity.ProductHistory{})
+
+	productGateway := NewProductGateway(db)
+
+	product, _ := entity.NewProduct("PRD-1", "Produto", entity.MustParseMoney("10", "BRL"))
+	assert.NoError(t, productGateway.Create(product))
+
+	// Move the creation a week back so the lookups have something to
+	// tell apart.
+	weekAgo := time.Now().Add(-7 * 24 * time.Hour)
+	db.Model(&entity.ProductHistory{}).Where("version = 1").Update("created_at", weekAgo)
+
+	product.Price = entity.MustParseMoney("12.50", "BRL")
+	assert.NoError(t, productGateway.Update(product))
+
+	old, err := productGateway.FindAsOf(product.ID.String(), weekAgo.Add(time.Hour))
+	assert.NoError(t, err)
+	assert.Equal(t, "10.00", old.Price.String())
+	assert.Equal(t, 1, old.Version)
+
+	current, err := productGateway.FindAsOf(product.ID.String(), time.Now())
+	assert.NoError(t, err)
+	assert.Equal(t, "12.50", current.Price.String())
+
+	_, err = productGateway.FindAsOf(product.ID.String(), weekAgo.Add(-time.Hour))
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+
+	assert.NoError(t, productGateway.Delete(product.ID.String(), "user-1", 0))
+
+	_, err = productGateway.FindAsOf(product.ID.String(), time.Now())
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+}
+
+func TestProductDecrementStock(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+	db.AutoMigrate(&entity.Product{}, &entity.ProductHistory{})
+
+	productGateway := NewProductGateway(db)
+
+	product, _ := entity.NewProduct("PRD-1", "Produto", randomPrice())
+	product.Stock = 5
+	assert.NoError(t, productGateway.Create(product))
+
+	updated, err := productGateway.DecrementStock(product.ID.String(), 3)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, updated.Stock)
+	assert.Equal(t, 2, updated.Version)
+
+	_, err = productGateway.DecrementStock(product.ID.String(), 3)
+	assert.ErrorIs(t, err, entity.ErrInsufficientStock)
+
+	_, err = productGateway.DecrementStock("missing", 1)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+
+	history, _ := productGateway.History(product.ID.String(), 0, 10)
+	assert.Len(t, history, 2)
+
+	before, _ := history[1].BeforeProduct()
+	assert.Equal(t, 5, before.Stock)
+}
+
+func TestProductIncrementStock(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+	db.AutoMigrate(&entity.Product{}, &entity.ProductHistory{})
+
+	productGateway := NewProductGateway(db)
+
+	product, _ := entity.NewProduct("PRD-1", "Produto", randomPrice())
+	product.Stock = 2
+	assert.NoError(t, productGateway.Create(product))
+
+	updated, err := productGateway.IncrementStock(product.ID.String(), 3)
+	assert.NoError(t, err)
+	assert.Equal(t, 5, updated.Stock)
+	assert.Equal(t, 2, updated.Version)
+
+	assert.NoError(t, productGateway.Delete(product.ID.String(), "user-1", 0))
+
+	updated, err = productGateway.IncrementStock(product.ID.String(), 1)
+	assert.NoError(t, err)
+	assert.Equal(t, 6, updated.Stock)
+
+	_, err = productGateway.IncrementStock("missing", 1)
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
 }
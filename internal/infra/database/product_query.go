@@ -0,0 +1,170 @@
+package database
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/rgoncalvesrr/fullcycle-clean-arch/internal/entity"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidSortField = errors.New("invalid sort field")
+)
+
+// productSortColumns is the whitelist of fields a listing may be sorted by,
+// mapped to the column used in the ORDER BY clause.
+var productSortColumns = map[string]string{
+	"sku":        "sku",
+	"name":       "name",
+	"price":      "price_amount",
+	"stock":      "stock",
+	"created_at": "created_at",
+	"updated_at": "updated_at",
+}
+
+type SortField struct {
+	Field string
+	Desc  bool
+}
+
+// ProductQuery describes the filters, ordering and pagination of a product
+// listing. Zero values mean "no filter". CategoryID matches the category and
+// all of its subcategories.
+type ProductQuery struct {
+	NameContains string
+	NamePrefix   string
+	CategoryID   string
+	Status       entity.ProductStatus
+	PriceMin     *entity.Money
+	PriceMax     *entity.Money
+	CreatedFrom  *time.Time
+	CreatedTo    *time.Time
+	// IncludeDeleted lists trashed products along with the others;
+	// OnlyDeleted lists just the trash.
+	IncludeDeleted bool
+	OnlyDeleted    bool
+	Sort           []SortField
+	Offset         int
+	Limit          int
+}
+
+// ParseProductSort parses a sort expression like "price,-name" where a
+// leading "-" means descending order. The legacy values "asc" and "desc"
+// sort by creation date.
+func ParseProductSort(s string) ([]SortField, error) {
+	s = strings.TrimSpace(s)
+
+	switch s {
+	case "":
+		return nil, nil
+	case "asc":
+		return []SortField{{Field: "created_at"}}, nil
+	case "desc":
+		return []SortField{{Field: "created_at", Desc: true}}, nil
+	}
+
+	var fields []SortField
+
+	for _, f := range strings.Split(s, ",") {
+		f = strings.TrimSpace(f)
+		desc := strings.HasPrefix(f, "-")
+		f = strings.TrimPrefix(strings.TrimPrefix(f, "-"), "+")
+
+		if _, ok := productSortColumns[f]; !ok {
+			return nil, ErrInvalidSortField
+		}
+
+		fields = append(fields, SortField{Field: f, Desc: desc})
+	}
+
+	return fields, nil
+}
+
+func (q ProductQuery) apply(db *gorm.DB) (*gorm.DB, error) {
+	db, err := q.filter(db)
+	if err != nil {
+		return nil, err
+	}
+
+	offset := q.Offset
+	if offset < 0 {
+		offset = 0
+	}
+
+	limit := q.Limit
+	if limit <= 0 || limit > 50 {
+		limit = 50
+	}
+
+	return db.Offset(offset).Limit(limit), nil
+}
+
+// filter applies the filters and ordering of q, without pagination.
+func (q ProductQuery) filter(db *gorm.DB) (*gorm.DB, error) {
+	if q.IncludeDeleted || q.OnlyDeleted {
+		db = db.Unscoped()
+	}
+
+	if q.OnlyDeleted {
+		db = db.Where("deleted_at IS NOT NULL")
+	}
+
+	if q.NameContains != "" {
+		db = db.Where("LOWER(name) LIKE ? ESCAPE '\\'", "%"+escapeLike(strings.ToLower(q.NameContains))+"%")
+	}
+
+	if q.NamePrefix != "" {
+		db = db.Where("LOWER(name) LIKE ? ESCAPE '\\'", escapeLike(strings.ToLower(q.NamePrefix))+"%")
+	}
+
+	if q.CategoryID != "" {
+		db = db.Where("category_id IN (?)", gorm.Expr(descendantCategoriesSQL, q.CategoryID))
+	}
+
+	if q.Status != "" {
+		db = db.Where("status = ?", q.Status)
+	}
+
+	if q.PriceMin != nil {
+		db = db.Where("price_currency = ? AND price_amount >= ?", q.PriceMin.Currency, q.PriceMin.Amount)
+	}
+
+	if q.PriceMax != nil {
+		db = db.Where("price_currency = ? AND price_amount <= ?", q.PriceMax.Currency, q.PriceMax.Amount)
+	}
+
+	if q.CreatedFrom != nil {
+		db = db.Where("created_at >= ?", *q.CreatedFrom)
+	}
+
+	if q.CreatedTo != nil {
+		db = db.Where("created_at <= ?", *q.CreatedTo)
+	}
+
+	sort := q.Sort
+	if len(sort) == 0 {
+		sort = []SortField{{Field: "created_at"}}
+	}
+
+	for _, s := range sort {
+		column, ok := productSortColumns[s.Field]
+		if !ok {
+			return nil, ErrInvalidSortField
+		}
+
+		if s.Desc {
+			column += " desc"
+		}
+
+		db = db.Order(column)
+	}
+
+	// Rows that tie on every sort field keep a stable order across pages.
+	return db.Order("id"), nil
+}
+
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
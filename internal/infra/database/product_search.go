@@ -0,0 +1,225 @@
+package database
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/rgoncalvesrr/fullcycle-clean-arch/internal/entity"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrSearchUnavailable = errors.New("full-text search is not available")
+	ErrEmptySearchQuery  = errors.New("search query is required")
+)
+
+// ProductSearchIndex maintains a full-text index over product names. Methods
+// receive the *gorm.DB to run on so index maintenance can share the
+// transaction of the write that triggered it.
+type ProductSearchIndex interface {
+	Setup(db *gorm.DB) error
+	Index(db *gorm.DB, product *entity.Product) error
+	Remove(db *gorm.DB, id string) error
+	Search(db *gorm.DB, q string, offset, limit int) ([]entity.Product, error)
+	Reindex(db *gorm.DB) error
+}
+
+// NewProductSearchIndex returns the index implementation for the dialect of
+// db: FTS5 on SQLite, FULLTEXT on MySQL and tsvector on Postgres.
+func NewProductSearchIndex(db *gorm.DB) (ProductSearchIndex, error) {
+	switch db.Dialector.Name() {
+	case "sqlite":
+		return &sqliteProductSearchIndex{}, nil
+	case "mysql":
+		return &mysqlProductSearchIndex{}, nil
+	case "postgres":
+		return &postgresProductSearchIndex{}, nil
+	}
+
+	return nil, ErrSearchUnavailable
+}
+
+func searchTerms(q string) []string {
+	return strings.Fields(q)
+}
+
+func searchPage(offset, limit int) (int, int) {
+	if offset < 0 {
+		offset = 0
+	}
+
+	if limit <= 0 || limit > 50 {
+		limit = 50
+	}
+
+	return offset, limit
+}
+
+// sqliteProductSearchIndex keeps product names in an FTS5 virtual table and
+// ranks matches with bm25. The sqlite driver must be built with the
+// sqlite_fts5 tag.
+type sqliteProductSearchIndex struct{}
+
+func (s *sqliteProductSearchIndex) Setup(db *gorm.DB) error {
+	err := db.Exec("CREATE VIRTUAL TABLE IF NOT EXISTS products_fts USING fts5(id UNINDEXED, name)").Error
+	if err != nil && strings.Contains(err.Error(), "no such module") {
+		return ErrSearchUnavailable
+	}
+
+	return err
+}
+
+func (s *sqliteProductSearchIndex) Index(db *gorm.DB, product *entity.Product) error {
+	if err := s.Remove(db, product.ID.String()); err != nil {
+		return err
+	}
+
+	return db.Exec("INSERT INTO products_fts (id, name) VALUES (?, ?)", product.ID.String(), product.Name).Error
+}
+
+func (s *sqliteProductSearchIndex) Remove(db *gorm.DB, id string) error {
+	return db.Exec("DELETE FROM products_fts WHERE id = ?", id).Error
+}
+
+func (s *sqliteProductSearchIndex) Search(db *gorm.DB, q string, offset, limit int) ([]entity.Product, error) {
+	terms := searchTerms(q)
+	if len(terms) == 0 {
+		return nil, ErrEmptySearchQuery
+	}
+
+	// Every term is quoted so user input can't inject FTS5 query syntax,
+	// and matched as a prefix so partial words still find results.
+	for i, t := range terms {
+		terms[i] = `"` + strings.ReplaceAll(t, `"`, `""`) + `"*`
+	}
+
+	offset, limit = searchPage(offset, limit)
+
+	var products []entity.Product
+
+	err := db.Model(&entity.Product{}).
+		Joins("JOIN products_fts ON products_fts.id = products.id").
+		Where("products_fts MATCH ?", strings.Join(terms, " ")).
+		Order("bm25(products_fts)").
+		Offset(offset).
+		Limit(limit).
+		Find(&products).Error
+
+	if err != nil {
+		products = nil
+	}
+
+	return products, err
+}
+
+func (s *sqliteProductSearchIndex) Reindex(db *gorm.DB) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("DELETE FROM products_fts").Error; err != nil {
+			return err
+		}
+
+		return tx.Exec("INSERT INTO products_fts (id, name) SELECT id, name FROM products WHERE deleted_at IS NULL").Error
+	})
+}
+
+// mysqlProductSearchIndex relies on a FULLTEXT index, which MySQL keeps in
+// sync by itself.
+type mysqlProductSearchIndex struct{}
+
+func (s *mysqlProductSearchIndex) Setup(db *gorm.DB) error {
+	var count int64
+
+	err := db.Raw(
+		"SELECT COUNT(*) FROM information_schema.statistics WHERE table_schema = DATABASE() AND table_name = 'products' AND index_name = 'idx_products_name_fulltext'",
+	).Scan(&count).Error
+	if err != nil || count > 0 {
+		return err
+	}
+
+	return db.Exec("ALTER TABLE products ADD FULLTEXT INDEX idx_products_name_fulltext (name)").Error
+}
+
+func (s *mysqlProductSearchIndex) Index(db *gorm.DB, product *entity.Product) error {
+	return nil
+}
+
+func (s *mysqlProductSearchIndex) Remove(db *gorm.DB, id string) error {
+	return nil
+}
+
+func (s *mysqlProductSearchIndex) Search(db *gorm.DB, q string, offset, limit int) ([]entity.Product, error) {
+	if len(searchTerms(q)) == 0 {
+		return nil, ErrEmptySearchQuery
+	}
+
+	offset, limit = searchPage(offset, limit)
+
+	var products []entity.Product
+
+	err := db.Model(&entity.Product{}).
+		Where("MATCH (name) AGAINST (? IN NATURAL LANGUAGE MODE)", q).
+		Order(gorm.Expr("MATCH (name) AGAINST (? IN NATURAL LANGUAGE MODE) DESC", q)).
+		Offset(offset).
+		Limit(limit).
+		Find(&products).Error
+
+	if err != nil {
+		products = nil
+	}
+
+	return products, err
+}
+
+func (s *mysqlProductSearchIndex) Reindex(db *gorm.DB) error {
+	return db.Exec("OPTIMIZE TABLE products").Error
+}
+
+// postgresProductSearchIndex searches a generated tsvector column, which
+// Postgres recomputes on every write.
+type postgresProductSearchIndex struct{}
+
+func (s *postgresProductSearchIndex) Setup(db *gorm.DB) error {
+	err := db.Exec(
+		"ALTER TABLE products ADD COLUMN IF NOT EXISTS search_vector tsvector GENERATED ALWAYS AS (to_tsvector('simple', coalesce(name, ''))) STORED",
+	).Error
+	if err != nil {
+		return err
+	}
+
+	return db.Exec("CREATE INDEX IF NOT EXISTS idx_products_search_vector ON products USING GIN (search_vector)").Error
+}
+
+func (s *postgresProductSearchIndex) Index(db *gorm.DB, product *entity.Product) error {
+	return nil
+}
+
+func (s *postgresProductSearchIndex) Remove(db *gorm.DB, id string) error {
+	return nil
+}
+
+func (s *postgresProductSearchIndex) Search(db *gorm.DB, q string, offset, limit int) ([]entity.Product, error) {
+	if len(searchTerms(q)) == 0 {
+		return nil, ErrEmptySearchQuery
+	}
+
+	offset, limit = searchPage(offset, limit)
+
+	var products []entity.Product
+
+	err := db.Model(&entity.Product{}).
+		Where("search_vector @@ plainto_tsquery('simple', ?)", q).
+		Order(gorm.Expr("ts_rank(search_vector, plainto_tsquery('simple', ?)) DESC", q)).
+		Offset(offset).
+		Limit(limit).
+		Find(&products).Error
+
+	if err != nil {
+		products = nil
+	}
+
+	return products, err
+}
+
+func (s *postgresProductSearchIndex) Reindex(db *gorm.DB) error {
+	return db.Exec("REINDEX INDEX idx_products_search_vector").Error
+}
@@ -0,0 +1,74 @@
+//go:build sqlite_fts5
+
+package database
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/rgoncalvesrr/fullcycle-clean-arch/internal/entity"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestProductSearch(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+	db.AutoMigrate(&entity.Product{}, &entity.ProductHistory{})
+
+	searchIndex, err := NewProductSearchIndex(db)
+	assert.NoError(t, err)
+	assert.NoError(t, searchIndex.Setup(db))
+
+	productGateway := NewProductGateway(db)
+	productGateway.SearchIndex = searchIndex
+
+	for i, name := range []string{"Caneta Azul", "Caneta Azul Azul", "Caderno Azul", "Lapis"} {
+		product, e := entity.NewProduct(fmt.Sprintf("PRD-%d", i+1), name, entity.MustParseMoney("10", "BRL"))
+		assert.NoError(t, e)
+		assert.NoError(t, productGateway.Create(product))
+	}
+
+	products, err := productGateway.Search("azul", 0, 10)
+	assert.NoError(t, err)
+	assert.Len(t, products, 3)
+	assert.Equal(t, "Caneta Azul Azul", products[0].Name)
+
+	products, err = productGateway.Search("can azul", 0, 10)
+	assert.NoError(t, err)
+	assert.Len(t, products, 2)
+
+	product := products[0]
+	product.Name = "Marcador"
+	assert.NoError(t, productGateway.Update(&product))
+
+	products, err = productGateway.Search("marcador", 0, 10)
+	assert.NoError(t, err)
+	assert.Len(t, products, 1)
+
+	assert.NoError(t, productGateway.Delete(product.ID.String(), "usuario", 0))
+
+	products, err = productGateway.Search("marcador", 0, 10)
+	assert.NoError(t, err)
+	assert.Len(t, products, 0)
+
+	_, err = productGateway.Restore(product.ID.String())
+	assert.NoError(t, err)
+
+	products, err = productGateway.Search("marcador", 0, 10)
+	assert.NoError(t, err)
+	assert.Len(t, products, 1)
+
+	db.Exec("DELETE FROM products_fts")
+	assert.NoError(t, productGateway.Reindex())
+
+	products, err = productGateway.Search("lapis", 0, 10)
+	assert.NoError(t, err)
+	assert.Len(t, products, 1)
+
+	_, err = productGateway.Search("  ", 0, 10)
+	assert.ErrorIs(t, err, ErrEmptySearchQuery)
+}
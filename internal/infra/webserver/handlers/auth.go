@@ -0,0 +1,25 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/go-chi/jwtauth"
+)
+
+// subject returns the id of the user the request's JWT was issued to.
+func subject(r *http.Request) string {
+	_, claims, _ := jwtauth.FromContext(r.Context())
+
+	sub, _ := claims["sub"].(string)
+
+	return sub
+}
+
+// isAdmin tells whether the request's JWT carries the admin claim.
+func isAdmin(r *http.Request) bool {
+	_, claims, _ := jwtauth.FromContext(r.Context())
+
+	admin, _ := claims["admin"].(bool)
+
+	return admin
+}
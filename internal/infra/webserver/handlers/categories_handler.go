@@ -0,0 +1,366 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi"
+	"github.com/rgoncalvesrr/fullcycle-clean-arch/internal/dto"
+	"github.com/rgoncalvesrr/fullcycle-clean-arch/internal/entity"
+	"github.com/rgoncalvesrr/fullcycle-clean-arch/internal/event"
+	"github.com/rgoncalvesrr/fullcycle-clean-arch/internal/infra/database"
+	pkgEntity "github.com/rgoncalvesrr/fullcycle-clean-arch/pkg/entity"
+	"github.com/rgoncalvesrr/fullcycle-clean-arch/pkg/events"
+	"gorm.io/gorm"
+)
+
+type CategoryHandler struct {
+	CategoryGateway database.CategoryInterface
+	EventDispatcher *events.RecordingDispatcher
+}
+
+func NewCategoryHandler(db database.CategoryInterface, dispatcher *events.RecordingDispatcher) *CategoryHandler {
+	return &CategoryHandler{
+		CategoryGateway: db,
+		EventDispatcher: dispatcher,
+	}
+}
+
+// Create Category godoc
+//
+//	@Summay			Create Category
+//	@Description	Create Category
+//	@Tags			categories
+//	@Accept			json
+//	@Produce		json
+//
+//	@Param			request	body		dto.CreateCategoryInput	true	"category request"
+//	@Success		201		{object}	dto.CategoryOutput
+//	@Failure		400		{object}	dto.Error
+//	@Failure		500		{object}	dto.Error
+//	@Router			/categories [post]
+//
+//	@Security		ApiKeyAuth
+func (h *CategoryHandler) CreateCategory(w http.ResponseWriter, r *http.Request) {
+	var categoryDto dto.CreateCategoryInput
+	err := json.NewDecoder(r.Body).Decode(&categoryDto)
+
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(&dto.Error{Message: err.Error()})
+		return
+	}
+
+	parentID, err := parseOptionalID(categoryDto.ParentID)
+
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(&dto.Error{Message: err.Error()})
+		return
+	}
+
+	c, err := entity.NewCategory(categoryDto.Name, categoryDto.Description, parentID)
+
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(&dto.Error{Message: err.Error()})
+		return
+	}
+
+	var o *dto.CategoryOutput
+
+	err = h.recordChange(r, h.CategoryGateway, func(tx database.CategoryInterface) (events.IEvent, error) {
+		if err := tx.Create(c); err != nil {
+			return nil, err
+		}
+
+		o = newCategoryOutput(c)
+		return event.NewCategoryChanged(event.CategoryCreated, o.ID, o), nil
+	})
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(&dto.Error{Message: err.Error()})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(o)
+}
+
+// List Categories godoc
+//
+//	@Summay			List Categories
+//	@Description	List all categories as a tree
+//	@Tags			categories
+//	@Accept			json
+//	@Produce		json
+//
+//	@Success		200	{array}		dto.CategoryTreeOutput
+//	@Failure		500	{object}	dto.Error
+//	@Router			/categories [get]
+//
+//	@Security		ApiKeyAuth
+func (h *CategoryHandler) GetCategories(w http.ResponseWriter, r *http.Request) {
+	categories, err := h.CategoryGateway.FindAll()
+
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(&dto.Error{Message: err.Error()})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(buildCategoryTree(categories))
+}
+
+// Get Category godoc
+//
+//	@Summay			Get Category
+//	@Description	Get Category
+//	@Tags			categories
+//	@Accept			json
+//	@Produce		json
+//
+//	@Param			id	path		string	true	"Category ID"	Format(uuid)
+//	@Success		200	{object}	dto.CategoryOutput
+//	@Failure		404	{object}	dto.Error
+//	@Router			/categories/{id} [get]
+//
+//	@Security		ApiKeyAuth
+func (h *CategoryHandler) GetCategory(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	category, err := h.CategoryGateway.FindByID(id)
+
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(&dto.Error{Message: err.Error()})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(newCategoryOutput(category))
+}
+
+// List Category Descendants godoc
+//
+//	@Summay			List Category Descendants
+//	@Description	List every category below the given one, at any depth
+//	@Tags			categories
+//	@Accept			json
+//	@Produce		json
+//
+//	@Param			id	path		string	true	"Category ID"	Format(uuid)
+//	@Success		200	{array}		dto.CategoryOutput
+//	@Failure		404	{object}	dto.Error
+//	@Router			/categories/{id}/descendants [get]
+//
+//	@Security		ApiKeyAuth
+func (h *CategoryHandler) GetCategoryDescendants(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	categories, err := h.CategoryGateway.FindDescendants(id)
+
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(&dto.Error{Message: err.Error()})
+		return
+	}
+
+	o := make([]*dto.CategoryOutput, 0, len(categories))
+	for i := range categories {
+		o = append(o, newCategoryOutput(&categories[i]))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(&o)
+}
+
+// Update Category godoc
+//
+//	@Summay			Update Category
+//	@Description	Update Category
+//	@Tags			categories
+//	@Accept			json
+//	@Produce		json
+//
+//	@Param			id		path		string					true	"Category ID"	Format(uuid)
+//	@Param			request	body		dto.CreateCategoryInput	true	"Category"
+//	@Success		200		{object}	dto.CategoryOutput
+//	@Failure		400		{object}	dto.Error
+//	@Failure		404		{object}	dto.Error
+//	@Failure		409		{object}	dto.Error
+//	@Failure		500		{object}	dto.Error
+//	@Router			/categories/{id} [put]
+//
+//	@Security		ApiKeyAuth
+func (h *CategoryHandler) UpdateCategory(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	category, err := h.CategoryGateway.FindByID(id)
+
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(&dto.Error{Message: err.Error()})
+		return
+	}
+
+	var categoryDto dto.CreateCategoryInput
+	err = json.NewDecoder(r.Body).Decode(&categoryDto)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(&dto.Error{Message: err.Error()})
+		return
+	}
+
+	parentID, err := parseOptionalID(categoryDto.ParentID)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(&dto.Error{Message: err.Error()})
+		return
+	}
+
+	category.Name = categoryDto.Name
+	category.Description = categoryDto.Description
+	category.ParentID = parentID
+
+	if err = category.Validate(); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(&dto.Error{Message: err.Error()})
+		return
+	}
+
+	var o *dto.CategoryOutput
+
+	err = h.recordChange(r, h.CategoryGateway, func(tx database.CategoryInterface) (events.IEvent, error) {
+		if err := tx.Update(category); err != nil {
+			return nil, err
+		}
+
+		o = newCategoryOutput(category)
+		return event.NewCategoryChanged(event.CategoryUpdated, o.ID, o), nil
+	})
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, database.ErrCategoryCycle) {
+			status = http.StatusConflict
+		}
+
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(&dto.Error{Message: err.Error()})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(o)
+}
+
+// Delete Category godoc
+//
+//	@Summay			Delete Category
+//	@Description	Delete a category without subcategories; its products become uncategorized
+//	@Tags			categories
+//	@Accept			json
+//	@Produce		json
+//
+//	@Param			id	path	string	true	"Category ID"	Format(uuid)
+//	@Success		204
+//	@Failure		404	{object}	dto.Error
+//	@Failure		409	{object}	dto.Error
+//	@Failure		500	{object}	dto.Error
+//	@Router			/categories/{id} [delete]
+//
+//	@Security		ApiKeyAuth
+func (h *CategoryHandler) DeleteCategory(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	err := h.recordChange(r, h.CategoryGateway.WithActor(subject(r)), func(tx database.CategoryInterface) (events.IEvent, error) {
+		if err := tx.Delete(id); err != nil {
+			return nil, err
+		}
+
+		return event.NewCategoryChanged(event.CategoryDeleted, id, map[string]string{"id": id}), nil
+	})
+
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			status = http.StatusNotFound
+		} else if errors.Is(err, database.ErrCategoryHasChildren) || errors.Is(err, database.ErrVersionConflict) {
+			status = http.StatusConflict
+		}
+
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(&dto.Error{Message: err.Error()})
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func newCategoryOutput(c *entity.Category) *dto.CategoryOutput {
+	var parentID *string
+	if c.ParentID != nil {
+		id := c.ParentID.String()
+		parentID = &id
+	}
+
+	return &dto.CategoryOutput{
+		ID:          c.ID.String(),
+		ParentID:    parentID,
+		Name:        c.Name,
+		Description: c.Description,
+		CreatedAt:   c.CreatedAt,
+		UpdatedAt:   c.UpdatedAt,
+	}
+}
+
+// buildCategoryTree nests a flat category list under its roots, keeping the
+// input order among siblings.
+func buildCategoryTree(categories []entity.Category) []dto.CategoryTreeOutput {
+	children := make(map[string][]entity.Category)
+	var roots []entity.Category
+
+	for _, c := range categories {
+		if c.ParentID == nil {
+			roots = append(roots, c)
+			continue
+		}
+		children[c.ParentID.String()] = append(children[c.ParentID.String()], c)
+	}
+
+	var build func([]entity.Category) []dto.CategoryTreeOutput
+	build = func(nodes []entity.Category) []dto.CategoryTreeOutput {
+		tree := make([]dto.CategoryTreeOutput, 0, len(nodes))
+		for _, c := range nodes {
+			tree = append(tree, dto.CategoryTreeOutput{
+				ID:          c.ID.String(),
+				Name:        c.Name,
+				Description: c.Description,
+				Children:    build(children[c.ID.String()]),
+			})
+		}
+		return tree
+	}
+
+	return build(roots)
+}
+
+func parseOptionalID(s string) (*pkgEntity.ID, error) {
+	if s == "" {
+		return nil, nil
+	}
+
+	id, err := pkgEntity.ParseID(s)
+	if err != nil {
+		return nil, entity.ErrInvalidID
+	}
+
+	return &id, nil
+}
@@ -0,0 +1,202 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi"
+	"github.com/rgoncalvesrr/fullcycle-clean-arch/internal/dto"
+	"github.com/rgoncalvesrr/fullcycle-clean-arch/pkg/events"
+)
+
+type DeadLetterHandler struct {
+	DeadLetters *events.DeadLetterQueue
+}
+
+func NewDeadLetterHandler(queue *events.DeadLetterQueue) *DeadLetterHandler {
+	return &DeadLetterHandler{
+		DeadLetters: queue,
+	}
+}
+
+// List Dead Letters godoc
+//
+//	@Summay			List Dead Letters
+//	@Description	List the events handlers kept failing on after their retries, oldest first. Requires an admin token.
+//	@Tags			dead-letters
+//	@Accept			json
+//	@Produce		json
+//
+//	@Param			page	query		string	false	"page number"
+//	@Param			limit	query		string	false	"limit"
+//	@Success		200		{array}		dto.DeadLetterOutput
+//	@Failure		403		{object}	dto.Error
+//	@Failure		500		{object}	dto.Error
+//	@Router			/dead-letters [get]
+//
+//	@Security		ApiKeyAuth
+func (h *DeadLetterHandler) ListDeadLetters(w http.ResponseWriter, r *http.Request) {
+	if !isAdmin(r) {
+		w.WriteHeader(http.StatusForbidden)
+		json.NewEncoder(w).Encode(&dto.Error{Message: ErrAdminRequired.Error()})
+		return
+	}
+
+	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+
+	if page < 1 {
+		page = 1
+	}
+
+	if limit < 1 {
+		limit = 50
+	}
+
+	letters, err := h.DeadLetters.List(r.Context(), (page-1)*limit, limit)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(&dto.Error{Message: err.Error()})
+		return
+	}
+
+	output := make([]dto.DeadLetterOutput, 0, len(letters))
+	for i := range letters {
+		output = append(output, newDeadLetterOutput(&letters[i]))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(&output)
+}
+
+// Get Dead Letter godoc
+//
+//	@Summay			Get Dead Letter
+//	@Description	Get a dead letter. Requires an admin token.
+//	@Tags			dead-letters
+//	@Accept			json
+//	@Produce		json
+//
+//	@Param			id	path		string	true	"Dead letter ID"	Format(uuid)
+//	@Success		200	{object}	dto.DeadLetterOutput
+//	@Failure		403	{object}	dto.Error
+//	@Failure		404	{object}	dto.Error
+//	@Failure		500	{object}	dto.Error
+//	@Router			/dead-letters/{id} [get]
+//
+//	@Security		ApiKeyAuth
+func (h *DeadLetterHandler) GetDeadLetter(w http.ResponseWriter, r *http.Request) {
+	if !isAdmin(r) {
+		w.WriteHeader(http.StatusForbidden)
+		json.NewEncoder(w).Encode(&dto.Error{Message: ErrAdminRequired.Error()})
+		return
+	}
+
+	letter, err := h.DeadLetters.Get(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		w.WriteHeader(deadLetterErrorStatus(err, http.StatusInternalServerError))
+		json.NewEncoder(w).Encode(&dto.Error{Message: err.Error()})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(newDeadLetterOutput(letter))
+}
+
+// Replay Dead Letter godoc
+//
+//	@Summay			Replay Dead Letter
+//	@Description	Hand a dead letter back to its handler, with the handler's retry policy. It is removed if the handler succeeds and kept with the new error otherwise. Requires an admin token.
+//	@Tags			dead-letters
+//	@Accept			json
+//	@Produce		json
+//
+//	@Param			id	path	string	true	"Dead letter ID"	Format(uuid)
+//	@Success		204
+//	@Failure		403	{object}	dto.Error
+//	@Failure		404	{object}	dto.Error
+//	@Failure		422	{object}	dto.Error	"handler not registered"
+//	@Failure		502	{object}	dto.Error	"handler failed again"
+//	@Router			/dead-letters/{id}/replay [post]
+//
+//	@Security		ApiKeyAuth
+func (h *DeadLetterHandler) ReplayDeadLetter(w http.ResponseWriter, r *http.Request) {
+	if !isAdmin(r) {
+		w.WriteHeader(http.StatusForbidden)
+		json.NewEncoder(w).Encode(&dto.Error{Message: ErrAdminRequired.Error()})
+		return
+	}
+
+	err := h.DeadLetters.Replay(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		// Anything else means the handler failed again.
+		w.WriteHeader(deadLetterErrorStatus(err, http.StatusBadGateway))
+		json.NewEncoder(w).Encode(&dto.Error{Message: err.Error()})
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Discard Dead Letter godoc
+//
+//	@Summay			Discard Dead Letter
+//	@Description	Drop a dead letter without handling it. Requires an admin token.
+//	@Tags			dead-letters
+//	@Accept			json
+//	@Produce		json
+//
+//	@Param			id	path	string	true	"Dead letter ID"	Format(uuid)
+//	@Success		204
+//	@Failure		403	{object}	dto.Error
+//	@Failure		404	{object}	dto.Error
+//	@Failure		500	{object}	dto.Error
+//	@Router			/dead-letters/{id} [delete]
+//
+//	@Security		ApiKeyAuth
+func (h *DeadLetterHandler) DiscardDeadLetter(w http.ResponseWriter, r *http.Request) {
+	if !isAdmin(r) {
+		w.WriteHeader(http.StatusForbidden)
+		json.NewEncoder(w).Encode(&dto.Error{Message: ErrAdminRequired.Error()})
+		return
+	}
+
+	err := h.DeadLetters.Discard(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		w.WriteHeader(deadLetterErrorStatus(err, http.StatusInternalServerError))
+		json.NewEncoder(w).Encode(&dto.Error{Message: err.Error()})
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func deadLetterErrorStatus(err error, fallback int) int {
+	switch {
+	case errors.Is(err, events.ErrDeadLetterNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, events.ErrDeadLetterHandlerUnknown):
+		return http.StatusUnprocessableEntity
+	}
+
+	return fallback
+}
+
+func newDeadLetterOutput(letter *events.DeadLetter) dto.DeadLetterOutput {
+	payload, _ := json.Marshal(letter.Event.GetPayLoad())
+
+	return dto.DeadLetterOutput{
+		ID:        letter.ID,
+		Handler:   letter.Handler,
+		Event:     letter.Event.GetName(),
+		Payload:   payload,
+		EventTime: letter.Event.GetDateTime(),
+		Error:     letter.Error,
+		Attempts:  letter.Attempts,
+		FailedAt:  letter.FailedAt,
+	}
+}
@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/rgoncalvesrr/fullcycle-clean-arch/internal/dto"
+)
+
+var (
+	ErrPreconditionRequired = errors.New("If-Match header is required")
+	ErrPreconditionFailed   = errors.New("resource has changed, fetch it again")
+)
+
+// versionETag renders a resource version as a strong entity tag.
+func versionETag(version int) string {
+	return `"` + strconv.Itoa(version) + `"`
+}
+
+// matchesETag reports whether an If-None-Match header value, a comma
+// separated list of entity tags or "*", contains etag by the weak
+// comparison: weak tags compare by their opaque value.
+func matchesETag(header string, etag string) bool {
+	return containsETag(header, etag, func(tag string) string {
+		return strings.TrimPrefix(tag, "W/")
+	})
+}
+
+// matchesETagStrong reports whether an If-Match header value contains etag
+// by the strong comparison: a weak tag never matches.
+func matchesETagStrong(header string, etag string) bool {
+	return containsETag(header, etag, func(tag string) string { return tag })
+}
+
+func containsETag(header string, etag string, normalize func(tag string) string) bool {
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+
+		if tag == "*" || normalize(tag) == etag {
+			return true
+		}
+	}
+
+	return false
+}
+
+// checkIfMatch enforces the If-Match precondition of a write against the
+// current version of the resource, answering 428 when the header is missing
+// and 412 when it doesn't match.
+func checkIfMatch(w http.ResponseWriter, r *http.Request, version int) bool {
+	header := r.Header.Get("If-Match")
+
+	if header == "" {
+		w.WriteHeader(http.StatusPreconditionRequired)
+		json.NewEncoder(w).Encode(&dto.Error{Message: ErrPreconditionRequired.Error()})
+		return false
+	}
+
+	if !matchesETagStrong(header, versionETag(version)) {
+		w.WriteHeader(http.StatusPreconditionFailed)
+		json.NewEncoder(w).Encode(&dto.Error{Message: ErrPreconditionFailed.Error()})
+		return false
+	}
+
+	return true
+}
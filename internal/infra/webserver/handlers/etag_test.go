@@ -0,0 +1,42 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatchesETag(t *testing.T) {
+	assert.True(t, matchesETag(`W/"3"`, `"3"`))
+	assert.True(t, matchesETag(`"2", "3"`, `"3"`))
+	assert.True(t, matchesETag(`*`, `"3"`))
+	assert.False(t, matchesETag(`"2"`, `"3"`))
+
+	assert.True(t, matchesETagStrong(`"2", "3"`, `"3"`))
+	assert.True(t, matchesETagStrong(`*`, `"3"`))
+	assert.False(t, matchesETagStrong(`W/"3"`, `"3"`))
+}
+
+func TestCheckIfMatch(t *testing.T) {
+	for header, status := range map[string]int{
+		"":      http.StatusPreconditionRequired,
+		`"3"`:   http.StatusOK,
+		`*`:     http.StatusOK,
+		`"2"`:   http.StatusPreconditionFailed,
+		`W/"3"`: http.StatusPreconditionFailed,
+	} {
+		r := httptest.NewRequest(http.MethodPut, "/products/1", nil)
+		if header != "" {
+			r.Header.Set("If-Match", header)
+		}
+
+		w := httptest.NewRecorder()
+		if checkIfMatch(w, r, 3) {
+			w.WriteHeader(http.StatusOK)
+		}
+
+		assert.Equal(t, status, w.Code, header)
+	}
+}
@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"net/http"
+
+	"github.com/rgoncalvesrr/fullcycle-clean-arch/internal/infra/database"
+	"github.com/rgoncalvesrr/fullcycle-clean-arch/pkg/events"
+)
+
+// eventContext is the context events of r are stored and handled with. It
+// drops the request's cancellation: chi recycles the request context once
+// the handler returns, while the database driver and async handlers may still
+// be watching it.
+func eventContext(r *http.Request) context.Context {
+	return context.WithoutCancel(r.Context())
+}
+
+// publish dispatches the events of committed changes. The events were
+// recorded in the transaction of their change, so a failing handler is
+// logged rather than failing the request.
+func publish(ctx context.Context, dispatcher *events.RecordingDispatcher, changes ...events.IEvent) {
+	for _, e := range changes {
+		if err := dispatcher.DispatchRecorded(ctx, e); err != nil {
+			log.Printf("dispatching %s failed: %v", e.GetName(), err)
+		}
+	}
+}
+
+// recordChange runs change in a transaction of gateway, storing the event it
+// returns in the same transaction, and publishes the event once committed.
+func (h *ProductHandler) recordChange(r *http.Request, gateway database.ProductInterface, change func(tx database.ProductInterface) (events.IEvent, error)) error {
+	ctx := eventContext(r)
+
+	var e events.IEvent
+
+	err := gateway.Transaction(func(tx database.ProductInterface) error {
+		var err error
+		if e, err = change(tx); err != nil {
+			return err
+		}
+
+		return tx.RecordEvent(ctx, e)
+	})
+	if err != nil {
+		return err
+	}
+
+	publish(ctx, h.EventDispatcher, e)
+
+	return nil
+}
+
+// recordChange runs change in a transaction of gateway, storing the event it
+// returns in the same transaction, and publishes the event once committed.
+func (h *CategoryHandler) recordChange(r *http.Request, gateway database.CategoryInterface, change func(tx database.CategoryInterface) (events.IEvent, error)) error {
+	ctx := eventContext(r)
+
+	var e events.IEvent
+
+	err := gateway.Transaction(func(tx database.CategoryInterface) error {
+		var err error
+		if e, err = change(tx); err != nil {
+			return err
+		}
+
+		return tx.RecordEvent(ctx, e)
+	})
+	if err != nil {
+		return err
+	}
+
+	publish(ctx, h.EventDispatcher, e)
+
+	return nil
+}
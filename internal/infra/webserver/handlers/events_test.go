@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi"
+	"github.com/rgoncalvesrr/fullcycle-clean-arch/internal/dto"
+	"github.com/rgoncalvesrr/fullcycle-clean-arch/internal/infra/database"
+	"github.com/rgoncalvesrr/fullcycle-clean-arch/pkg/events"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestHandlersRecordEvents(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+	assert.NoError(t, database.Migrate(db))
+
+	store := database.NewEventStoreGateway(db)
+	dispatcher := events.NewRecordingDispatcher(events.NewEventDispatcher(), store)
+
+	categoryGateway := database.NewCategoryGateway(db)
+	categoryHandler := NewCategoryHandler(categoryGateway, dispatcher)
+	productHandler := NewProductHandler(database.NewProductGateway(db), categoryGateway, dispatcher)
+
+	r := chi.NewRouter()
+	r.Post("/categories", categoryHandler.CreateCategory)
+	r.Post("/products", productHandler.CreateProduct)
+	r.Post("/products:batch", productHandler.BatchProducts)
+	r.Delete("/products/{id}", productHandler.DeleteProduct)
+
+	send := func(method, target, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		req.Header.Set("If-Match", "*")
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	w := send(http.MethodPost, "/categories", `{"name":"Papelaria"}`)
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	var category dto.CategoryOutput
+	assert.NoError(t, json.NewDecoder(w.Body).Decode(&category))
+
+	w = send(http.MethodPost, "/products", `{"sku":"CAN-1","name":"Caneta","price":{"amount":"2.50","currency":"BRL"},"category_id":"`+category.ID+`"}`)
+	assert.Equal(t, http.StatusCreated, w.Code)
+
+	var product dto.CreateProductOutput
+	assert.NoError(t, json.NewDecoder(w.Body).Decode(&product))
+
+	w = send(http.MethodDelete, "/products/"+product.ID, "")
+	assert.Equal(t, http.StatusNoContent, w.Code)
+
+	// A rolled back batch publishes nothing, not even its successful
+	// operations.
+	w = send(http.MethodPost, "/products:batch", `{"operations":[`+
+		`{"op":"create","product":{"sku":"LAP-1","name":"Lápis","price":{"amount":"1.00","currency":"BRL"}}},`+
+		`{"op":"update","id":"`+product.ID+`","product":{"name":"Lápis"}}]}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	assert.Contains(t, w.Body.String(), `"status":201`)
+
+	stored, err := store.Load(context.Background(), events.EventQuery{})
+	assert.NoError(t, err)
+
+	var names, keys []string
+	for _, e := range stored {
+		names = append(names, e.Event.GetName())
+		keys = append(keys, e.Event.(events.IKeyedEvent).GetKey())
+	}
+
+	assert.Equal(t, []string{"category.created", "product.created", "product.deleted"}, names)
+	assert.Equal(t, []string{category.ID, product.ID, product.ID}, keys)
+}
+
+func TestHandlersRollBackChangesWhoseEventIsNotStored(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+	assert.NoError(t, database.Migrate(db))
+	assert.NoError(t, db.Migrator().DropTable("stored_events"))
+
+	dispatcher := events.NewRecordingDispatcher(events.NewEventDispatcher(), database.NewEventStoreGateway(db))
+
+	categoryGateway := database.NewCategoryGateway(db)
+	categoryHandler := NewCategoryHandler(categoryGateway, dispatcher)
+
+	r := chi.NewRouter()
+	r.Post("/categories", categoryHandler.CreateCategory)
+
+	req := httptest.NewRequest(http.MethodPost, "/categories", strings.NewReader(`{"name":"Papelaria"}`))
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+
+	categories, err := categoryGateway.FindAll()
+	assert.NoError(t, err)
+	assert.Empty(t, categories)
+}
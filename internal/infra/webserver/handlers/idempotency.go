@@ -0,0 +1,264 @@
+package handlers
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/rgoncalvesrr/fullcycle-clean-arch/internal/dto"
+	"github.com/rgoncalvesrr/fullcycle-clean-arch/internal/entity"
+	"github.com/rgoncalvesrr/fullcycle-clean-arch/internal/infra/database"
+)
+
+var (
+	ErrIdempotencyKeyReused     = errors.New("Idempotency-Key was already used with a different request")
+	ErrIdempotencyKeyInProgress = errors.New("a request with this Idempotency-Key is still in progress")
+	ErrIdempotentBodyTooLarge   = errors.New("request body is too large to be sent with an Idempotency-Key")
+)
+
+const (
+	// idempotencyWait is how long a duplicate request waits for the first
+	// one to finish before giving up with 409.
+	idempotencyWait = 5 * time.Second
+	idempotencyPoll = 100 * time.Millisecond
+
+	defaultIdempotencyKeyTTL = 24 * time.Hour
+	defaultIdempotentMaxBody = 1 << 20
+)
+
+type IdempotencyHandler struct {
+	IdempotencyKeyGateway database.IdempotencyKeyInterface
+	TTL                   time.Duration
+	// MaxBodySize caps the body of requests sent with a key, which is held
+	// in memory to fingerprint the request.
+	MaxBodySize int64
+	// Lease is how long a reservation holds its key without being renewed.
+	// The request running with it renews it until it completes.
+	Lease time.Duration
+}
+
+func NewIdempotencyHandler(db database.IdempotencyKeyInterface, ttl time.Duration) *IdempotencyHandler {
+	if ttl <= 0 {
+		ttl = defaultIdempotencyKeyTTL
+	}
+
+	return &IdempotencyHandler{
+		IdempotencyKeyGateway: db,
+		TTL:                   ttl,
+		MaxBodySize:           defaultIdempotentMaxBody,
+		Lease:                 entity.IdempotencyKeyLease,
+	}
+}
+
+// Middleware honors the Idempotency-Key header on mutating requests. The
+// first request with a key runs normally and its response is stored; retries
+// with the same key and request replay it, retries with a different request
+// get 422 and retries while the first one is still running wait for it or
+// get 409. Server errors aren't stored, so the request can be retried, and a
+// key left pending by a request that died is taken over by a retry once its
+// lease runs out.
+// Bodies over MaxBodySize get 413 when sent with a key; large imports should
+// be sent without one. It must run after the JWT authenticator, as keys are
+// scoped by subject.
+func (h *IdempotencyHandler) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header := r.Header.Get("Idempotency-Key")
+
+		if header == "" || !isMutating(r.Method) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		body, err := io.ReadAll(io.LimitReader(r.Body, h.MaxBodySize+1))
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(&dto.Error{Message: err.Error()})
+			return
+		}
+
+		if int64(len(body)) > h.MaxBodySize {
+			w.WriteHeader(http.StatusRequestEntityTooLarge)
+			json.NewEncoder(w).Encode(&dto.Error{Message: ErrIdempotentBodyTooLarge.Error()})
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		key, err := entity.NewIdempotencyKey(subject(r), header, requestFingerprint(r, body), h.TTL)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(&dto.Error{Message: err.Error()})
+			return
+		}
+		key.LockedUntil = key.CreatedAt.Add(h.Lease)
+
+		deadline := time.Now().Add(idempotencyWait)
+
+		for {
+			existing, err := h.IdempotencyKeyGateway.Reserve(key)
+
+			switch {
+			case err != nil:
+				w.WriteHeader(http.StatusInternalServerError)
+				json.NewEncoder(w).Encode(&dto.Error{Message: err.Error()})
+				return
+			case existing == nil:
+				h.serve(w, r, next, key)
+				return
+			case existing.Fingerprint != key.Fingerprint:
+				w.WriteHeader(http.StatusUnprocessableEntity)
+				json.NewEncoder(w).Encode(&dto.Error{Message: ErrIdempotencyKeyReused.Error()})
+				return
+			case existing.IsCompleted():
+				replay(w, existing)
+				return
+			case time.Now().After(deadline):
+				w.WriteHeader(http.StatusConflict)
+				json.NewEncoder(w).Encode(&dto.Error{Message: ErrIdempotencyKeyInProgress.Error()})
+				return
+			}
+
+			select {
+			case <-r.Context().Done():
+				return
+			case <-time.After(idempotencyPoll):
+			}
+		}
+	})
+}
+
+// serve runs the request that reserved key and stores its response.
+func (h *IdempotencyHandler) serve(w http.ResponseWriter, r *http.Request, next http.Handler, key *entity.IdempotencyKey) {
+	recorder := &responseRecorder{ResponseWriter: w}
+
+	completed := false
+	defer func() {
+		if !completed {
+			h.release(key)
+		}
+	}()
+
+	stopRenewing := h.renew(key)
+	next.ServeHTTP(recorder, r)
+	stopRenewing()
+
+	if recorder.status == 0 {
+		recorder.status = http.StatusOK
+		recorder.header = w.Header().Clone()
+	}
+
+	if recorder.status >= http.StatusInternalServerError {
+		return
+	}
+
+	key.Status = recorder.status
+	key.Header, _ = json.Marshal(recorder.header)
+	key.Body = recorder.body.Bytes()
+
+	if err := h.IdempotencyKeyGateway.Complete(key); err != nil {
+		log.Printf("storing response of idempotency key %q failed: %v", key.Key, err)
+		return
+	}
+
+	completed = true
+}
+
+// renew keeps extending the lease of key until the returned function is
+// called, so that a request running longer than the lease keeps its key.
+func (h *IdempotencyHandler) renew(key *entity.IdempotencyKey) func() {
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+
+	go func() {
+		defer close(stopped)
+
+		ticker := time.NewTicker(h.Lease / 3)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				if err := h.IdempotencyKeyGateway.Renew(key, time.Now().Add(h.Lease)); err != nil {
+					log.Printf("renewing idempotency key %q failed: %v", key.Key, err)
+				}
+			}
+		}
+	}()
+
+	return func() {
+		close(done)
+		<-stopped
+	}
+}
+
+func (h *IdempotencyHandler) release(key *entity.IdempotencyKey) {
+	if err := h.IdempotencyKeyGateway.Release(key.Scope, key.Key); err != nil {
+		log.Printf("releasing idempotency key %q failed: %v", key.Key, err)
+	}
+}
+
+func replay(w http.ResponseWriter, key *entity.IdempotencyKey) {
+	var header http.Header
+	json.Unmarshal(key.Header, &header)
+
+	for name, values := range header {
+		w.Header()[name] = values
+	}
+
+	w.Header().Set("Idempotent-Replayed", "true")
+	w.WriteHeader(key.Status)
+	w.Write(key.Body)
+}
+
+func isMutating(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	}
+
+	return false
+}
+
+// requestFingerprint identifies a request by its method, target and body.
+func requestFingerprint(r *http.Request, body []byte) string {
+	hash := sha256.New()
+	hash.Write([]byte(r.Method + " " + r.URL.RequestURI() + "\n"))
+	hash.Write(body)
+
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// responseRecorder passes a response through while keeping a copy of its
+// status, headers and body.
+type responseRecorder struct {
+	http.ResponseWriter
+	status int
+	header http.Header
+	body   bytes.Buffer
+}
+
+func (rr *responseRecorder) WriteHeader(status int) {
+	if rr.status == 0 {
+		rr.status = status
+		rr.header = rr.ResponseWriter.Header().Clone()
+	}
+
+	rr.ResponseWriter.WriteHeader(status)
+}
+
+func (rr *responseRecorder) Write(b []byte) (int, error) {
+	if rr.status == 0 {
+		rr.WriteHeader(http.StatusOK)
+	}
+
+	rr.body.Write(b)
+
+	return rr.ResponseWriter.Write(b)
+}
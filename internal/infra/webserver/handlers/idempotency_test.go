@@ -0,0 +1,121 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/jwtauth"
+	"github.com/rgoncalvesrr/fullcycle-clean-arch/internal/entity"
+	"github.com/rgoncalvesrr/fullcycle-clean-arch/internal/infra/database"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// newIdempotentServer serves a handler that counts its calls behind the JWT
+// verifier and the idempotency middleware.
+func newIdempotentServer(t *testing.T, ttl time.Duration) (*IdempotencyHandler, http.Handler, *int, string) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+	db.AutoMigrate(&entity.IdempotencyKey{})
+
+	tokenAuth := jwtauth.New("HS256", []byte("secret"), nil)
+	_, token, _ := tokenAuth.Encode(map[string]interface{}{"sub": "user-1"})
+
+	calls := 0
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"call":` + strconv.Itoa(calls) + `}`))
+	})
+
+	handler := NewIdempotencyHandler(database.NewIdempotencyKeyGateway(db), ttl)
+
+	return handler, jwtauth.Verifier(tokenAuth)(handler.Middleware(next)), &calls, token
+}
+
+func idempotentRequest(server http.Handler, token, key, body string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodPost, "/products", strings.NewReader(body))
+	r.Header.Set("Authorization", "Bearer "+token)
+	r.Header.Set("Idempotency-Key", key)
+
+	w := httptest.NewRecorder()
+	server.ServeHTTP(w, r)
+
+	return w
+}
+
+func TestIdempotencyReplaysResponse(t *testing.T) {
+	_, server, calls, token := newIdempotentServer(t, time.Hour)
+
+	first := idempotentRequest(server, token, "key-1", `{"name":"Caneta"}`)
+	assert.Equal(t, http.StatusCreated, first.Code)
+	assert.Empty(t, first.Header().Get("Idempotent-Replayed"))
+
+	second := idempotentRequest(server, token, "key-1", `{"name":"Caneta"}`)
+	assert.Equal(t, http.StatusCreated, second.Code)
+	assert.Equal(t, "true", second.Header().Get("Idempotent-Replayed"))
+	assert.Equal(t, "application/json", second.Header().Get("Content-Type"))
+	assert.Equal(t, first.Body.String(), second.Body.String())
+	assert.Equal(t, 1, *calls)
+
+	// Another key is another request.
+	assert.Equal(t, http.StatusCreated, idempotentRequest(server, token, "key-2", `{"name":"Caneta"}`).Code)
+	assert.Equal(t, 2, *calls)
+}
+
+func TestIdempotencyKeyReusedWithAnotherBody(t *testing.T) {
+	_, server, calls, token := newIdempotentServer(t, time.Hour)
+
+	idempotentRequest(server, token, "key-1", `{"name":"Caneta"}`)
+
+	w := idempotentRequest(server, token, "key-1", `{"name":"Lápis"}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	assert.Contains(t, w.Body.String(), ErrIdempotencyKeyReused.Error())
+	assert.Equal(t, 1, *calls)
+}
+
+func TestIdempotencyKeyExpires(t *testing.T) {
+	_, server, calls, token := newIdempotentServer(t, 50*time.Millisecond)
+
+	idempotentRequest(server, token, "key-1", `{"name":"Caneta"}`)
+	time.Sleep(60 * time.Millisecond)
+
+	w := idempotentRequest(server, token, "key-1", `{"name":"Lápis"}`)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Empty(t, w.Header().Get("Idempotent-Replayed"))
+	assert.Equal(t, 2, *calls)
+}
+
+func TestIdempotencyTakesOverAbandonedKey(t *testing.T) {
+	handler, server, calls, token := newIdempotentServer(t, time.Hour)
+
+	// A request that crashed left its key pending, with its lease over.
+	abandoned, _ := entity.NewIdempotencyKey("user-1", "key-1", "abc", time.Hour)
+	abandoned.LockedUntil = time.Now().Add(-time.Second)
+	_, err := handler.IdempotencyKeyGateway.Reserve(abandoned)
+	assert.NoError(t, err)
+
+	w := idempotentRequest(server, token, "key-1", `{"name":"Caneta"}`)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Empty(t, w.Header().Get("Idempotent-Replayed"))
+	assert.Equal(t, 1, *calls)
+}
+
+func TestIdempotencyBodyTooLarge(t *testing.T) {
+	handler, server, calls, token := newIdempotentServer(t, time.Hour)
+	handler.MaxBodySize = 8
+
+	w := idempotentRequest(server, token, "key-1", `{"name":"Caneta"}`)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+	assert.Equal(t, 0, *calls)
+
+	assert.Equal(t, http.StatusCreated, idempotentRequest(server, token, "key-2", `{}`).Code)
+}
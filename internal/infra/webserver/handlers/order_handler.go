@@ -0,0 +1,270 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi"
+	"github.com/rgoncalvesrr/fullcycle-clean-arch/internal/dto"
+	"github.com/rgoncalvesrr/fullcycle-clean-arch/internal/entity"
+	"github.com/rgoncalvesrr/fullcycle-clean-arch/internal/infra/database"
+	"github.com/rgoncalvesrr/fullcycle-clean-arch/internal/usecase"
+	"github.com/rgoncalvesrr/fullcycle-clean-arch/pkg/events"
+	"gorm.io/gorm"
+)
+
+type OrderHandler struct {
+	ProductGateway  database.ProductInterface
+	OrderGateway    database.OrderInterface
+	TaxPolicy       entity.TaxPolicy
+	EventDispatcher events.IEventDispacher
+}
+
+func NewOrderHandler(productDB database.ProductInterface, orderDB database.OrderInterface, taxPolicy entity.TaxPolicy, dispatcher events.IEventDispacher) *OrderHandler {
+	return &OrderHandler{
+		ProductGateway:  productDB,
+		OrderGateway:    orderDB,
+		TaxPolicy:       taxPolicy,
+		EventDispatcher: dispatcher,
+	}
+}
+
+// Create Order godoc
+//
+//	@Summay			Create Order
+//	@Description	Place an order for catalogue products at their current prices plus the taxes due in the order region, taking the quantities out of stock
+//	@Tags			orders
+//	@Accept			json
+//	@Produce		json
+//
+//	@Param			request	body		dto.CreateOrderInput	true	"order request"
+//	@Success		201		{object}	dto.OrderOutput
+//	@Failure		400		{object}	dto.Error
+//	@Failure		409		{object}	dto.Error	"insufficient stock"
+//	@Failure		422		{object}	dto.Error	"product missing or unavailable"
+//	@Failure		500		{object}	dto.Error
+//	@Router			/order [post]
+//
+//	@Security		ApiKeyAuth
+func (h *OrderHandler) CreateOrder(w http.ResponseWriter, r *http.Request) {
+	var orderDto dto.CreateOrderInput
+	err := json.NewDecoder(r.Body).Decode(&orderDto)
+
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(&dto.Error{Message: err.Error()})
+		return
+	}
+
+	uc := usecase.NewCreateOrderUseCase(h.ProductGateway, h.OrderGateway.WithActor(subject(r)), h.TaxPolicy)
+
+	o, err := uc.Execute(orderDto)
+	if err != nil {
+		w.WriteHeader(orderErrorStatus(err))
+		json.NewEncoder(w).Encode(&dto.Error{Message: err.Error()})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(o)
+}
+
+// List Orders godoc
+//
+//	@Summay			List Orders
+//	@Description	List Orders
+//	@Tags			orders
+//	@Accept			json
+//	@Produce		json
+//
+//	@Param			page	query		string	false	"page number"
+//	@Param			limit	query		string	false	"limit"
+//	@Success		200		{array}		dto.OrderOutput
+//	@Failure		500		{object}	dto.Error
+//	@Router			/order [get]
+//
+//	@Security		ApiKeyAuth
+func (h *OrderHandler) ListOrders(w http.ResponseWriter, r *http.Request) {
+	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+
+	orders, err := usecase.NewListOrdersUseCase(h.OrderGateway).Execute(dto.ListOrdersInput{Page: page, Limit: limit})
+
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(&dto.Error{Message: err.Error()})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(&orders)
+}
+
+// Get Order godoc
+//
+//	@Summay			Get Order
+//	@Description	Get Order
+//	@Tags			orders
+//	@Accept			json
+//	@Produce		json
+//
+//	@Param			id	path		string	true	"Order ID"	Format(uuid)
+//	@Success		200	{object}	dto.OrderOutput
+//	@Failure		404	{object}	dto.Error
+//	@Router			/order/{id} [get]
+//
+//	@Security		ApiKeyAuth
+func (h *OrderHandler) GetOrder(w http.ResponseWriter, r *http.Request) {
+	o, err := usecase.NewGetOrderUseCase(h.OrderGateway).Execute(chi.URLParam(r, "id"))
+
+	if err != nil {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(&dto.Error{Message: err.Error()})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(o)
+}
+
+// Pay Order godoc
+//
+//	@Summay			Pay Order
+//	@Description	Pay a pending order
+//	@Tags			orders
+//	@Produce		json
+//
+//	@Param			id	path		string	true	"Order ID"	Format(uuid)
+//	@Success		200	{object}	dto.OrderOutput
+//	@Failure		404	{object}	dto.Error
+//	@Failure		409	{object}	dto.Error	"transition not allowed from the current status"
+//	@Failure		500	{object}	dto.Error
+//	@Router			/order/{id}/pay [post]
+//
+//	@Security		ApiKeyAuth
+func (h *OrderHandler) PayOrder(w http.ResponseWriter, r *http.Request) {
+	h.transitionOrder(w, r, entity.OrderPaid)
+}
+
+// Ship Order godoc
+//
+//	@Summay			Ship Order
+//	@Description	Ship a paid order
+//	@Tags			orders
+//	@Produce		json
+//
+//	@Param			id	path		string	true	"Order ID"	Format(uuid)
+//	@Success		200	{object}	dto.OrderOutput
+//	@Failure		404	{object}	dto.Error
+//	@Failure		409	{object}	dto.Error	"transition not allowed from the current status"
+//	@Failure		500	{object}	dto.Error
+//	@Router			/order/{id}/ship [post]
+//
+//	@Security		ApiKeyAuth
+func (h *OrderHandler) ShipOrder(w http.ResponseWriter, r *http.Request) {
+	h.transitionOrder(w, r, entity.OrderShipped)
+}
+
+// Deliver Order godoc
+//
+//	@Summay			Deliver Order
+//	@Description	Mark a shipped order as delivered
+//	@Tags			orders
+//	@Produce		json
+//
+//	@Param			id	path		string	true	"Order ID"	Format(uuid)
+//	@Success		200	{object}	dto.OrderOutput
+//	@Failure		404	{object}	dto.Error
+//	@Failure		409	{object}	dto.Error	"transition not allowed from the current status"
+//	@Failure		500	{object}	dto.Error
+//	@Router			/order/{id}/deliver [post]
+//
+//	@Security		ApiKeyAuth
+func (h *OrderHandler) DeliverOrder(w http.ResponseWriter, r *http.Request) {
+	h.transitionOrder(w, r, entity.OrderDelivered)
+}
+
+// Cancel Order godoc
+//
+//	@Summay			Cancel Order
+//	@Description	Cancel a pending order, putting its items back in stock
+//	@Tags			orders
+//	@Produce		json
+//
+//	@Param			id	path		string	true	"Order ID"	Format(uuid)
+//	@Success		200	{object}	dto.OrderOutput
+//	@Failure		404	{object}	dto.Error
+//	@Failure		409	{object}	dto.Error	"transition not allowed from the current status"
+//	@Failure		500	{object}	dto.Error
+//	@Router			/order/{id}/cancel [post]
+//
+//	@Security		ApiKeyAuth
+func (h *OrderHandler) CancelOrder(w http.ResponseWriter, r *http.Request) {
+	h.transitionOrder(w, r, entity.OrderCancelled)
+}
+
+// Refund Order godoc
+//
+//	@Summay			Refund Order
+//	@Description	Refund a paid or delivered order
+//	@Tags			orders
+//	@Produce		json
+//
+//	@Param			id	path		string	true	"Order ID"	Format(uuid)
+//	@Success		200	{object}	dto.OrderOutput
+//	@Failure		404	{object}	dto.Error
+//	@Failure		409	{object}	dto.Error	"transition not allowed from the current status"
+//	@Failure		500	{object}	dto.Error
+//	@Router			/order/{id}/refund [post]
+//
+//	@Security		ApiKeyAuth
+func (h *OrderHandler) RefundOrder(w http.ResponseWriter, r *http.Request) {
+	h.transitionOrder(w, r, entity.OrderRefunded)
+}
+
+func (h *OrderHandler) transitionOrder(w http.ResponseWriter, r *http.Request, status entity.OrderStatus) {
+	uc := usecase.NewTransitionOrderUseCase(h.OrderGateway.WithActor(subject(r)), h.EventDispatcher)
+
+	// The events outlive the request, like those passed to publish.
+	o, err := uc.Execute(context.WithoutCancel(r.Context()), dto.TransitionOrderInput{
+		OrderID: chi.URLParam(r, "id"),
+		Status:  string(status),
+		Actor:   subject(r),
+	})
+	if err != nil {
+		w.WriteHeader(orderErrorStatus(err))
+		json.NewEncoder(w).Encode(&dto.Error{Message: err.Error()})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(o)
+}
+
+func orderErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, entity.ErrInsufficientStock),
+		errors.Is(err, entity.ErrInvalidOrderTransition),
+		errors.Is(err, entity.ErrOrderVersionConflict):
+		return http.StatusConflict
+	case errors.Is(err, entity.ErrProductNotFound),
+		errors.Is(err, entity.ErrProductUnavailable):
+		return http.StatusUnprocessableEntity
+	case errors.Is(err, entity.ErrOrderHasNoItems),
+		errors.Is(err, entity.ErrInvalidQuantity),
+		errors.Is(err, entity.ErrCurrencyMismatch),
+		errors.Is(err, entity.ErrInvalidOrderTotal):
+		return http.StatusBadRequest
+	}
+
+	return http.StatusInternalServerError
+}
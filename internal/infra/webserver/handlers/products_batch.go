@@ -0,0 +1,300 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/rgoncalvesrr/fullcycle-clean-arch/internal/dto"
+	"github.com/rgoncalvesrr/fullcycle-clean-arch/internal/entity"
+	"github.com/rgoncalvesrr/fullcycle-clean-arch/internal/event"
+	"github.com/rgoncalvesrr/fullcycle-clean-arch/internal/infra/database"
+	pkgEntity "github.com/rgoncalvesrr/fullcycle-clean-arch/pkg/entity"
+	"github.com/rgoncalvesrr/fullcycle-clean-arch/pkg/events"
+	"github.com/rgoncalvesrr/fullcycle-clean-arch/pkg/jsonpatch"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrEmptyBatch        = errors.New("batch has no operations")
+	ErrBatchTooLarge     = fmt.Errorf("batch exceeds %d operations", maxBatchOperations)
+	ErrInvalidBatchMode  = errors.New("invalid batch mode, use atomic or independent")
+	ErrInvalidBatchOp    = errors.New("invalid batch operation, use create, update or delete")
+	ErrProductRequired   = errors.New("product is required")
+	ErrBatchNotAttempted = errors.New("not attempted, an earlier operation of the atomic batch failed")
+)
+
+const (
+	batchAtomic      = "atomic"
+	batchIndependent = "independent"
+
+	maxBatchOperations = 500
+)
+
+// errBatchRolledBack rolls back the transaction of a failed operation, and
+// of the whole batch in atomic mode.
+var errBatchRolledBack = errors.New("batch rolled back")
+
+// Batch Products godoc
+//
+//	@Summay			Batch Products
+//	@Description	Create, update and delete up to 500 products in one request. In atomic mode every operation runs in a single transaction that is rolled back if any of them fails, and the operations after the failed one are reported as not attempted (424); in independent mode each operation runs in its own transaction and succeeds or fails on its own. Updates and deletes with a version fail with 412 unless the product is at that version; without one (or with 0) they apply to the current version.
+//	@Tags			products
+//	@Accept			json
+//	@Produce		json
+//
+//	@Param			request	body		dto.BatchProductsInput	true	"operations"
+//	@Success		200		{object}	dto.BatchProductsOutput
+//	@Failure		400		{object}	dto.Error
+//	@Failure		422		{object}	dto.BatchProductsOutput
+//	@Failure		500		{object}	dto.Error
+//	@Router			/products:batch [post]
+//
+//	@Security		ApiKeyAuth
+func (h *ProductHandler) BatchProducts(w http.ResponseWriter, r *http.Request) {
+	var batchDto dto.BatchProductsInput
+	err := json.NewDecoder(r.Body).Decode(&batchDto)
+
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(&dto.Error{Message: err.Error()})
+		return
+	}
+
+	if batchDto.Mode == "" {
+		batchDto.Mode = batchAtomic
+	}
+
+	switch {
+	case batchDto.Mode != batchAtomic && batchDto.Mode != batchIndependent:
+		err = ErrInvalidBatchMode
+	case len(batchDto.Operations) == 0:
+		err = ErrEmptyBatch
+	case len(batchDto.Operations) > maxBatchOperations:
+		err = ErrBatchTooLarge
+	}
+
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(&dto.Error{Message: err.Error()})
+		return
+	}
+
+	output := dto.BatchProductsOutput{
+		Mode:    batchDto.Mode,
+		Results: make([]dto.BatchProductResult, 0, len(batchDto.Operations)),
+	}
+
+	gateway := h.ProductGateway.WithActor(subject(r))
+
+	if batchDto.Mode == batchIndependent {
+		// Each operation runs in its own transaction, so a failed one can
+		// neither undo nor abort the others.
+		for i, op := range batchDto.Operations {
+			var result dto.BatchProductResult
+			var change events.IEvent
+
+			err := gateway.Transaction(func(tx database.ProductInterface) error {
+				result, change = h.runBatchOperation(eventContext(r), tx, op, subject(r))
+				if result.Error != "" {
+					return errBatchRolledBack
+				}
+
+				return nil
+			})
+			if err != nil && !errors.Is(err, errBatchRolledBack) {
+				result.Status = batchErrorStatus(err)
+				result.Error = err.Error()
+			}
+
+			if err == nil {
+				publish(eventContext(r), h.EventDispatcher, change)
+			}
+
+			result.Index = i
+			output.Results = append(output.Results, result)
+		}
+	} else {
+		var changes []events.IEvent
+
+		err = gateway.Transaction(func(tx database.ProductInterface) error {
+			failed := false
+
+			for i, op := range batchDto.Operations {
+				// The batch is rolled back anyway, so the operations after
+				// a failed one aren't run.
+				if failed {
+					output.Results = append(output.Results, dto.BatchProductResult{
+						Index:  i,
+						Op:     op.Op,
+						ID:     op.ID,
+						Status: http.StatusFailedDependency,
+						Error:  ErrBatchNotAttempted.Error(),
+					})
+					continue
+				}
+
+				result, change := h.runBatchOperation(eventContext(r), tx, op, subject(r))
+				result.Index = i
+				output.Results = append(output.Results, result)
+
+				failed = failed || result.Error != ""
+				changes = append(changes, change)
+			}
+
+			if failed {
+				return errBatchRolledBack
+			}
+
+			return nil
+		})
+
+		if err == nil {
+			publish(eventContext(r), h.EventDispatcher, changes...)
+		}
+	}
+
+	if err != nil && !errors.Is(err, errBatchRolledBack) {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(&dto.Error{Message: err.Error()})
+		return
+	}
+
+	output.Committed = err == nil
+
+	status := http.StatusOK
+	if !output.Committed {
+		status = http.StatusUnprocessableEntity
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(&output)
+}
+
+// runBatchOperation runs op through tx and records its event there,
+// returning its result and, when it succeeded, the event to publish once tx
+// is committed.
+func (h *ProductHandler) runBatchOperation(ctx context.Context, tx database.ProductInterface, op dto.BatchProductOperation, deletedBy string) (dto.BatchProductResult, events.IEvent) {
+	result := dto.BatchProductResult{Op: op.Op, ID: op.ID}
+
+	var product *entity.Product
+	var change string
+	var err error
+
+	switch op.Op {
+	case "create":
+		product, err = h.batchCreate(tx, op)
+		result.Status = http.StatusCreated
+		change = event.ProductCreated
+	case "update":
+		product, err = h.batchUpdate(tx, op)
+		result.Status = http.StatusOK
+		change = event.ProductUpdated
+	case "delete":
+		err = tx.Delete(op.ID, deletedBy, op.Version)
+		result.Status = http.StatusNoContent
+	default:
+		err = ErrInvalidBatchOp
+	}
+
+	if err != nil {
+		result.Status = batchErrorStatus(err)
+		result.Error = err.Error()
+		return result, nil
+	}
+
+	var e events.IEvent
+
+	if product == nil {
+		e = event.NewProductChanged(event.ProductDeleted, op.ID, deletedProductPayload(op.ID))
+	} else {
+		result.ID = product.ID.String()
+		result.Version = product.Version
+		e = event.NewProductChanged(change, result.ID, newProductOutput(product))
+	}
+
+	if err = tx.RecordEvent(ctx, e); err != nil {
+		result.Status = batchErrorStatus(err)
+		result.Error = err.Error()
+		return result, nil
+	}
+
+	return result, e
+}
+
+func (h *ProductHandler) batchCreate(tx database.ProductInterface, op dto.BatchProductOperation) (*entity.Product, error) {
+	if len(op.Product) == 0 {
+		return nil, ErrProductRequired
+	}
+
+	productDto, err := decodeProductInput(bytes.NewReader(op.Product))
+	if err != nil {
+		return nil, &batchInputError{err}
+	}
+
+	product := &entity.Product{ID: pkgEntity.NewID(), Version: 1}
+
+	if err = h.applyProductInput(product, productDto); err != nil {
+		return nil, &batchInputError{err}
+	}
+
+	return product, tx.Create(product)
+}
+
+func (h *ProductHandler) batchUpdate(tx database.ProductInterface, op dto.BatchProductOperation) (*entity.Product, error) {
+	if len(op.Product) == 0 {
+		return nil, ErrProductRequired
+	}
+
+	product, err := tx.FindByID(op.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if op.Version != 0 && op.Version != product.Version {
+		return nil, database.ErrVersionConflict
+	}
+
+	if err = h.patchProduct(product, op.Product, jsonpatch.MergePatch); err != nil {
+		return nil, &batchInputError{err}
+	}
+
+	return product, tx.Update(product)
+}
+
+// batchInputError marks a failure caused by the operation payload, so it is
+// reported as a bad request rather than a server error.
+type batchInputError struct {
+	err error
+}
+
+func (e *batchInputError) Error() string {
+	return e.err.Error()
+}
+
+func (e *batchInputError) Unwrap() error {
+	return e.err
+}
+
+func batchErrorStatus(err error) int {
+	var inputErr *batchInputError
+
+	switch {
+	case errors.As(err, &inputErr),
+		errors.Is(err, ErrInvalidBatchOp),
+		errors.Is(err, ErrProductRequired):
+		return http.StatusBadRequest
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, database.ErrVersionConflict):
+		return http.StatusPreconditionFailed
+	case isSKUConflict(err):
+		return http.StatusConflict
+	}
+
+	return http.StatusInternalServerError
+}
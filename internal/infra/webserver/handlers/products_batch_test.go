@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/rgoncalvesrr/fullcycle-clean-arch/internal/dto"
+	"github.com/rgoncalvesrr/fullcycle-clean-arch/internal/infra/database"
+	"github.com/rgoncalvesrr/fullcycle-clean-arch/pkg/entity"
+	"github.com/rgoncalvesrr/fullcycle-clean-arch/pkg/events"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestAtomicBatchStopsAtFirstFailure(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+	assert.NoError(t, database.Migrate(db))
+
+	productGateway := database.NewProductGateway(db)
+	dispatcher := events.NewRecordingDispatcher(events.NewEventDispatcher(), database.NewEventStoreGateway(db))
+	handler := NewProductHandler(productGateway, database.NewCategoryGateway(db), dispatcher)
+
+	body := `{"operations":[` +
+		`{"op":"create","product":{"sku":"CAN-1","name":"Caneta","price":{"amount":"2.50","currency":"BRL"}}},` +
+		`{"op":"delete","id":"` + entity.NewID().String() + `"},` +
+		`{"op":"create","product":{"sku":"LAP-1","name":"Lápis","price":{"amount":"1.00","currency":"BRL"}}}]}`
+
+	r := httptest.NewRequest(http.MethodPost, "/products:batch", strings.NewReader(body))
+	w := httptest.NewRecorder()
+	handler.BatchProducts(w, r)
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+
+	var output dto.BatchProductsOutput
+	assert.NoError(t, json.NewDecoder(w.Body).Decode(&output))
+
+	assert.False(t, output.Committed)
+	assert.Len(t, output.Results, 3)
+
+	var statuses []int
+	for _, result := range output.Results {
+		statuses = append(statuses, result.Status)
+	}
+	assert.Equal(t, []int{http.StatusCreated, http.StatusNotFound, http.StatusFailedDependency}, statuses)
+	assert.Equal(t, ErrBatchNotAttempted.Error(), output.Results[2].Error)
+
+	products, err := productGateway.FindAll(0, 10, "asc")
+	assert.NoError(t, err)
+	assert.Empty(t, products)
+}
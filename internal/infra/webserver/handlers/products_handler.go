@@ -1,23 +1,44 @@
 package handlers
 
 import (
+	"bytes"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/go-chi/chi"
 	"github.com/rgoncalvesrr/fullcycle-clean-arch/internal/dto"
 	"github.com/rgoncalvesrr/fullcycle-clean-arch/internal/entity"
+	"github.com/rgoncalvesrr/fullcycle-clean-arch/internal/event"
 	"github.com/rgoncalvesrr/fullcycle-clean-arch/internal/infra/database"
+	pkgEntity "github.com/rgoncalvesrr/fullcycle-clean-arch/pkg/entity"
+	"github.com/rgoncalvesrr/fullcycle-clean-arch/pkg/events"
+	"github.com/rgoncalvesrr/fullcycle-clean-arch/pkg/jsonpatch"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrCategoryNotFound = errors.New("category not found")
+	ErrAdminRequired    = errors.New("admin privileges required")
+	ErrUnsupportedPatch = errors.New("unsupported patch media type")
 )
 
 type ProductHandler struct {
-	ProductGateway database.ProductInterface
+	ProductGateway  database.ProductInterface
+	CategoryGateway database.CategoryInterface
+	EventDispatcher *events.RecordingDispatcher
 }
 
-func NewProductHandler(db database.ProductInterface) *ProductHandler {
+func NewProductHandler(db database.ProductInterface, categoryDB database.CategoryInterface, dispatcher *events.RecordingDispatcher) *ProductHandler {
 	return &ProductHandler{
-		ProductGateway: db,
+		ProductGateway:  db,
+		CategoryGateway: categoryDB,
+		EventDispatcher: dispatcher,
 	}
 }
 
@@ -31,6 +52,7 @@ func NewProductHandler(db database.ProductInterface) *ProductHandler {
 //
 //	@Param			request	body		dto.CreateProductInput	true	"product request"
 //	@Success		201		{object}	dto.CreateProductOutput
+//	@Failure		409		{object}	dto.Error
 //	@Failure		500		{object}	dto.Error
 //	@Router			/products [post]
 //
@@ -45,33 +67,104 @@ func (h *ProductHandler) CreateProduct(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	p, err := entity.NewProduct(productDto.Name, productDto.Price)
+	price, err := parseMoney(productDto.Price)
 
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(&dto.Error{Message: err.Error()})
+		return
+	}
+
+	categoryID, err := h.findCategoryID(productDto.CategoryID)
+
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
 		json.NewEncoder(w).Encode(&dto.Error{Message: err.Error()})
 		return
 	}
 
-	err = h.ProductGateway.Create(p)
+	p, err := entity.NewProduct(productDto.SKU, productDto.Name, price)
+
+	if err == nil {
+		p.Description = productDto.Description
+		p.CategoryID = categoryID
+		p.Stock = productDto.Stock
+		if productDto.Status != "" {
+			p.Status = entity.ProductStatus(productDto.Status)
+		}
+		err = p.Validate()
+	}
+
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
 		json.NewEncoder(w).Encode(&dto.Error{Message: err.Error()})
 		return
 	}
 
-	o := &dto.CreateProductOutput{
-		ID:        p.ID.String(),
-		Name:      p.Name,
-		Price:     p.Price,
-		CreatedAt: p.CreatedAt,
+	var o *dto.CreateProductOutput
+
+	err = h.recordChange(r, h.ProductGateway.WithActor(subject(r)), func(tx database.ProductInterface) (events.IEvent, error) {
+		if err := tx.Create(p); err != nil {
+			return nil, err
+		}
+
+		o = newProductOutput(p)
+		return event.NewProductChanged(event.ProductCreated, o.ID, o), nil
+	})
+	if err != nil {
+		status := http.StatusInternalServerError
+		if isSKUConflict(err) {
+			status = http.StatusConflict
+		}
+
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(&dto.Error{Message: err.Error()})
+		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", versionETag(p.Version))
 	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(&o)
 }
 
+// findCategoryID parses an optional category id and checks that the
+// category exists.
+func (h *ProductHandler) findCategoryID(id string) (*pkgEntity.ID, error) {
+	if id == "" {
+		return nil, nil
+	}
+
+	category, err := h.CategoryGateway.FindByID(id)
+	if err != nil {
+		return nil, ErrCategoryNotFound
+	}
+
+	return &category.ID, nil
+}
+
+func newProductOutput(p *entity.Product) *dto.CreateProductOutput {
+	var categoryID *string
+	if p.CategoryID != nil {
+		id := p.CategoryID.String()
+		categoryID = &id
+	}
+
+	return &dto.CreateProductOutput{
+		ID:          p.ID.String(),
+		SKU:         p.SKU,
+		Name:        p.Name,
+		Description: p.Description,
+		CategoryID:  categoryID,
+		Price:       dto.Money{Amount: p.Price.String(), Currency: p.Price.Currency},
+		Stock:       p.Stock,
+		Status:      string(p.Status),
+		CreatedAt:   p.CreatedAt,
+		Version:     p.Version,
+		UpdatedAt:   p.UpdatedAt,
+	}
+}
+
 // Get Product godoc
 //
 //	@Summay			Get Product
@@ -80,8 +173,12 @@ func (h *ProductHandler) CreateProduct(w http.ResponseWriter, r *http.Request) {
 //	@Accept			json
 //	@Produce		json
 //
-//	@Param			id	path		string	true	"Product ID"	Format(uuid)
-//	@Success		200	{object}	entity.Product
+//	@Param			id				path		string	true	"Product ID"	Format(uuid)
+//	@Param			as_of			query		string	false	"return the product as it was at this time (RFC 3339)"
+//	@Param			If-None-Match	header		string	false	"ETag of a cached copy"
+//	@Success		200				{object}	dto.CreateProductOutput
+//	@Header			200				{string}	ETag	"product version"
+//	@Success		304
 //	@Failure		404	{object}	dto.Error
 //	@Router			/products/{id} [get]
 //
@@ -94,7 +191,21 @@ func (h *ProductHandler) GetProduct(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	product, err := h.ProductGateway.FindByID(id)
+	asOf, err := parseTimeParam(r.URL.Query().Get("as_of"))
+
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(&dto.Error{Message: fmt.Sprintf("invalid as_of: %v", err)})
+		return
+	}
+
+	var product *entity.Product
+
+	if asOf != nil {
+		product, err = h.ProductGateway.FindAsOf(id, *asOf)
+	} else {
+		product, err = h.ProductGateway.FindByID(id)
+	}
 
 	if err != nil {
 		w.WriteHeader(http.StatusNotFound)
@@ -102,33 +213,191 @@ func (h *ProductHandler) GetProduct(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	o := &dto.CreateProductOutput{
-		ID:        product.ID.String(),
-		Name:      product.Name,
-		Price:     product.Price,
-		CreatedAt: product.CreatedAt,
+	etag := versionETag(product.Version)
+	w.Header().Set("ETag", etag)
+
+	if match := r.Header.Get("If-None-Match"); match != "" && matchesETag(match, etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
 	}
 
+	o := newProductOutput(product)
+
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(&o)
 }
 
-// Update Product godoc
+// Get Product History godoc
 //
-//	@Summay			Update Product
-//	@Description	Update Product
+//	@Summay			Get Product History
+//	@Description	List the recorded changes of a product, oldest first, with its state before and after each of them
 //	@Tags			products
 //	@Accept			json
 //	@Produce		json
 //
-//	@Param			id		path		string					true	"Product ID"	Format(uuid)
-//	@Param			request	body		dto.CreateProductInput	true	"Product"
-//	@Success		200		{object}	entity.Product
-//	@Failure		400		{object}	dto.Error
+//	@Param			id		path		string	true	"Product ID"	Format(uuid)
+//	@Param			page	query		string	false	"page number"
+//	@Param			limit	query		string	false	"limit"
+//	@Success		200		{array}		dto.ProductHistoryOutput
 //	@Failure		404		{object}	dto.Error
 //	@Failure		500		{object}	dto.Error
-//	@Router			/products/{id} [put]
+//	@Router			/products/{id}/history [get]
+//
+//	@Security		ApiKeyAuth
+func (h *ProductHandler) GetProductHistory(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	pageInt, err := strconv.Atoi(r.URL.Query().Get("page"))
+	if err != nil || pageInt < 1 {
+		pageInt = 1
+	}
+
+	limitInt, err := strconv.Atoi(r.URL.Query().Get("limit"))
+	if err != nil || limitInt < 1 {
+		limitInt = 50
+	}
+
+	history, err := h.ProductGateway.History(id, (pageInt-1)*limitInt, limitInt)
+
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(&dto.Error{Message: err.Error()})
+		return
+	}
+
+	if len(history) == 0 && pageInt == 1 {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(&dto.Error{Message: gorm.ErrRecordNotFound.Error()})
+		return
+	}
+
+	o := make([]dto.ProductHistoryOutput, 0, len(history))
+	for i := range history {
+		entry, err := newProductHistoryOutput(&history[i])
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(&dto.Error{Message: err.Error()})
+			return
+		}
+
+		o = append(o, *entry)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(&o)
+}
+
+func newProductHistoryOutput(h *entity.ProductHistory) (*dto.ProductHistoryOutput, error) {
+	o := &dto.ProductHistoryOutput{
+		ID:        h.ID.String(),
+		Action:    string(h.Action),
+		Actor:     h.Actor,
+		Version:   h.Version,
+		CreatedAt: h.CreatedAt,
+	}
+
+	before, err := h.BeforeProduct()
+	if err != nil {
+		return nil, err
+	}
+
+	after, err := h.AfterProduct()
+	if err != nil {
+		return nil, err
+	}
+
+	if before != nil {
+		o.Before = newProductOutput(before)
+	}
+
+	if after != nil {
+		o.After = newProductOutput(after)
+	}
+
+	return o, nil
+}
+
+// Search Products godoc
+//
+//	@Summay			Search Products
+//	@Description	Full-text search on product names, ordered by relevance
+//	@Tags			products
+//	@Accept			json
+//	@Produce		json
+//
+//	@Param			q		query	string	true	"search terms"
+//	@Param			page	query	string	false	"page number"
+//	@Param			limit	query	string	false	"limit"
+//	@Success		200		{array}	entity.Product
+//	@Success		204
+//	@Failure		400	{object}	dto.Error
+//	@Failure		500	{object}	dto.Error
+//	@Failure		503	{object}	dto.Error
+//	@Router			/products/search [get]
+//
+//	@Security		ApiKeyAuth
+func (h *ProductHandler) SearchProducts(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query().Get("q")
+
+	pageInt, err := strconv.Atoi(r.URL.Query().Get("page"))
+	if err != nil || pageInt < 1 {
+		pageInt = 1
+	}
+
+	limitInt, err := strconv.Atoi(r.URL.Query().Get("limit"))
+	if err != nil {
+		limitInt = 50
+	}
+
+	products, err := h.ProductGateway.Search(q, (pageInt-1)*limitInt, limitInt)
+
+	if err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, database.ErrEmptySearchQuery):
+			status = http.StatusBadRequest
+		case errors.Is(err, database.ErrSearchUnavailable):
+			status = http.StatusServiceUnavailable
+		}
+
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(&dto.Error{Message: err.Error()})
+		return
+	}
+
+	if len(products) == 0 {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(&products)
+}
+
+// Update Product godoc
+//
+//	@Summay			Update Product
+//	@Description	Partially update a product with a JSON Merge Patch (RFC 7396) or a JSON Patch (RFC 6902). Plain application/json bodies are treated as merge patches.
+//	@Tags			products
+//	@Accept			application/merge-patch+json,application/json-patch+json,json
+//	@Produce		json
+//
+//	@Param			id			path		string					true	"Product ID"	Format(uuid)
+//	@Param			If-Match	header		string					true	"ETag of the version being updated"
+//	@Param			request		body		dto.CreateProductInput	true	"Patch document"
+//	@Success		200			{object}	dto.CreateProductOutput
+//	@Header			200			{string}	ETag	"new product version"
+//	@Failure		400			{object}	dto.Error
+//	@Failure		404			{object}	dto.Error
+//	@Failure		409			{object}	dto.Error
+//	@Failure		412			{object}	dto.Error
+//	@Failure		415			{object}	dto.Error
+//	@Failure		428			{object}	dto.Error
+//	@Failure		500			{object}	dto.Error
+//	@Router			/products/{id} [patch]
 //
 //	@Security		ApiKeyAuth
 func (h *ProductHandler) UpdateProduct(w http.ResponseWriter, r *http.Request) {
@@ -147,39 +416,220 @@ func (h *ProductHandler) UpdateProduct(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var productDto dto.CreateProductInput
-	err = json.NewDecoder(r.Body).Decode(&productDto)
+	if !checkIfMatch(w, r, product.Version) {
+		return
+	}
+
+	var apply func(doc, patch []byte) ([]byte, error)
+
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	switch mediaType {
+	case "application/merge-patch+json", "application/json", "":
+		apply = jsonpatch.MergePatch
+	case "application/json-patch+json":
+		apply = jsonpatch.Apply
+	default:
+		w.WriteHeader(http.StatusUnsupportedMediaType)
+		json.NewEncoder(w).Encode(&dto.Error{Message: ErrUnsupportedPatch.Error()})
+		return
+	}
+
+	patch, err := io.ReadAll(r.Body)
 	if err != nil {
 		w.WriteHeader(http.StatusBadRequest)
 		json.NewEncoder(w).Encode(&dto.Error{Message: err.Error()})
 		return
 	}
 
-	product.Name = productDto.Name
-	product.Price = productDto.Price
+	if err = h.patchProduct(product, patch, apply); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(&dto.Error{Message: err.Error()})
+		return
+	}
+
+	h.saveProduct(w, r, product)
+}
+
+// Replace Product godoc
+//
+//	@Summay			Replace Product
+//	@Description	Replace every writable field of a product
+//	@Tags			products
+//	@Accept			json
+//	@Produce		json
+//
+//	@Param			id			path		string					true	"Product ID"	Format(uuid)
+//	@Param			If-Match	header		string					true	"ETag of the version being replaced"
+//	@Param			request		body		dto.CreateProductInput	true	"Product"
+//	@Success		200			{object}	dto.CreateProductOutput
+//	@Header			200			{string}	ETag	"new product version"
+//	@Failure		400			{object}	dto.Error
+//	@Failure		404			{object}	dto.Error
+//	@Failure		409			{object}	dto.Error
+//	@Failure		412			{object}	dto.Error
+//	@Failure		428			{object}	dto.Error
+//	@Failure		500			{object}	dto.Error
+//	@Router			/products/{id} [put]
+//
+//	@Security		ApiKeyAuth
+func (h *ProductHandler) ReplaceProduct(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	if id == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	product, err := h.ProductGateway.FindByID(id)
 
-	err = h.ProductGateway.Update(product)
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
+		w.WriteHeader(http.StatusNotFound)
 		json.NewEncoder(w).Encode(&dto.Error{Message: err.Error()})
 		return
 	}
 
-	w.WriteHeader(http.StatusNoContent)
+	if !checkIfMatch(w, r, product.Version) {
+		return
+	}
+
+	productDto, err := decodeProductInput(r.Body)
+	if err == nil {
+		err = h.applyProductInput(product, productDto)
+	}
+
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(&dto.Error{Message: err.Error()})
+		return
+	}
+
+	h.saveProduct(w, r, product)
+}
+
+// saveProduct stores an updated product, answering with its new state.
+func (h *ProductHandler) saveProduct(w http.ResponseWriter, r *http.Request, product *entity.Product) {
+	var o *dto.CreateProductOutput
+
+	err := h.recordChange(r, h.ProductGateway.WithActor(subject(r)), func(tx database.ProductInterface) (events.IEvent, error) {
+		if err := tx.Update(product); err != nil {
+			return nil, err
+		}
+
+		o = newProductOutput(product)
+		return event.NewProductChanged(event.ProductUpdated, o.ID, o), nil
+	})
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, database.ErrVersionConflict) {
+			status = http.StatusPreconditionFailed
+		} else if isSKUConflict(err) {
+			status = http.StatusConflict
+		}
+
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(&dto.Error{Message: err.Error()})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", versionETag(product.Version))
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(o)
+}
+
+// patchProduct applies patch with apply to the writable representation of
+// product, so absent members keep their current value and null ones are
+// cleared, then validates the result.
+func (h *ProductHandler) patchProduct(product *entity.Product, patch []byte, apply func(doc, patch []byte) ([]byte, error)) error {
+	doc, err := json.Marshal(newProductInput(product))
+	if err == nil {
+		doc, err = apply(doc, patch)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	productDto, err := decodeProductInput(bytes.NewReader(doc))
+	if err != nil {
+		return err
+	}
+
+	return h.applyProductInput(product, productDto)
+}
+
+// applyProductInput overwrites the writable fields of product with
+// productDto and validates the result.
+func (h *ProductHandler) applyProductInput(product *entity.Product, productDto dto.CreateProductInput) error {
+	price, err := parseMoney(productDto.Price)
+	if err != nil {
+		return err
+	}
+
+	categoryID, err := h.findCategoryID(productDto.CategoryID)
+	if err != nil {
+		return err
+	}
+
+	product.SKU = productDto.SKU
+	product.Name = productDto.Name
+	product.Description = productDto.Description
+	product.CategoryID = categoryID
+	product.Price = price
+	product.Stock = productDto.Stock
+	product.Status = entity.ProductStatus(productDto.Status)
+	if product.Status == "" {
+		product.Status = entity.ProductActive
+	}
+
+	return product.Validate()
+}
+
+// decodeProductInput decodes a product body, rejecting unknown fields so a
+// misspelled member isn't silently dropped.
+func decodeProductInput(r io.Reader) (dto.CreateProductInput, error) {
+	var productDto dto.CreateProductInput
+
+	decoder := json.NewDecoder(r)
+	decoder.DisallowUnknownFields()
+	err := decoder.Decode(&productDto)
+
+	return productDto, err
+}
+
+func newProductInput(p *entity.Product) *dto.CreateProductInput {
+	var categoryID string
+	if p.CategoryID != nil {
+		categoryID = p.CategoryID.String()
+	}
+
+	return &dto.CreateProductInput{
+		SKU:         p.SKU,
+		Name:        p.Name,
+		Description: p.Description,
+		CategoryID:  categoryID,
+		Price:       dto.Money{Amount: p.Price.String(), Currency: p.Price.Currency},
+		Stock:       p.Stock,
+		Status:      string(p.Status),
+	}
 }
 
 // Delete Product godoc
 //
 //	@Summay			Delete Product
-//	@Description	Delete Product
+//	@Description	Move a product to the trash
 //	@Tags			products
 //	@Accept			json
 //	@Produce		json
 //
-//	@Param			id	path	string	true	"Product ID"	Format(uuid)
+//	@Param			id			path	string	true	"Product ID"	Format(uuid)
+//	@Param			If-Match	header	string	true	"ETag of the version being deleted"
 //	@Success		204
 //	@Failure		400
 //	@Failure		404	{object}	dto.Error
+//	@Failure		412	{object}	dto.Error
+//	@Failure		428	{object}	dto.Error
+//	@Failure		500	{object}	dto.Error
 //	@Router			/products/{id} [delete]
 //
 //	@Security		ApiKeyAuth
@@ -191,7 +641,7 @@ func (h *ProductHandler) DeleteProduct(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err := h.ProductGateway.Delete(id)
+	product, err := h.ProductGateway.FindByID(id)
 
 	if err != nil {
 		w.WriteHeader(http.StatusNotFound)
@@ -199,44 +649,188 @@ func (h *ProductHandler) DeleteProduct(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if !checkIfMatch(w, r, product.Version) {
+		return
+	}
+
+	err = h.recordChange(r, h.ProductGateway, func(tx database.ProductInterface) (events.IEvent, error) {
+		if err := tx.Delete(id, subject(r), product.Version); err != nil {
+			return nil, err
+		}
+
+		return event.NewProductChanged(event.ProductDeleted, id, deletedProductPayload(id)), nil
+	})
+
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			status = http.StatusNotFound
+		} else if errors.Is(err, database.ErrVersionConflict) {
+			status = http.StatusPreconditionFailed
+		}
+
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(&dto.Error{Message: err.Error()})
+		return
+	}
+
 	w.WriteHeader(http.StatusNoContent)
 }
 
-// List Product godoc
+// isSKUConflict reports whether err rejected a SKU used by another product,
+// in the trash or not.
+func isSKUConflict(err error) bool {
+	return errors.Is(err, database.ErrSKUTaken) || errors.Is(err, database.ErrSKUInTrash)
+}
+
+// deletedProductPayload is the payload of product.deleted events.
+func deletedProductPayload(id string) map[string]string {
+	return map[string]string{"id": id}
+}
+
+// Restore Product godoc
 //
-//	@Summay			List Products
-//	@Description	List Products
+//	@Summay			Restore Product
+//	@Description	Take a product out of the trash. Requires an admin token.
+//	@Tags			products
+//	@Accept			json
+//	@Produce		json
+//
+//	@Param			id	path		string	true	"Product ID"	Format(uuid)
+//	@Success		200	{object}	dto.CreateProductOutput
+//	@Failure		403	{object}	dto.Error
+//	@Failure		404	{object}	dto.Error
+//	@Failure		500	{object}	dto.Error
+//	@Router			/products/{id}/restore [post]
+//
+//	@Security		ApiKeyAuth
+func (h *ProductHandler) RestoreProduct(w http.ResponseWriter, r *http.Request) {
+	if !isAdmin(r) {
+		w.WriteHeader(http.StatusForbidden)
+		json.NewEncoder(w).Encode(&dto.Error{Message: ErrAdminRequired.Error()})
+		return
+	}
+
+	var product *entity.Product
+	var o *dto.CreateProductOutput
+
+	err := h.recordChange(r, h.ProductGateway.WithActor(subject(r)), func(tx database.ProductInterface) (events.IEvent, error) {
+		var err error
+		if product, err = tx.Restore(chi.URLParam(r, "id")); err != nil {
+			return nil, err
+		}
+
+		o = newProductOutput(product)
+		return event.NewProductChanged(event.ProductRestored, o.ID, o), nil
+	})
+
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			status = http.StatusNotFound
+		}
+
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(&dto.Error{Message: err.Error()})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", versionETag(product.Version))
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(o)
+}
+
+// List Trash godoc
+//
+//	@Summay			List Trash
+//	@Description	List deleted products. Requires an admin token.
 //	@Tags			products
 //	@Accept			json
 //	@Produce		json
 //
 //	@Param			page	query	string	false	"page number"
 //	@Param			limit	query	string	false	"limit"
-//	@Param			sort	query	string	false	"order type"
+//	@Param			sort	query	string	false	"comma separated fields, prefixed with - for descending order"
 //	@Success		200		{array}	entity.Product
 //	@Success		204
+//	@Failure		400	{object}	dto.Error
+//	@Failure		403	{object}	dto.Error
+//	@Failure		500	{object}	dto.Error
+//	@Router			/products/trash [get]
+//
+//	@Security		ApiKeyAuth
+func (h *ProductHandler) GetTrash(w http.ResponseWriter, r *http.Request) {
+	if !isAdmin(r) {
+		w.WriteHeader(http.StatusForbidden)
+		json.NewEncoder(w).Encode(&dto.Error{Message: ErrAdminRequired.Error()})
+		return
+	}
+
+	query, err := productQueryFromRequest(r)
+
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(&dto.Error{Message: err.Error()})
+		return
+	}
+
+	query.OnlyDeleted = true
+
+	h.writeProducts(w, query)
+}
+
+// List Product godoc
+//
+//	@Summay			List Products
+//	@Description	List Products
+//	@Tags			products
+//	@Accept			json
+//	@Produce		json
+//
+//	@Param			page			query	string	false	"page number"
+//	@Param			limit			query	string	false	"limit"
+//	@Param			sort			query	string	false	"comma separated fields (sku, name, price, stock, created_at, updated_at), prefixed with - for descending order"
+//	@Param			name			query	string	false	"name contains"
+//	@Param			name_prefix		query	string	false	"name starts with"
+//	@Param			price_min		query	string	false	"minimum price"
+//	@Param			price_max		query	string	false	"maximum price"
+//	@Param			currency		query	string	false	"currency of price_min and price_max (default BRL)"
+//	@Param			created_from	query	string	false	"created at or after (RFC 3339)"
+//	@Param			created_to		query	string	false	"created at or before (RFC 3339)"
+//	@Param			category		query	string	false	"category id, including its subcategories"	Format(uuid)
+//	@Param			status			query	string	false	"status"	Enums(active, archived)
+//	@Param			include_deleted	query	bool	false	"include products in the trash (admin only)"
+//	@Success		200				{array}	entity.Product
+//	@Success		204
+//	@Failure		400	{object}	dto.Error
+//	@Failure		403	{object}	dto.Error
 //	@Failure		500	{object}	dto.Error
 //	@Router			/products [get]
 //
 //	@Security		ApiKeyAuth
 func (h *ProductHandler) GetProducts(w http.ResponseWriter, r *http.Request) {
-	page := r.URL.Query().Get("page")
-	limit := r.URL.Query().Get("limit")
-	sort := r.URL.Query().Get("sort")
+	query, err := productQueryFromRequest(r)
 
-	pageInt, err := strconv.Atoi(page)
 	if err != nil {
-		pageInt = 1
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(&dto.Error{Message: err.Error()})
+		return
 	}
 
-	limitInt, err := strconv.Atoi(limit)
-	if err != nil {
-		limitInt = 50
+	query.IncludeDeleted = r.URL.Query().Get("include_deleted") == "true"
+
+	if query.IncludeDeleted && !isAdmin(r) {
+		w.WriteHeader(http.StatusForbidden)
+		json.NewEncoder(w).Encode(&dto.Error{Message: ErrAdminRequired.Error()})
+		return
 	}
 
-	offset := (pageInt - 1) * limitInt
+	h.writeProducts(w, query)
+}
 
-	products, err := h.ProductGateway.FindAll(offset, limitInt, sort)
+func (h *ProductHandler) writeProducts(w http.ResponseWriter, query database.ProductQuery) {
+	products, err := h.ProductGateway.FindByQuery(query)
 
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
@@ -253,3 +847,93 @@ func (h *ProductHandler) GetProducts(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(&products)
 }
+
+func productQueryFromRequest(r *http.Request) (database.ProductQuery, error) {
+	values := r.URL.Query()
+
+	pageInt, err := strconv.Atoi(values.Get("page"))
+	if err != nil || pageInt < 1 {
+		pageInt = 1
+	}
+
+	limitInt, err := strconv.Atoi(values.Get("limit"))
+	if err != nil {
+		limitInt = 50
+	}
+
+	sort, err := database.ParseProductSort(values.Get("sort"))
+	if err != nil {
+		return database.ProductQuery{}, err
+	}
+
+	query := database.ProductQuery{
+		NameContains: values.Get("name"),
+		NamePrefix:   values.Get("name_prefix"),
+		CategoryID:   values.Get("category"),
+		Status:       entity.ProductStatus(values.Get("status")),
+		Sort:         sort,
+		Offset:       (pageInt - 1) * limitInt,
+		Limit:        limitInt,
+	}
+
+	if query.Status != "" && !query.Status.IsValid() {
+		return query, entity.ErrInvalidProductStatus
+	}
+
+	currency := values.Get("currency")
+	if currency == "" {
+		currency = entity.DefaultCurrency
+	}
+
+	if query.PriceMin, err = parseMoneyParam(values.Get("price_min"), currency); err != nil {
+		return query, fmt.Errorf("invalid price_min: %w", err)
+	}
+
+	if query.PriceMax, err = parseMoneyParam(values.Get("price_max"), currency); err != nil {
+		return query, fmt.Errorf("invalid price_max: %w", err)
+	}
+
+	if query.CreatedFrom, err = parseTimeParam(values.Get("created_from")); err != nil {
+		return query, fmt.Errorf("invalid created_from: %w", err)
+	}
+
+	if query.CreatedTo, err = parseTimeParam(values.Get("created_to")); err != nil {
+		return query, fmt.Errorf("invalid created_to: %w", err)
+	}
+
+	return query, nil
+}
+
+func parseMoneyParam(s string, currency string) (*entity.Money, error) {
+	if s == "" {
+		return nil, nil
+	}
+
+	m, err := entity.ParseMoney(s, currency)
+	if err != nil {
+		return nil, err
+	}
+
+	return &m, nil
+}
+
+func parseMoney(m dto.Money) (entity.Money, error) {
+	if m.Currency == "" {
+		m.Currency = entity.DefaultCurrency
+	}
+
+	return entity.ParseMoney(m.Amount, m.Currency)
+}
+
+func parseTimeParam(s string) (*time.Time, error) {
+	if s == "" {
+		return nil, nil
+	}
+
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return nil, err
+	}
+
+	return &t, nil
+}
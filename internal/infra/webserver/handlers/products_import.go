@@ -0,0 +1,526 @@
+package handlers
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/rgoncalvesrr/fullcycle-clean-arch/internal/dto"
+	"github.com/rgoncalvesrr/fullcycle-clean-arch/internal/entity"
+	"github.com/rgoncalvesrr/fullcycle-clean-arch/internal/event"
+	"github.com/rgoncalvesrr/fullcycle-clean-arch/internal/infra/database"
+	pkgEntity "github.com/rgoncalvesrr/fullcycle-clean-arch/pkg/entity"
+	"github.com/rgoncalvesrr/fullcycle-clean-arch/pkg/events"
+	"github.com/rgoncalvesrr/fullcycle-clean-arch/pkg/jsonpatch"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrUnsupportedImport = errors.New("unsupported import media type, use text/csv or application/x-ndjson")
+	ErrUnsupportedExport = errors.New("unsupported export format, use csv or ndjson")
+	ErrInvalidImportMode = errors.New("invalid import mode, use transactional or best_effort")
+	ErrMalformedImport   = errors.New("malformed import file")
+	ErrUnknownCSVColumn  = errors.New("unknown csv column")
+)
+
+const (
+	importTransactional = "transactional"
+	importBestEffort    = "best_effort"
+
+	// exportFlushEvery is the number of rows written between flushes of an
+	// export stream.
+	exportFlushEvery = 100
+)
+
+// errImportRolledBack aborts the import transaction on dry runs and failed
+// transactional imports.
+var errImportRolledBack = errors.New("import rolled back")
+
+// productCSVColumns is the column order of exported files. Imported files
+// may use any subset of them, in any order.
+var productCSVColumns = []string{"sku", "name", "description", "category_id", "price", "currency", "stock", "status"}
+
+// Import Products godoc
+//
+//	@Summay			Import Products
+//	@Description	Create or update products by SKU from a CSV or NDJSON file. Columns or members missing from a row, and empty CSV cells, keep the current value of existing products. In transactional mode a single rejected row rolls the whole import back; in best_effort mode each row is saved on its own and the valid rows are kept.
+//	@Tags			products
+//	@Accept			text/csv,application/x-ndjson
+//	@Produce		json
+//
+//	@Param			mode	query		string	false	"Import mode"	Enums(transactional, best_effort)	default(transactional)
+//	@Param			dry_run	query		bool	false	"Validate the file without saving anything"
+//	@Success		200		{object}	dto.ImportProductsOutput
+//	@Failure		400		{object}	dto.Error
+//	@Failure		415		{object}	dto.Error
+//	@Failure		422		{object}	dto.ImportProductsOutput
+//	@Failure		500		{object}	dto.Error
+//	@Router			/products/import [post]
+//
+//	@Security		ApiKeyAuth
+func (h *ProductHandler) ImportProducts(w http.ResponseWriter, r *http.Request) {
+	mode := r.URL.Query().Get("mode")
+	if mode == "" {
+		mode = importTransactional
+	}
+
+	if mode != importTransactional && mode != importBestEffort {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(&dto.Error{Message: ErrInvalidImportMode.Error()})
+		return
+	}
+
+	var rows productRowReader
+	var err error
+
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	switch mediaType {
+	case "text/csv":
+		rows, err = newCSVProductReader(r.Body)
+	case "application/x-ndjson", "application/ndjson":
+		rows = newNDJSONProductReader(r.Body)
+	default:
+		w.WriteHeader(http.StatusUnsupportedMediaType)
+		json.NewEncoder(w).Encode(&dto.Error{Message: ErrUnsupportedImport.Error()})
+		return
+	}
+
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(&dto.Error{Message: err.Error()})
+		return
+	}
+
+	report := dto.ImportProductsOutput{
+		Mode:   mode,
+		DryRun: r.URL.Query().Get("dry_run") == "true",
+		Errors: []dto.ImportProductRowError{},
+	}
+
+	gateway := h.ProductGateway.WithActor(subject(r))
+
+	if mode == importBestEffort {
+		// Each row is committed on its own, so that a failed one can't
+		// abort the transaction the others are in.
+		err = eachProductRow(rows, func(row productRow) error {
+			var product *entity.Product
+			var created bool
+			var rowErr error
+
+			err := gateway.Transaction(func(tx database.ProductInterface) error {
+				if product, created, rowErr = h.importProductRow(eventContext(r), tx, row); rowErr != nil {
+					return rowErr
+				}
+
+				if report.DryRun {
+					return errImportRolledBack
+				}
+
+				return nil
+			})
+			if err != nil && !errors.Is(err, errImportRolledBack) {
+				rowErr = err
+			}
+
+			if err == nil {
+				publish(eventContext(r), h.EventDispatcher, importedProductChanged(product, created))
+			}
+
+			addImportedRow(&report, row, created, rowErr)
+
+			return nil
+		})
+	} else {
+		var changes []events.IEvent
+
+		err = gateway.Transaction(func(tx database.ProductInterface) error {
+			err := eachProductRow(rows, func(row productRow) error {
+				product, created, err := h.importProductRow(eventContext(r), tx, row)
+				addImportedRow(&report, row, created, err)
+
+				if err == nil {
+					changes = append(changes, importedProductChanged(product, created))
+				}
+
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+
+			if report.DryRun || report.Failed > 0 {
+				return errImportRolledBack
+			}
+
+			return nil
+		})
+
+		if err == nil {
+			publish(eventContext(r), h.EventDispatcher, changes...)
+		}
+	}
+
+	if err != nil && !errors.Is(err, errImportRolledBack) {
+		status := http.StatusInternalServerError
+		if errors.Is(err, ErrMalformedImport) {
+			status = http.StatusBadRequest
+		}
+
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(&dto.Error{Message: err.Error()})
+		return
+	}
+
+	status := http.StatusOK
+	if mode == importTransactional && report.Failed > 0 {
+		status = http.StatusUnprocessableEntity
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(&report)
+}
+
+// eachProductRow calls fn with every row read, stopping at the first error.
+func eachProductRow(rows productRowReader, fn func(productRow) error) error {
+	for {
+		row, err := rows.Next()
+		if err == io.EOF {
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if err := fn(row); err != nil {
+			return err
+		}
+	}
+}
+
+// addImportedRow counts row in report, with its error if it was rejected.
+func addImportedRow(report *dto.ImportProductsOutput, row productRow, created bool, err error) {
+	switch {
+	case err != nil:
+		sku, _ := row.patch["sku"].(string)
+		report.Failed++
+		report.Errors = append(report.Errors, dto.ImportProductRowError{Line: row.line, SKU: sku, Message: err.Error()})
+	case created:
+		report.Created++
+	default:
+		report.Updated++
+	}
+}
+
+// importProductRow merges row into the product with the same SKU, or into a
+// new product when there is none, and saves it and its event through tx. It
+// returns the saved product and whether it was created.
+func (h *ProductHandler) importProductRow(ctx context.Context, tx database.ProductInterface, row productRow) (*entity.Product, bool, error) {
+	if row.err != nil {
+		return nil, false, row.err
+	}
+
+	sku, _ := row.patch["sku"].(string)
+	if sku == "" {
+		return nil, false, entity.ErrSKUIsRequired
+	}
+
+	created := false
+
+	product, err := tx.FindBySKU(sku)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		product = &entity.Product{ID: pkgEntity.NewID(), Version: 1}
+		created = true
+	} else if err != nil {
+		return nil, false, err
+	}
+
+	patch, err := json.Marshal(row.patch)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if err = h.patchProduct(product, patch, jsonpatch.MergePatch); err != nil {
+		return nil, false, err
+	}
+
+	if created {
+		err = tx.Create(product)
+	} else {
+		err = tx.Update(product)
+	}
+
+	if err != nil {
+		return nil, false, err
+	}
+
+	return product, created, tx.RecordEvent(ctx, importedProductChanged(product, created))
+}
+
+// importedProductChanged is the event published for an imported product.
+func importedProductChanged(product *entity.Product, created bool) events.IEvent {
+	name := event.ProductUpdated
+	if created {
+		name = event.ProductCreated
+	}
+
+	return event.NewProductChanged(name, product.ID.String(), newProductOutput(product))
+}
+
+// Export Products godoc
+//
+//	@Summay			Export Products
+//	@Description	Stream every product matching the filters as CSV or NDJSON, in the format accepted by the import
+//	@Tags			products
+//	@Produce		text/csv,application/x-ndjson
+//
+//	@Param			format			query	string	false	"Export format"	Enums(csv, ndjson)	default(csv)
+//	@Param			name			query	string	false	"name contains (case insensitive)"
+//	@Param			name_prefix		query	string	false	"name starts with (case insensitive)"
+//	@Param			category		query	string	false	"category id, including its subcategories"	Format(uuid)
+//	@Param			status			query	string	false	"product status"	Enums(active, archived)
+//	@Param			price_min		query	string	false	"minimum price"
+//	@Param			price_max		query	string	false	"maximum price"
+//	@Param			currency		query	string	false	"currency of price_min and price_max"	default(BRL)
+//	@Param			created_from	query	string	false	"created at or after (RFC 3339)"
+//	@Param			created_to		query	string	false	"created at or before (RFC 3339)"
+//	@Param			sort			query	string	false	"comma separated fields, prefix with - for descending"
+//	@Success		200
+//	@Failure		400	{object}	dto.Error
+//	@Router			/products/export [get]
+//
+//	@Security		ApiKeyAuth
+func (h *ProductHandler) ExportProducts(w http.ResponseWriter, r *http.Request) {
+	query, err := productQueryFromRequest(r)
+
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(&dto.Error{Message: err.Error()})
+		return
+	}
+
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = "csv"
+	}
+
+	var write func(*dto.CreateProductInput) error
+	var flush func() error
+	var csvWriter *csv.Writer
+
+	switch format {
+	case "csv":
+		csvWriter = csv.NewWriter(w)
+		write = func(p *dto.CreateProductInput) error {
+			return csvWriter.Write([]string{
+				p.SKU, p.Name, p.Description, p.CategoryID, p.Price.Amount, p.Price.Currency, strconv.Itoa(p.Stock), p.Status,
+			})
+		}
+		flush = func() error {
+			csvWriter.Flush()
+			return csvWriter.Error()
+		}
+
+		w.Header().Set("Content-Type", "text/csv")
+	case "ndjson":
+		encoder := json.NewEncoder(w)
+		write = func(p *dto.CreateProductInput) error {
+			return encoder.Encode(p)
+		}
+		flush = func() error {
+			return nil
+		}
+
+		w.Header().Set("Content-Type", "application/x-ndjson")
+	default:
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(&dto.Error{Message: ErrUnsupportedExport.Error()})
+		return
+	}
+
+	w.Header().Set("Content-Disposition", `attachment; filename="products.`+format+`"`)
+	w.WriteHeader(http.StatusOK)
+
+	if csvWriter != nil {
+		csvWriter.Write(productCSVColumns)
+	}
+
+	n := 0
+	err = h.ProductGateway.Each(query, func(p *entity.Product) error {
+		if err := write(newProductInput(p)); err != nil {
+			return err
+		}
+
+		n++
+		if n%exportFlushEvery == 0 {
+			if err := flush(); err != nil {
+				return err
+			}
+
+			if f, ok := w.(http.Flusher); ok {
+				f.Flush()
+			}
+		}
+
+		return nil
+	})
+
+	if err == nil {
+		err = flush()
+	}
+
+	// The status line is already sent, so a failure can only cut the stream
+	// short.
+	if err != nil {
+		log.Printf("product export aborted after %d rows: %v", n, err)
+	}
+}
+
+// productRow is an imported row as a merge patch over the writable product
+// representation.
+type productRow struct {
+	line  int
+	patch map[string]any
+	err   error
+}
+
+// productRowReader reads the rows of an import file, returning io.EOF after
+// the last one. Errors that make the rest of the file unreadable wrap
+// ErrMalformedImport; problems confined to a row are reported in its err.
+type productRowReader interface {
+	Next() (productRow, error)
+}
+
+type csvProductReader struct {
+	reader  *csv.Reader
+	columns []string
+}
+
+func newCSVProductReader(r io.Reader) (*csvProductReader, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = 0
+	reader.ReuseRecord = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrMalformedImport, err)
+	}
+
+	columns := make([]string, len(header))
+	for i, c := range header {
+		c = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(c, "\ufeff")))
+
+		if !isProductCSVColumn(c) {
+			return nil, fmt.Errorf("%w: %q", ErrUnknownCSVColumn, c)
+		}
+
+		columns[i] = c
+	}
+
+	return &csvProductReader{reader: reader, columns: columns}, nil
+}
+
+func (c *csvProductReader) Next() (productRow, error) {
+	record, err := c.reader.Read()
+	if err == io.EOF {
+		return productRow{}, err
+	}
+
+	if errors.Is(err, csv.ErrFieldCount) {
+		line, _ := c.reader.FieldPos(0)
+		return productRow{line: line, err: csv.ErrFieldCount}, nil
+	}
+
+	if err != nil {
+		return productRow{}, fmt.Errorf("%w: %v", ErrMalformedImport, err)
+	}
+
+	line, _ := c.reader.FieldPos(0)
+	row := productRow{line: line, patch: map[string]any{}}
+	price := map[string]any{}
+
+	for i, value := range record {
+		if value == "" {
+			continue
+		}
+
+		switch column := c.columns[i]; column {
+		case "price":
+			price["amount"] = value
+		case "currency":
+			price["currency"] = value
+		case "stock":
+			stock, err := strconv.Atoi(value)
+			if err != nil {
+				row.err = entity.ErrInvalidStock
+			}
+			row.patch[column] = stock
+		default:
+			row.patch[column] = value
+		}
+	}
+
+	if len(price) > 0 {
+		row.patch["price"] = price
+	}
+
+	return row, nil
+}
+
+func isProductCSVColumn(c string) bool {
+	for _, column := range productCSVColumns {
+		if c == column {
+			return true
+		}
+	}
+
+	return false
+}
+
+type ndjsonProductReader struct {
+	scanner *bufio.Scanner
+	line    int
+}
+
+func newNDJSONProductReader(r io.Reader) *ndjsonProductReader {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	return &ndjsonProductReader{scanner: scanner}
+}
+
+func (n *ndjsonProductReader) Next() (productRow, error) {
+	for n.scanner.Scan() {
+		n.line++
+
+		text := bytes.TrimSpace(n.scanner.Bytes())
+		if len(text) == 0 {
+			continue
+		}
+
+		row := productRow{line: n.line}
+
+		decoder := json.NewDecoder(bytes.NewReader(text))
+		decoder.UseNumber()
+		row.err = decoder.Decode(&row.patch)
+
+		return row, nil
+	}
+
+	if err := n.scanner.Err(); err != nil {
+		return productRow{}, fmt.Errorf("%w: %v", ErrMalformedImport, err)
+	}
+
+	return productRow{}, io.EOF
+}
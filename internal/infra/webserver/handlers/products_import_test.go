@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/rgoncalvesrr/fullcycle-clean-arch/internal/dto"
+	"github.com/rgoncalvesrr/fullcycle-clean-arch/internal/entity"
+	"github.com/rgoncalvesrr/fullcycle-clean-arch/internal/infra/database"
+	"github.com/rgoncalvesrr/fullcycle-clean-arch/pkg/events"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestImportProductsReportsTrashedSKU(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+	assert.NoError(t, database.Migrate(db))
+
+	productGateway := database.NewProductGateway(db)
+	dispatcher := events.NewRecordingDispatcher(events.NewEventDispatcher(), database.NewEventStoreGateway(db))
+	handler := NewProductHandler(productGateway, database.NewCategoryGateway(db), dispatcher)
+
+	trashed, err := entity.NewProduct("CAN-1", "Caneta", entity.MustParseMoney("2.50", "BRL"))
+	assert.NoError(t, err)
+	assert.NoError(t, productGateway.Create(trashed))
+	assert.NoError(t, productGateway.Delete(trashed.ID.String(), "admin", 0))
+
+	body := `{"sku":"CAN-1","name":"Caneta azul","price":{"amount":"3.00","currency":"BRL"}}` + "\n" +
+		`{"sku":"LAP-1","name":"Lápis","price":{"amount":"1.00","currency":"BRL"}}` + "\n"
+
+	for _, mode := range []string{importTransactional, importBestEffort} {
+		r := httptest.NewRequest(http.MethodPost, "/products/import?mode="+mode, strings.NewReader(body))
+		r.Header.Set("Content-Type", "application/x-ndjson")
+
+		w := httptest.NewRecorder()
+		handler.ImportProducts(w, r)
+
+		var report dto.ImportProductsOutput
+		assert.NoError(t, json.NewDecoder(w.Body).Decode(&report))
+
+		assert.Equal(t, 1, report.Failed, mode)
+		assert.Equal(t, []dto.ImportProductRowError{
+			{Line: 1, SKU: "CAN-1", Message: database.ErrSKUInTrash.Error()},
+		}, report.Errors, mode)
+
+		if mode == importTransactional {
+			assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+		} else {
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.Equal(t, 1, report.Created)
+		}
+	}
+
+	_, err = productGateway.FindBySKU("LAP-1")
+	assert.NoError(t, err)
+}
@@ -64,8 +64,9 @@ func (h *UserHandler) GetJWT(w http.ResponseWriter, r *http.Request) {
 	}
 
 	_, token, _ := h.Jwt.Encode(map[string]interface{}{
-		"sub": u.ID.String(),
-		"exp": time.Now().Add(time.Second * time.Duration(h.JwtExpiresIn)).Unix(),
+		"sub":   u.ID.String(),
+		"admin": u.Admin,
+		"exp":   time.Now().Add(time.Second * time.Duration(h.JwtExpiresIn)).Unix(),
 	})
 
 	accessToken := dto.GetJWTOutput{AccessToken: token}
@@ -0,0 +1,65 @@
+package usecase
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/rgoncalvesrr/fullcycle-clean-arch/internal/dto"
+	"github.com/rgoncalvesrr/fullcycle-clean-arch/internal/entity"
+	"github.com/rgoncalvesrr/fullcycle-clean-arch/internal/infra/database"
+	"gorm.io/gorm"
+)
+
+type CreateOrderUseCase struct {
+	ProductGateway database.ProductInterface
+	OrderGateway   database.OrderInterface
+	TaxPolicy      entity.TaxPolicy
+}
+
+func NewCreateOrderUseCase(productDB database.ProductInterface, orderDB database.OrderInterface, taxPolicy entity.TaxPolicy) *CreateOrderUseCase {
+	return &CreateOrderUseCase{
+		ProductGateway: productDB,
+		OrderGateway:   orderDB,
+		TaxPolicy:      taxPolicy,
+	}
+}
+
+// Execute places an order for the requested products at their current
+// prices plus the taxes due in the order region, taking the quantities out
+// of stock.
+func (u *CreateOrderUseCase) Execute(input dto.CreateOrderInput) (*dto.OrderOutput, error) {
+	items := make([]entity.OrderItem, 0, len(input.Items))
+
+	for _, line := range input.Items {
+		product, err := u.ProductGateway.FindByID(line.ProductID)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("%w: %s", entity.ErrProductNotFound, line.ProductID)
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		item, err := entity.NewOrderItem(product, line.Quantity)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", err, product.SKU)
+		}
+
+		items = append(items, item)
+	}
+
+	order, err := entity.NewOrder(items)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = order.ApplyTax(u.TaxPolicy, input.Region); err != nil {
+		return nil, err
+	}
+
+	if err = u.OrderGateway.Create(order); err != nil {
+		return nil, err
+	}
+
+	return NewOrderOutput(order), nil
+}
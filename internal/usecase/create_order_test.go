@@ -0,0 +1,123 @@
+package usecase
+
+import (
+	"testing"
+
+	"github.com/rgoncalvesrr/fullcycle-clean-arch/internal/dto"
+	"github.com/rgoncalvesrr/fullcycle-clean-arch/internal/entity"
+	"github.com/rgoncalvesrr/fullcycle-clean-arch/internal/infra/database"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func newTestDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	if err != nil {
+		t.Error(err)
+	}
+	assert.NoError(t, database.Migrate(db))
+
+	return db
+}
+
+func newTestProduct(t *testing.T, db *gorm.DB, sku string, price string, stock int) *entity.Product {
+	product, err := entity.NewProduct(sku, "Produto "+sku, entity.MustParseMoney(price, "BRL"))
+	assert.NoError(t, err)
+
+	product.Stock = stock
+	assert.NoError(t, database.NewProductGateway(db).Create(product))
+
+	return product
+}
+
+func newTestTaxPolicy(t *testing.T, rules ...entity.TaxRule) entity.TaxPolicy {
+	policy, err := entity.NewRuleTaxPolicy(rules, entity.RoundHalfUp)
+	assert.NoError(t, err)
+
+	return policy
+}
+
+func TestCreateOrder(t *testing.T) {
+	db := newTestDB(t)
+	pen := newTestProduct(t, db, "PEN", "2.50", 10)
+	book := newTestProduct(t, db, "BOOK", "15.90", 3)
+
+	uc := NewCreateOrderUseCase(database.NewProductGateway(db), database.NewOrderGateway(db), newTestTaxPolicy(t))
+
+	output, err := uc.Execute(dto.CreateOrderInput{Items: []dto.OrderItemInput{
+		{ProductID: pen.ID.String(), Quantity: 4},
+		{ProductID: book.ID.String(), Quantity: 1},
+	}})
+
+	assert.NoError(t, err)
+	assert.Equal(t, dto.Money{Amount: "25.90", Currency: "BRL"}, output.Total)
+	assert.Len(t, output.Items, 2)
+	assert.Equal(t, dto.Money{Amount: "10.00", Currency: "BRL"}, output.Items[0].Total)
+
+	orders, err := NewListOrdersUseCase(database.NewOrderGateway(db)).Execute(dto.ListOrdersInput{})
+	assert.NoError(t, err)
+	assert.Len(t, orders, 1)
+	assert.Equal(t, output.ID, orders[0].ID)
+}
+
+func TestCreateOrderChargesTax(t *testing.T) {
+	db := newTestDB(t)
+	pen := newTestProduct(t, db, "PEN", "2.50", 10)
+	book := newTestProduct(t, db, "BOOK", "15.90", 3)
+
+	books, _ := entity.NewCategory("Livros", "", nil)
+	assert.NoError(t, database.NewCategoryGateway(db).Create(books))
+
+	book.CategoryID = &books.ID
+	assert.NoError(t, database.NewProductGateway(db).Update(book))
+
+	policy := newTestTaxPolicy(t,
+		entity.TaxRule{Region: "SP", Rate: "18"},
+		entity.TaxRule{Category: books.ID.String(), Exempt: true},
+	)
+	uc := NewCreateOrderUseCase(database.NewProductGateway(db), database.NewOrderGateway(db), policy)
+
+	output, err := uc.Execute(dto.CreateOrderInput{Region: "SP", Items: []dto.OrderItemInput{
+		{ProductID: pen.ID.String(), Quantity: 4},
+		{ProductID: book.ID.String(), Quantity: 1},
+	}})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "SP", output.Region)
+	assert.Equal(t, dto.Money{Amount: "25.90", Currency: "BRL"}, output.Subtotal)
+	assert.Equal(t, dto.Money{Amount: "1.80", Currency: "BRL"}, output.Tax)
+	assert.Equal(t, dto.Money{Amount: "27.70", Currency: "BRL"}, output.Total)
+	assert.Equal(t, "18.00", output.Items[0].TaxRate)
+	assert.Equal(t, "0.00", output.Items[1].TaxRate)
+
+	found, err := NewGetOrderUseCase(database.NewOrderGateway(db)).Execute(output.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, output.Total, found.Total)
+	assert.Equal(t, output.Items[0].Tax, found.Items[0].Tax)
+}
+
+func TestCreateOrderErrors(t *testing.T) {
+	db := newTestDB(t)
+	pen := newTestProduct(t, db, "PEN", "2.50", 1)
+	archived := newTestProduct(t, db, "OLD", "1.00", 5)
+	archived.Archive()
+	assert.NoError(t, database.NewProductGateway(db).Update(archived))
+
+	uc := NewCreateOrderUseCase(database.NewProductGateway(db), database.NewOrderGateway(db), newTestTaxPolicy(t))
+
+	_, err := uc.Execute(dto.CreateOrderInput{})
+	assert.ErrorIs(t, err, entity.ErrOrderHasNoItems)
+
+	_, err = uc.Execute(dto.CreateOrderInput{Items: []dto.OrderItemInput{{ProductID: "missing", Quantity: 1}}})
+	assert.ErrorIs(t, err, entity.ErrProductNotFound)
+
+	_, err = uc.Execute(dto.CreateOrderInput{Items: []dto.OrderItemInput{{ProductID: archived.ID.String(), Quantity: 1}}})
+	assert.ErrorIs(t, err, entity.ErrProductUnavailable)
+
+	_, err = uc.Execute(dto.CreateOrderInput{Items: []dto.OrderItemInput{{ProductID: pen.ID.String(), Quantity: 2}}})
+	assert.ErrorIs(t, err, entity.ErrInsufficientStock)
+
+	_, err = uc.Execute(dto.CreateOrderInput{Items: []dto.OrderItemInput{{ProductID: pen.ID.String(), Quantity: 0}}})
+	assert.ErrorIs(t, err, entity.ErrInvalidQuantity)
+}
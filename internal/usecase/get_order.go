@@ -0,0 +1,25 @@
+package usecase
+
+import (
+	"github.com/rgoncalvesrr/fullcycle-clean-arch/internal/dto"
+	"github.com/rgoncalvesrr/fullcycle-clean-arch/internal/infra/database"
+)
+
+type GetOrderUseCase struct {
+	OrderGateway database.OrderInterface
+}
+
+func NewGetOrderUseCase(orderDB database.OrderInterface) *GetOrderUseCase {
+	return &GetOrderUseCase{
+		OrderGateway: orderDB,
+	}
+}
+
+func (u *GetOrderUseCase) Execute(id string) (*dto.OrderOutput, error) {
+	order, err := u.OrderGateway.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewOrderOutput(order), nil
+}
@@ -0,0 +1,38 @@
+package usecase
+
+import (
+	"github.com/rgoncalvesrr/fullcycle-clean-arch/internal/dto"
+	"github.com/rgoncalvesrr/fullcycle-clean-arch/internal/infra/database"
+)
+
+type ListOrdersUseCase struct {
+	OrderGateway database.OrderInterface
+}
+
+func NewListOrdersUseCase(orderDB database.OrderInterface) *ListOrdersUseCase {
+	return &ListOrdersUseCase{
+		OrderGateway: orderDB,
+	}
+}
+
+func (u *ListOrdersUseCase) Execute(input dto.ListOrdersInput) ([]dto.OrderOutput, error) {
+	if input.Page < 1 {
+		input.Page = 1
+	}
+
+	if input.Limit < 1 {
+		input.Limit = 50
+	}
+
+	orders, err := u.OrderGateway.FindAll((input.Page-1)*input.Limit, input.Limit)
+	if err != nil {
+		return nil, err
+	}
+
+	output := make([]dto.OrderOutput, 0, len(orders))
+	for i := range orders {
+		output = append(output, *NewOrderOutput(&orders[i]))
+	}
+
+	return output, nil
+}
@@ -0,0 +1,55 @@
+package usecase
+
+import (
+	"github.com/rgoncalvesrr/fullcycle-clean-arch/internal/dto"
+	"github.com/rgoncalvesrr/fullcycle-clean-arch/internal/entity"
+)
+
+func NewOrderOutput(o *entity.Order) *dto.OrderOutput {
+	items := make([]dto.OrderItemOutput, 0, len(o.Items))
+
+	for _, item := range o.Items {
+		items = append(items, dto.OrderItemOutput{
+			ID:        item.ID.String(),
+			ProductID: item.ProductID.String(),
+			SKU:       item.SKU,
+			Name:      item.Name,
+			Quantity:  item.Quantity,
+			UnitPrice: newMoneyOutput(item.UnitPrice),
+			Total:     newMoneyOutput(item.Total),
+			TaxRate:   item.TaxRate,
+			Tax:       newMoneyOutput(item.Tax),
+		})
+	}
+
+	transitions := make([]dto.OrderTransitionOutput, 0, len(o.Transitions))
+
+	for _, t := range o.Transitions {
+		transitions = append(transitions, newOrderTransitionOutput(&t))
+	}
+
+	return &dto.OrderOutput{
+		ID:          o.ID.String(),
+		Items:       items,
+		Region:      o.Region,
+		Subtotal:    newMoneyOutput(o.Subtotal),
+		Tax:         newMoneyOutput(o.Tax),
+		Total:       newMoneyOutput(o.Total),
+		Status:      string(o.Status),
+		Transitions: transitions,
+		CreatedAt:   o.CreatedAt,
+	}
+}
+
+func newOrderTransitionOutput(t *entity.OrderTransition) dto.OrderTransitionOutput {
+	return dto.OrderTransitionOutput{
+		From:      string(t.From),
+		To:        string(t.To),
+		Actor:     t.Actor,
+		CreatedAt: t.CreatedAt,
+	}
+}
+
+func newMoneyOutput(m entity.Money) dto.Money {
+	return dto.Money{Amount: m.String(), Currency: m.Currency}
+}
@@ -0,0 +1,55 @@
+package usecase
+
+import (
+	"context"
+	"log"
+
+	"github.com/rgoncalvesrr/fullcycle-clean-arch/internal/dto"
+	"github.com/rgoncalvesrr/fullcycle-clean-arch/internal/entity"
+	"github.com/rgoncalvesrr/fullcycle-clean-arch/internal/event"
+	"github.com/rgoncalvesrr/fullcycle-clean-arch/internal/infra/database"
+	"github.com/rgoncalvesrr/fullcycle-clean-arch/pkg/events"
+)
+
+type TransitionOrderUseCase struct {
+	OrderGateway    database.OrderInterface
+	EventDispatcher events.IEventDispacher
+}
+
+func NewTransitionOrderUseCase(orderDB database.OrderInterface, dispatcher events.IEventDispacher) *TransitionOrderUseCase {
+	return &TransitionOrderUseCase{
+		OrderGateway:    orderDB,
+		EventDispatcher: dispatcher,
+	}
+}
+
+// Execute moves an order to another status and dispatches an
+// OrderStatusChanged event with the updated order once the change is
+// stored. ctx bounds the event handlers.
+func (u *TransitionOrderUseCase) Execute(ctx context.Context, input dto.TransitionOrderInput) (*dto.OrderOutput, error) {
+	order, err := u.OrderGateway.FindByID(input.OrderID)
+	if err != nil {
+		return nil, err
+	}
+
+	status := entity.OrderStatus(input.Status)
+
+	transition, err := order.TransitionTo(status, input.Actor)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = u.OrderGateway.Transition(order, transition); err != nil {
+		return nil, err
+	}
+
+	output := NewOrderOutput(order)
+
+	// The change is already stored, so a failing handler doesn't fail the
+	// transition.
+	if err = u.EventDispatcher.Dispatch(ctx, event.NewOrderStatusChanged(output.ID, status, output)); err != nil {
+		log.Printf("dispatching %s for order %s failed: %v", event.OrderStatusEventName(status), order.ID, err)
+	}
+
+	return output, nil
+}
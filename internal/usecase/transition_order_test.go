@@ -0,0 +1,59 @@
+package usecase
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/rgoncalvesrr/fullcycle-clean-arch/internal/dto"
+	"github.com/rgoncalvesrr/fullcycle-clean-arch/internal/entity"
+	"github.com/rgoncalvesrr/fullcycle-clean-arch/internal/infra/database"
+	"github.com/rgoncalvesrr/fullcycle-clean-arch/pkg/events"
+	"github.com/stretchr/testify/assert"
+)
+
+type recordingHandler struct {
+	mu     sync.Mutex
+	events []events.IEvent
+}
+
+func (h *recordingHandler) Handle(ctx context.Context, event events.IEvent) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.events = append(h.events, event)
+
+	return nil
+}
+
+func TestTransitionOrder(t *testing.T) {
+	db := newTestDB(t)
+	pen := newTestProduct(t, db, "PEN", "2.50", 10)
+
+	order, err := NewCreateOrderUseCase(database.NewProductGateway(db), database.NewOrderGateway(db), newTestTaxPolicy(t)).
+		Execute(dto.CreateOrderInput{Items: []dto.OrderItemInput{{ProductID: pen.ID.String(), Quantity: 2}}})
+	assert.NoError(t, err)
+	assert.Equal(t, "pending", order.Status)
+
+	handler := &recordingHandler{}
+	dispatcher := events.NewEventDispatcher()
+	dispatcher.Register("order.paid", handler)
+
+	uc := NewTransitionOrderUseCase(database.NewOrderGateway(db), dispatcher)
+
+	output, err := uc.Execute(context.Background(), dto.TransitionOrderInput{OrderID: order.ID, Status: "paid", Actor: "user-1"})
+	assert.NoError(t, err)
+	assert.Equal(t, "paid", output.Status)
+	assert.Len(t, output.Transitions, 1)
+	assert.Equal(t, "user-1", output.Transitions[0].Actor)
+
+	assert.Len(t, handler.events, 1)
+	assert.Equal(t, "order.paid", handler.events[0].GetName())
+	assert.Equal(t, output, handler.events[0].GetPayLoad())
+
+	_, err = uc.Execute(context.Background(), dto.TransitionOrderInput{OrderID: order.ID, Status: "paid", Actor: "user-1"})
+	assert.ErrorIs(t, err, entity.ErrInvalidOrderTransition)
+	assert.Len(t, handler.events, 1)
+
+	_, err = uc.Execute(context.Background(), dto.TransitionOrderInput{OrderID: "missing", Status: "paid"})
+	assert.Error(t, err)
+}
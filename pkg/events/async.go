@@ -0,0 +1,216 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"hash/fnv"
+	"log"
+	"strings"
+	"sync"
+)
+
+var (
+	ErrQueueFull           = errors.New("event queue is full")
+	ErrDispatcherClosed    = errors.New("event dispatcher is shut down")
+	ErrInvalidBackpressure = errors.New("invalid backpressure policy, use block, drop or error")
+)
+
+// BackpressurePolicy tells an async dispatcher what to do with an event when
+// the queue it belongs to is full.
+type BackpressurePolicy int
+
+const (
+	// BackpressureBlock waits for room in the queue, or for the dispatch
+	// context to be done.
+	BackpressureBlock BackpressurePolicy = iota
+	// BackpressureDrop discards the event, reporting ErrQueueFull to the
+	// error handler; Dispatch succeeds.
+	BackpressureDrop
+	// BackpressureError fails Dispatch with ErrQueueFull.
+	BackpressureError
+)
+
+// ParseBackpressurePolicy reads block, drop or error. Empty means block.
+func ParseBackpressurePolicy(s string) (BackpressurePolicy, error) {
+	switch strings.ToLower(s) {
+	case "", "block":
+		return BackpressureBlock, nil
+	case "drop":
+		return BackpressureDrop, nil
+	case "error":
+		return BackpressureError, nil
+	}
+
+	return 0, ErrInvalidBackpressure
+}
+
+// IKeyedEvent is implemented by events that must be handled in order with
+// the other events of the same key, e.g. the events of one order. Events
+// without a key, or with an empty one, are ordered by name.
+type IKeyedEvent interface {
+	IEvent
+	GetKey() string
+}
+
+type AsyncOptions struct {
+	// Workers is the number of events handled at the same time; at least 1.
+	Workers int
+	// QueueSize is how many events each worker holds before backpressure
+	// applies; at least 1.
+	QueueSize    int
+	Backpressure BackpressurePolicy
+	// ErrorHandler receives the errors of events handled in the
+	// background. By default they are logged.
+	ErrorHandler func(event IEvent, err error)
+}
+
+// NewAsyncEventDispatcher returns a dispatcher whose Dispatch queues events
+// and returns at once, leaving the handlers to a pool of workers. Each
+// worker has its own bounded queue and events are assigned to workers by
+// key, so events with the same key are handled one at a time in dispatch
+// order. Call Shutdown to handle the queued events before exiting.
+func NewAsyncEventDispatcher(opts AsyncOptions) *EventDispatcher {
+	ed := NewEventDispatcher()
+	ed.async = newAsyncQueue(ed, opts)
+
+	return ed
+}
+
+// Shutdown stops accepting events and waits for the queued ones to be
+// handled, or for ctx to be done. Dispatch fails with ErrDispatcherClosed
+// afterwards. It does nothing on a synchronous dispatcher.
+func (ed *EventDispatcher) Shutdown(ctx context.Context) error {
+	if ed.async == nil {
+		return nil
+	}
+
+	return ed.async.shutdown(ctx)
+}
+
+type queuedEvent struct {
+	ctx   context.Context
+	event IEvent
+}
+
+type asyncQueue struct {
+	dispatcher   *EventDispatcher
+	queues       []chan queuedEvent
+	backpressure BackpressurePolicy
+	onError      func(event IEvent, err error)
+
+	// mu guards closed against the queues being closed while events are
+	// sent to them.
+	mu      sync.RWMutex
+	closed  bool
+	workers sync.WaitGroup
+}
+
+func newAsyncQueue(ed *EventDispatcher, opts AsyncOptions) *asyncQueue {
+	opts.Workers = max(opts.Workers, 1)
+	opts.QueueSize = max(opts.QueueSize, 1)
+
+	if opts.ErrorHandler == nil {
+		opts.ErrorHandler = func(event IEvent, err error) {
+			log.Printf("handling event %s failed: %v", event.GetName(), err)
+		}
+	}
+
+	q := &asyncQueue{
+		dispatcher:   ed,
+		queues:       make([]chan queuedEvent, opts.Workers),
+		backpressure: opts.Backpressure,
+		onError:      opts.ErrorHandler,
+	}
+
+	for i := range q.queues {
+		q.queues[i] = make(chan queuedEvent, opts.QueueSize)
+
+		q.workers.Add(1)
+		go q.work(q.queues[i])
+	}
+
+	return q
+}
+
+func (q *asyncQueue) enqueue(ctx context.Context, event IEvent) error {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+
+	if q.closed {
+		return ErrDispatcherClosed
+	}
+
+	// Handlers outlive the request that raised the event, so they keep its
+	// values but not its cancellation.
+	item := queuedEvent{ctx: context.WithoutCancel(ctx), event: event}
+	queue := q.queues[q.worker(event)]
+
+	select {
+	case queue <- item:
+		return nil
+	default:
+	}
+
+	switch q.backpressure {
+	case BackpressureDrop:
+		q.onError(event, ErrQueueFull)
+		return nil
+	case BackpressureError:
+		return ErrQueueFull
+	}
+
+	select {
+	case queue <- item:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// worker picks the queue of an event from its key.
+func (q *asyncQueue) worker(event IEvent) int {
+	key := event.GetName()
+	if keyed, ok := event.(IKeyedEvent); ok && keyed.GetKey() != "" {
+		key = keyed.GetKey()
+	}
+
+	h := fnv.New32a()
+	h.Write([]byte(key))
+
+	return int(h.Sum32() % uint32(len(q.queues)))
+}
+
+func (q *asyncQueue) work(queue chan queuedEvent) {
+	defer q.workers.Done()
+
+	for item := range queue {
+		if err := q.dispatcher.dispatch(item.ctx, item.event); err != nil {
+			q.onError(item.event, err)
+		}
+	}
+}
+
+func (q *asyncQueue) shutdown(ctx context.Context) error {
+	q.mu.Lock()
+	if !q.closed {
+		q.closed = true
+
+		for _, queue := range q.queues {
+			close(queue)
+		}
+	}
+	q.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		q.workers.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
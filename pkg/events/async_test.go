@@ -0,0 +1,223 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type keyedTestEvent struct {
+	TestEvent
+	Key string
+}
+
+func (e *keyedTestEvent) GetKey() string {
+	return e.Key
+}
+
+// orderRecorder records the payloads it handles per event key.
+type orderRecorder struct {
+	mu   sync.Mutex
+	seen map[string][]int
+}
+
+func (h *orderRecorder) Handle(ctx context.Context, event IEvent) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	key := event.(*keyedTestEvent).Key
+	h.seen[key] = append(h.seen[key], event.GetPayLoad().(int))
+
+	return nil
+}
+
+// gateHandler blocks until its gate is closed.
+type gateHandler struct {
+	gate    chan struct{}
+	started chan struct{}
+	once    sync.Once
+}
+
+func newGateHandler() *gateHandler {
+	return &gateHandler{gate: make(chan struct{}), started: make(chan struct{})}
+}
+
+func (h *gateHandler) Handle(ctx context.Context, event IEvent) error {
+	h.once.Do(func() { close(h.started) })
+	<-h.gate
+	return nil
+}
+
+func TestAsyncDispatcher_KeepsOrderPerKey(t *testing.T) {
+	ed := NewAsyncEventDispatcher(AsyncOptions{Workers: 4, QueueSize: 8})
+
+	recorder := &orderRecorder{seen: make(map[string][]int)}
+	ed.Register("order.paid", recorder)
+
+	keys := []string{"a", "b", "c", "d", "e"}
+	for i := 0; i < 100; i++ {
+		for _, key := range keys {
+			event := &keyedTestEvent{TestEvent: TestEvent{Name: "order.paid", Payload: i}, Key: key}
+			assert.NoError(t, ed.Dispatch(context.Background(), event))
+		}
+	}
+
+	assert.NoError(t, ed.Shutdown(context.Background()))
+
+	for _, key := range keys {
+		assert.Len(t, recorder.seen[key], 100, key)
+
+		for i, payload := range recorder.seen[key] {
+			assert.Equal(t, i, payload, fmt.Sprintf("event %d of key %s", i, key))
+		}
+	}
+}
+
+func TestAsyncDispatcher_DispatchDoesNotWaitForHandlers(t *testing.T) {
+	ed := NewAsyncEventDispatcher(AsyncOptions{Workers: 1, QueueSize: 1})
+
+	handler := newGateHandler()
+	ed.Register("evento1", handler)
+
+	assert.NoError(t, ed.Dispatch(context.Background(), &TestEvent{Name: "evento1"}))
+	<-handler.started
+
+	close(handler.gate)
+	assert.NoError(t, ed.Shutdown(context.Background()))
+}
+
+func TestAsyncDispatcher_Backpressure(t *testing.T) {
+	event := &TestEvent{Name: "evento1"}
+
+	t.Run("error", func(t *testing.T) {
+		ed := NewAsyncEventDispatcher(AsyncOptions{Workers: 1, QueueSize: 1, Backpressure: BackpressureError})
+		handler := newGateHandler()
+		ed.Register(event.GetName(), handler)
+
+		assert.NoError(t, ed.Dispatch(context.Background(), event))
+		<-handler.started
+		assert.NoError(t, ed.Dispatch(context.Background(), event))
+		assert.ErrorIs(t, ed.Dispatch(context.Background(), event), ErrQueueFull)
+
+		close(handler.gate)
+		assert.NoError(t, ed.Shutdown(context.Background()))
+	})
+
+	t.Run("drop", func(t *testing.T) {
+		var dropped []error
+		ed := NewAsyncEventDispatcher(AsyncOptions{
+			Workers:      1,
+			QueueSize:    1,
+			Backpressure: BackpressureDrop,
+			ErrorHandler: func(event IEvent, err error) { dropped = append(dropped, err) },
+		})
+		handler := newGateHandler()
+		ed.Register(event.GetName(), handler)
+
+		assert.NoError(t, ed.Dispatch(context.Background(), event))
+		<-handler.started
+		assert.NoError(t, ed.Dispatch(context.Background(), event))
+		assert.NoError(t, ed.Dispatch(context.Background(), event))
+
+		close(handler.gate)
+		assert.NoError(t, ed.Shutdown(context.Background()))
+		assert.Equal(t, []error{ErrQueueFull}, dropped)
+	})
+
+	t.Run("block", func(t *testing.T) {
+		ed := NewAsyncEventDispatcher(AsyncOptions{Workers: 1, QueueSize: 1})
+		handler := newGateHandler()
+		ed.Register(event.GetName(), handler)
+
+		assert.NoError(t, ed.Dispatch(context.Background(), event))
+		<-handler.started
+		assert.NoError(t, ed.Dispatch(context.Background(), event))
+
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+		assert.ErrorIs(t, ed.Dispatch(ctx, event), context.DeadlineExceeded)
+
+		go func() {
+			time.Sleep(10 * time.Millisecond)
+			close(handler.gate)
+		}()
+		assert.NoError(t, ed.Dispatch(context.Background(), event))
+
+		assert.NoError(t, ed.Shutdown(context.Background()))
+	})
+}
+
+func TestAsyncDispatcher_ReportsHandlerErrors(t *testing.T) {
+	errHandler := errors.New("handler failed")
+
+	var mu sync.Mutex
+	var reported []error
+
+	ed := NewAsyncEventDispatcher(AsyncOptions{
+		Workers: 2,
+		ErrorHandler: func(event IEvent, err error) {
+			mu.Lock()
+			defer mu.Unlock()
+			reported = append(reported, err)
+		},
+	})
+	ed.Register("evento1", &failingHandler{err: errHandler})
+
+	assert.NoError(t, ed.Dispatch(context.Background(), &TestEvent{Name: "evento1"}))
+	assert.NoError(t, ed.Shutdown(context.Background()))
+
+	assert.Len(t, reported, 1)
+	assert.ErrorIs(t, reported[0], errHandler)
+}
+
+func TestAsyncDispatcher_Shutdown(t *testing.T) {
+	ed := NewAsyncEventDispatcher(AsyncOptions{Workers: 1, QueueSize: 4})
+
+	handler := newGateHandler()
+	ed.Register("evento1", handler)
+	assert.NoError(t, ed.Dispatch(context.Background(), &TestEvent{Name: "evento1"}))
+	<-handler.started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, ed.Shutdown(ctx), context.DeadlineExceeded)
+
+	assert.ErrorIs(t, ed.Dispatch(context.Background(), &TestEvent{Name: "evento1"}), ErrDispatcherClosed)
+
+	close(handler.gate)
+	assert.NoError(t, ed.Shutdown(context.Background()))
+}
+
+func TestAsyncDispatcher_HandlersKeepRunningAfterRequestEnds(t *testing.T) {
+	ed := NewAsyncEventDispatcher(AsyncOptions{Workers: 1})
+
+	counter := &countingHandler{}
+	ed.Register("evento1", counter)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	assert.NoError(t, ed.Dispatch(ctx, &TestEvent{Name: "evento1"}))
+	cancel()
+
+	assert.NoError(t, ed.Shutdown(context.Background()))
+	assert.Equal(t, 1, counter.calls)
+}
+
+func TestParseBackpressurePolicy(t *testing.T) {
+	for s, want := range map[string]BackpressurePolicy{"": BackpressureBlock, "block": BackpressureBlock, "DROP": BackpressureDrop, "error": BackpressureError} {
+		got, err := ParseBackpressurePolicy(s)
+		assert.NoError(t, err)
+		assert.Equal(t, want, got)
+	}
+
+	_, err := ParseBackpressurePolicy("wait")
+	assert.ErrorIs(t, err, ErrInvalidBackpressure)
+}
+
+func TestSyncDispatcher_Shutdown(t *testing.T) {
+	assert.NoError(t, NewEventDispatcher().Shutdown(context.Background()))
+}
@@ -0,0 +1,244 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/rgoncalvesrr/fullcycle-clean-arch/pkg/entity"
+)
+
+var (
+	ErrDeadLettered             = errors.New("event sent to the dead letter queue")
+	ErrDeadLetterNotFound       = errors.New("dead letter not found")
+	ErrDeadLetterHandlerUnknown = errors.New("dead letter handler is not registered")
+	ErrDeadLetterNameRequired   = errors.New("dead letter handler name is required")
+)
+
+// DeadLetter is an event a handler kept failing on after its retries.
+type DeadLetter struct {
+	ID       string
+	Handler  string
+	Event    IEvent
+	Error    string
+	Attempts int
+	FailedAt time.Time
+}
+
+// DeadLetterSink stores dead letters. Put replaces a letter with the same
+// ID, and Find fails with ErrDeadLetterNotFound for unknown IDs.
+type DeadLetterSink interface {
+	Put(ctx context.Context, letter *DeadLetter) error
+	Find(ctx context.Context, id string) (*DeadLetter, error)
+	FindAll(ctx context.Context, offset, limit int) ([]DeadLetter, error)
+	Delete(ctx context.Context, id string) error
+}
+
+// RawEvent is an event read back from storage, its payload still JSON.
+type RawEvent struct {
+	Name     string
+	Key      string
+	Payload  json.RawMessage
+	DateTime time.Time
+}
+
+func (e *RawEvent) GetName() string {
+	return e.Name
+}
+
+func (e *RawEvent) GetKey() string {
+	return e.Key
+}
+
+func (e *RawEvent) GetDateTime() time.Time {
+	return e.DateTime
+}
+
+func (e *RawEvent) GetPayLoad() any {
+	return e.Payload
+}
+
+// DeadLetterQueue sends the events handlers fail on after their retries to
+// a sink, and replays them to the same handler on request. Handlers are
+// known by name, which must be stable across restarts for letters stored in
+// a database to be replayed.
+type DeadLetterQueue struct {
+	Sink DeadLetterSink
+
+	mu       sync.RWMutex
+	handlers map[string]*retryHandler
+}
+
+func NewDeadLetterQueue(sink DeadLetterSink) *DeadLetterQueue {
+	return &DeadLetterQueue{
+		Sink:     sink,
+		handlers: make(map[string]*retryHandler),
+	}
+}
+
+// WithRetry is like the WithRetry function, but events still failing after
+// the retries are stored as dead letters of the handler called name.
+func (q *DeadLetterQueue) WithRetry(name string, handler IEventHandler, policy RetryPolicy) (IEventHandler, error) {
+	if name == "" {
+		return nil, ErrDeadLetterNameRequired
+	}
+
+	h := &retryHandler{name: name, handler: handler, policy: policy, queue: q}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if _, ok := q.handlers[name]; ok {
+		return nil, ErrHandlerAlreadyRegistered
+	}
+
+	q.handlers[name] = h
+
+	return h, nil
+}
+
+func (q *DeadLetterQueue) List(ctx context.Context, offset, limit int) ([]DeadLetter, error) {
+	return q.Sink.FindAll(ctx, offset, limit)
+}
+
+func (q *DeadLetterQueue) Get(ctx context.Context, id string) (*DeadLetter, error) {
+	return q.Sink.Find(ctx, id)
+}
+
+// Discard drops a dead letter without handling it.
+func (q *DeadLetterQueue) Discard(ctx context.Context, id string) error {
+	if _, err := q.Sink.Find(ctx, id); err != nil {
+		return err
+	}
+
+	return q.Sink.Delete(ctx, id)
+}
+
+// Replay hands a dead letter back to its handler, retrying it with the
+// handler's policy. The letter is removed if the handler succeeds and
+// updated with the new error and attempts otherwise.
+func (q *DeadLetterQueue) Replay(ctx context.Context, id string) error {
+	letter, err := q.Sink.Find(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	q.mu.RLock()
+	h, ok := q.handlers[letter.Handler]
+	q.mu.RUnlock()
+
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrDeadLetterHandlerUnknown, letter.Handler)
+	}
+
+	attempts, err := h.policy.run(ctx, func() error { return h.handler.Handle(ctx, letter.Event) })
+	if err == nil {
+		return q.Sink.Delete(ctx, id)
+	}
+
+	letter.Attempts += attempts
+	letter.Error = err.Error()
+	letter.FailedAt = time.Now()
+
+	if putErr := q.Sink.Put(context.WithoutCancel(ctx), letter); putErr != nil {
+		return errors.Join(err, putErr)
+	}
+
+	return err
+}
+
+func (q *DeadLetterQueue) deadLetter(ctx context.Context, name string, event IEvent, attempts int, err error) error {
+	letter := &DeadLetter{
+		ID:       entity.NewID().String(),
+		Handler:  name,
+		Event:    event,
+		Error:    err.Error(),
+		Attempts: attempts,
+		FailedAt: time.Now(),
+	}
+
+	// The dispatch may have been cancelled, which is no reason to lose the
+	// event.
+	if putErr := q.Sink.Put(context.WithoutCancel(ctx), letter); putErr != nil {
+		return errors.Join(err, putErr)
+	}
+
+	return fmt.Errorf("%w: %w", ErrDeadLettered, err)
+}
+
+// MemoryDeadLetterSink keeps dead letters in memory, in the order they
+// failed. They are lost on restart.
+type MemoryDeadLetterSink struct {
+	mu      sync.RWMutex
+	letters []DeadLetter
+}
+
+func NewMemoryDeadLetterSink() *MemoryDeadLetterSink {
+	return &MemoryDeadLetterSink{}
+}
+
+func (s *MemoryDeadLetterSink) Put(ctx context.Context, letter *DeadLetter) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if i := s.index(letter.ID); i >= 0 {
+		s.letters[i] = *letter
+		return nil
+	}
+
+	s.letters = append(s.letters, *letter)
+
+	return nil
+}
+
+func (s *MemoryDeadLetterSink) Find(ctx context.Context, id string) (*DeadLetter, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	i := s.index(id)
+	if i < 0 {
+		return nil, ErrDeadLetterNotFound
+	}
+
+	letter := s.letters[i]
+
+	return &letter, nil
+}
+
+func (s *MemoryDeadLetterSink) FindAll(ctx context.Context, offset, limit int) ([]DeadLetter, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	offset = min(max(offset, 0), len(s.letters))
+
+	end := len(s.letters)
+	if limit > 0 {
+		end = min(offset+limit, end)
+	}
+
+	return append([]DeadLetter(nil), s.letters[offset:end]...), nil
+}
+
+func (s *MemoryDeadLetterSink) Delete(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if i := s.index(id); i >= 0 {
+		s.letters = append(s.letters[:i:i], s.letters[i+1:]...)
+	}
+
+	return nil
+}
+
+func (s *MemoryDeadLetterSink) index(id string) int {
+	for i := range s.letters {
+		if s.letters[i].ID == id {
+			return i
+		}
+	}
+
+	return -1
+}
@@ -0,0 +1,92 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeadLetterQueue(t *testing.T) {
+	errFlaky := errors.New("flaky")
+	event := &TestEvent{Name: "evento1", Payload: "Conteúdo"}
+
+	queue := NewDeadLetterQueue(NewMemoryDeadLetterSink())
+
+	h := &flakyHandler{failures: 4, err: errFlaky}
+	retrying, err := queue.WithRetry("audit", h, RetryPolicy{MaxAttempts: 2, InitialBackoff: time.Millisecond})
+	assert.NoError(t, err)
+
+	_, err = queue.WithRetry("audit", h, RetryPolicy{})
+	assert.ErrorIs(t, err, ErrHandlerAlreadyRegistered)
+
+	ed := NewEventDispatcher()
+	assert.NoError(t, ed.Register(event.GetName(), retrying))
+
+	err = ed.Dispatch(context.Background(), event)
+	assert.ErrorIs(t, err, ErrDeadLettered)
+	assert.ErrorIs(t, err, errFlaky)
+
+	letters, err := queue.List(context.Background(), 0, 10)
+	assert.NoError(t, err)
+	assert.Len(t, letters, 1)
+	assert.Equal(t, "audit", letters[0].Handler)
+	assert.Equal(t, 2, letters[0].Attempts)
+	assert.Equal(t, "flaky", letters[0].Error)
+	assert.Equal(t, event, letters[0].Event)
+
+	id := letters[0].ID
+
+	// Still failing: the letter stays, with the new attempts.
+	assert.ErrorIs(t, queue.Replay(context.Background(), id), errFlaky)
+
+	letter, err := queue.Get(context.Background(), id)
+	assert.NoError(t, err)
+	assert.Equal(t, 4, letter.Attempts)
+
+	assert.NoError(t, queue.Replay(context.Background(), id))
+	assert.Equal(t, 5, h.calls)
+
+	_, err = queue.Get(context.Background(), id)
+	assert.ErrorIs(t, err, ErrDeadLetterNotFound)
+}
+
+func TestDeadLetterQueue_ReplayUnknownHandler(t *testing.T) {
+	sink := NewMemoryDeadLetterSink()
+	sink.Put(context.Background(), &DeadLetter{ID: "1", Handler: "gone", Event: &TestEvent{Name: "evento1"}})
+
+	queue := NewDeadLetterQueue(sink)
+
+	assert.ErrorIs(t, queue.Replay(context.Background(), "1"), ErrDeadLetterHandlerUnknown)
+	assert.ErrorIs(t, queue.Replay(context.Background(), "2"), ErrDeadLetterNotFound)
+
+	assert.NoError(t, queue.Discard(context.Background(), "1"))
+	assert.ErrorIs(t, queue.Discard(context.Background(), "1"), ErrDeadLetterNotFound)
+}
+
+func TestMemoryDeadLetterSink(t *testing.T) {
+	ctx := context.Background()
+	sink := NewMemoryDeadLetterSink()
+
+	for _, id := range []string{"1", "2", "3"} {
+		assert.NoError(t, sink.Put(ctx, &DeadLetter{ID: id, Attempts: 1}))
+	}
+
+	assert.NoError(t, sink.Put(ctx, &DeadLetter{ID: "2", Attempts: 2}))
+
+	letters, _ := sink.FindAll(ctx, 1, 1)
+	assert.Len(t, letters, 1)
+	assert.Equal(t, "2", letters[0].ID)
+	assert.Equal(t, 2, letters[0].Attempts)
+
+	letters, _ = sink.FindAll(ctx, 5, 10)
+	assert.Empty(t, letters)
+
+	assert.NoError(t, sink.Delete(ctx, "1"))
+
+	letters, _ = sink.FindAll(ctx, 0, 0)
+	assert.Len(t, letters, 2)
+	assert.Equal(t, "2", letters[0].ID)
+}
@@ -1,73 +1,353 @@
 package events
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
 	"sync"
+	"unsafe"
 )
 
 var (
 	ErrHandlerAlreadyRegistered = errors.New("handler already registered")
+	ErrHandlerPanicked          = errors.New("event handler panicked")
 )
 
+// EventDispatcher is safe for concurrent use. Dispatch works on a snapshot
+// of the handlers, so handlers may register or remove handlers, themselves
+// included, while an event is being dispatched; the change applies from the
+// next dispatch on.
+//
+// Event names are made of segments separated by dots, e.g. order.paid, and
+// handlers may be registered for a pattern instead of a name:
+//
+//   - * matches exactly one segment: product.* matches product.created but
+//     neither product nor product.price.changed;
+//   - # matches zero or more segments: order.# matches order, order.paid and
+//     order.item.added;
+//   - * on its own matches every event, like #.
+//
+// Wildcards must be whole segments, so product.cre* is invalid. Dispatch
+// looks the patterns up in a trie, so its cost doesn't grow with their
+// number, and a handler matched by several registrations runs once per
+// event.
+//
+// By default Dispatch runs the handlers before returning; a dispatcher made
+// with NewAsyncEventDispatcher queues events for a pool of workers instead.
 type EventDispatcher struct {
+	mu       sync.RWMutex
 	handlers map[string][]IEventHandler
+	// ids identifies each registration, at the same index as its handler,
+	// since handlers may not be comparable and so can't be map keys.
+	ids      map[string][]registrationID
+	nextID   uint64
+	patterns *patternNode
+	async    *asyncQueue
+
+	middlewares             []Middleware
+	subscriptionMiddlewares map[uint64][]Middleware
 }
 
 func NewEventDispatcher() *EventDispatcher {
 	return &EventDispatcher{
 		handlers: make(map[string][]IEventHandler),
+		ids:      make(map[string][]registrationID),
+		patterns: newPatternNode(),
+
+		subscriptionMiddlewares: make(map[uint64][]Middleware),
 	}
 }
 
+// Register subscribes handler to an event name or pattern. It fails with
+// ErrInvalidEventPattern for malformed patterns.
 func (ed *EventDispatcher) Register(eventName string, handler IEventHandler) error {
-	if ed.Has(eventName, handler) {
+	return ed.register(eventName, handler, nil)
+}
+
+func (ed *EventDispatcher) register(eventName string, handler IEventHandler, middlewares []Middleware) error {
+	var segments []string
+
+	if isPattern(eventName) {
+		var err error
+		if segments, err = patternSegments(eventName); err != nil {
+			return err
+		}
+	}
+
+	ed.mu.Lock()
+	defer ed.mu.Unlock()
+
+	if ed.has(eventName, handler) {
 		return ErrHandlerAlreadyRegistered
 	}
 
+	if segments != nil && len(ed.handlers[eventName]) == 0 {
+		ed.patterns.add(eventName, segments)
+	}
+
+	ed.nextID++
+	id := registrationID{subscription: ed.nextID, handler: ed.handlerID(handler)}
+
 	ed.handlers[eventName] = append(ed.handlers[eventName], handler)
+	ed.ids[eventName] = append(ed.ids[eventName], id)
+
+	if len(middlewares) > 0 {
+		ed.subscriptionMiddlewares[id.subscription] = middlewares
+	}
 
 	return nil
 }
 
-func (ed *EventDispatcher) Dispatch(event IEvent) error {
-	if handlers, ok := ed.handlers[event.GetName()]; ok {
-		wg := &sync.WaitGroup{}
-		for _, handler := range handlers {
-			wg.Add(1)
-			go handler.Handle(event, wg)
+// registrationID identifies a registration, whose middlewares are kept under
+// subscription, and its handler, shared by the registrations of the same
+// handler under other names so that it runs once per event.
+type registrationID struct {
+	subscription uint64
+	handler      uint64
+}
+
+// handlerID is the handler ID of an existing registration of handler, or
+// else a new one. It's called with ed.mu held, after nextID was taken.
+func (ed *EventDispatcher) handlerID(handler IEventHandler) uint64 {
+	for eventName, handlers := range ed.handlers {
+		for i, h := range handlers {
+			if sameInstance(h, handler) {
+				return ed.ids[eventName][i].handler
+			}
 		}
+	}
+
+	return ed.nextID
+}
+
+// Dispatch runs the handlers of event concurrently and waits for them. It
+// returns the errors of the failed handlers joined with errors.Join, a
+// panicking handler failing with ErrHandlerPanicked. If ctx is done first,
+// Dispatch returns ctx.Err() without waiting for the handlers, which get the
+// same ctx and should stop on their own.
+//
+// An async dispatcher queues the event and returns; see
+// NewAsyncEventDispatcher.
+func (ed *EventDispatcher) Dispatch(ctx context.Context, event IEvent) error {
+	if ed.async != nil {
+		return ed.async.enqueue(ctx, event)
+	}
+
+	return ed.dispatch(ctx, event)
+}
+
+func (ed *EventDispatcher) dispatch(ctx context.Context, event IEvent) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	handlers := ed.snapshot(event.GetName())
+	if len(handlers) == 0 {
+		return nil
+	}
+
+	// Buffered, so handlers finishing after Dispatch gave up don't block.
+	results := make(chan error, len(handlers))
+
+	wg := &sync.WaitGroup{}
+	for _, handler := range handlers {
+		wg.Add(1)
+		go func(handler IEventHandler) {
+			defer wg.Done()
+			results <- callHandler(func() error { return handler.Handle(ctx, event) })
+		}(handler)
+	}
+
+	done := make(chan struct{})
+	go func() {
 		wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		return ctx.Err()
 	}
 
-	return nil
+	close(results)
+
+	var errs []error
+	for err := range results {
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
 }
 
+// Remove and Has take the name or pattern the handler was registered with:
+// Has("order.*", h) is false for a handler registered for order.paid.
+// Handlers of uncomparable types, such as func types or structs with func
+// fields, can be registered but are never found again; use a pointer to
+// remove them later.
 func (ed *EventDispatcher) Remove(eventName string, handler IEventHandler) error {
-	if _, ok := ed.handlers[eventName]; ok {
-		for i, h := range ed.handlers[eventName] {
-			if h == handler {
-				ed.handlers[eventName] = append(ed.handlers[eventName][:i], ed.handlers[eventName][i+1:]...)
-				return nil
+	ed.mu.Lock()
+	defer ed.mu.Unlock()
+
+	for i, h := range ed.handlers[eventName] {
+		if !sameHandler(h, handler) {
+			continue
+		}
+
+		id := ed.ids[eventName][i].subscription
+
+		// Build new slices rather than shifting in place, so snapshots
+		// taken by running dispatches aren't altered.
+		handlers := make([]IEventHandler, 0, len(ed.handlers[eventName])-1)
+		handlers = append(handlers, ed.handlers[eventName][:i]...)
+		handlers = append(handlers, ed.handlers[eventName][i+1:]...)
+
+		ids := make([]registrationID, 0, len(handlers))
+		ids = append(ids, ed.ids[eventName][:i]...)
+		ids = append(ids, ed.ids[eventName][i+1:]...)
+
+		if len(handlers) == 0 {
+			delete(ed.handlers, eventName)
+			delete(ed.ids, eventName)
+
+			if isPattern(eventName) {
+				segments, _ := patternSegments(eventName)
+				ed.patterns.remove(eventName, segments)
 			}
+		} else {
+			ed.handlers[eventName] = handlers
+			ed.ids[eventName] = ids
 		}
+
+		delete(ed.subscriptionMiddlewares, id)
+
+		return nil
 	}
 
 	return nil
 }
 
 func (ed *EventDispatcher) Has(eventName string, handler IEventHandler) bool {
-	if _, ok := ed.handlers[eventName]; ok {
-		for _, h := range ed.handlers[eventName] {
-			if h == handler {
-				return true
-			}
-		}
-	}
+	ed.mu.RLock()
+	defer ed.mu.RUnlock()
 
-	return false
+	return ed.has(eventName, handler)
 }
 
+// Clear removes every handler. The middlewares given to Use stay.
 func (ed *EventDispatcher) Clear() error {
+	ed.mu.Lock()
+	defer ed.mu.Unlock()
+
 	ed.handlers = make(map[string][]IEventHandler)
+	ed.ids = make(map[string][]registrationID)
+	ed.patterns = newPatternNode()
+	ed.subscriptionMiddlewares = make(map[uint64][]Middleware)
 	return nil
 }
+
+func (ed *EventDispatcher) has(eventName string, handler IEventHandler) bool {
+	for _, h := range ed.handlers[eventName] {
+		if sameHandler(h, handler) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// sameHandler compares handlers without panicking on those of uncomparable
+// types, such as structs with func fields, which are never the same.
+func sameHandler(a, b IEventHandler) (same bool) {
+	defer func() {
+		if recover() != nil {
+			same = false
+		}
+	}()
+
+	return a == b
+}
+
+// sameInstance is sameHandler extended to handlers of uncomparable types,
+// which are the same when they are copies of one value: same type and same
+// bytes, so slices share their array and funcs their code and closure.
+func sameInstance(a, b IEventHandler) bool {
+	if sameHandler(a, b) {
+		return true
+	}
+
+	t := reflect.TypeOf(a)
+	if t != reflect.TypeOf(b) || t.Comparable() {
+		return false
+	}
+
+	return string(valueBytes(a)) == string(valueBytes(b))
+}
+
+// valueBytes is the memory of a copy of h's value.
+func valueBytes(h IEventHandler) []byte {
+	v := reflect.New(reflect.TypeOf(h))
+	v.Elem().Set(reflect.ValueOf(h))
+
+	return unsafe.Slice((*byte)(v.UnsafePointer()), v.Elem().Type().Size())
+}
+
+// callHandler runs fn, turning a panic into an error.
+func callHandler(fn func() error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%w: %v", ErrHandlerPanicked, r)
+		}
+	}()
+
+	return fn()
+}
+
+// snapshot copies the handlers of an event, those of its name first and
+// then those of the matching patterns, wrapped in their middlewares, so they
+// can be called without holding the lock.
+func (ed *EventDispatcher) snapshot(eventName string) []IEventHandler {
+	ed.mu.RLock()
+	defer ed.mu.RUnlock()
+
+	var handlers []IEventHandler
+	seen := make(map[uint64]struct{})
+
+	add := func(registeredAs string) {
+		for i, h := range ed.handlers[registeredAs] {
+			id := ed.ids[registeredAs][i]
+			if _, ok := seen[id.handler]; ok {
+				continue
+			}
+
+			seen[id.handler] = struct{}{}
+			handlers = append(handlers, ed.chain(id.subscription, h))
+		}
+	}
+
+	add(eventName)
+
+	if len(ed.patterns.children) == 0 {
+		return handlers
+	}
+
+	found := make(map[string]struct{})
+	ed.patterns.match(strings.Split(eventName, "."), found)
+
+	patterns := make([]string, 0, len(found))
+	for p := range found {
+		patterns = append(patterns, p)
+	}
+	sort.Strings(patterns)
+
+	for _, p := range patterns {
+		add(p)
+	}
+
+	return handlers
+}
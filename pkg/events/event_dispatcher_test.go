@@ -1,6 +1,8 @@
 package events
 
 import (
+	"context"
+	"errors"
 	"log"
 	"sync"
 	"testing"
@@ -32,8 +34,9 @@ type TestEventHandler struct {
 	ID int
 }
 
-func (eh *TestEventHandler) Handle(event IEvent, wg *sync.WaitGroup) {
+func (eh *TestEventHandler) Handle(ctx context.Context, event IEvent) error {
 	log.Println("Evento disparado. Evento: ", event.GetName())
+	return nil
 }
 
 type EventDispatcherTestSuite struct {
@@ -136,9 +139,9 @@ type MockHandler struct {
 	mock.Mock
 }
 
-func (m *MockHandler) Handle(event IEvent, wg *sync.WaitGroup) {
-	defer wg.Done()
+func (m *MockHandler) Handle(ctx context.Context, event IEvent) error {
 	m.Called(event)
+	return nil
 }
 
 func (suite *EventDispatcherTestSuite) TestEventDispatcher_Dispatch() {
@@ -150,7 +153,7 @@ func (suite *EventDispatcherTestSuite) TestEventDispatcher_Dispatch() {
 
 	suite.eventDispatcher.Register(suite.event.GetName(), eh)
 	suite.eventDispatcher.Register(suite.event.GetName(), eh2)
-	suite.eventDispatcher.Dispatch(&suite.event)
+	suite.eventDispatcher.Dispatch(context.Background(), &suite.event)
 
 	eh.AssertExpectations(suite.T())
 	eh.AssertNumberOfCalls(suite.T(), "Handle", 1)
@@ -185,3 +188,205 @@ func (suite *EventDispatcherTestSuite) TestEventDispatcher_Remove() {
 func TestSuite(t *testing.T) {
 	suite.Run(t, new(EventDispatcherTestSuite))
 }
+
+type countingHandler struct {
+	mu    sync.Mutex
+	calls int
+}
+
+func (h *countingHandler) Handle(ctx context.Context, event IEvent) error {
+	h.mu.Lock()
+	h.calls++
+	h.mu.Unlock()
+
+	return nil
+}
+
+// Run with -race: registering, removing and dispatching concurrently must
+// not race on the handlers map.
+func TestEventDispatcher_ConcurrentUse(t *testing.T) {
+	ed := NewEventDispatcher()
+	event := &TestEvent{Name: "evento1"}
+
+	const workers = 8
+	const rounds = 200
+
+	var wg sync.WaitGroup
+
+	for w := 0; w < workers; w++ {
+		wg.Add(3)
+
+		go func() {
+			defer wg.Done()
+
+			for i := 0; i < rounds; i++ {
+				h := &countingHandler{}
+				ed.Register(event.GetName(), h)
+				ed.Has(event.GetName(), h)
+				ed.Remove(event.GetName(), h)
+			}
+		}()
+
+		go func() {
+			defer wg.Done()
+
+			for i := 0; i < rounds; i++ {
+				assert.NoError(t, ed.Dispatch(context.Background(), event))
+			}
+		}()
+
+		go func() {
+			defer wg.Done()
+
+			for i := 0; i < rounds/10; i++ {
+				ed.Clear()
+			}
+		}()
+	}
+
+	wg.Wait()
+}
+
+func TestEventDispatcher_ConcurrentDispatchReachesEveryHandler(t *testing.T) {
+	ed := NewEventDispatcher()
+	event := &TestEvent{Name: "evento1"}
+
+	handlers := make([]*countingHandler, 10)
+	for i := range handlers {
+		handlers[i] = &countingHandler{}
+		assert.NoError(t, ed.Register(event.GetName(), handlers[i]))
+	}
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+			ed.Dispatch(context.Background(), event)
+		}()
+	}
+
+	wg.Wait()
+
+	for _, h := range handlers {
+		assert.Equal(t, 50, h.calls)
+	}
+}
+
+// selfRemovingHandler unregisters itself the first time it runs.
+type selfRemovingHandler struct {
+	ed    *EventDispatcher
+	calls int
+}
+
+func (h *selfRemovingHandler) Handle(ctx context.Context, event IEvent) error {
+	h.calls++
+	return h.ed.Remove(event.GetName(), h)
+}
+
+func TestEventDispatcher_HandlerRemovesItself(t *testing.T) {
+	ed := NewEventDispatcher()
+	event := &TestEvent{Name: "evento1"}
+
+	self := &selfRemovingHandler{ed: ed}
+	other := &countingHandler{}
+
+	assert.NoError(t, ed.Register(event.GetName(), self))
+	assert.NoError(t, ed.Register(event.GetName(), other))
+
+	assert.NoError(t, ed.Dispatch(context.Background(), event))
+	assert.NoError(t, ed.Dispatch(context.Background(), event))
+
+	assert.Equal(t, 1, self.calls)
+	assert.Equal(t, 2, other.calls)
+	assert.False(t, ed.Has(event.GetName(), self))
+	assert.True(t, ed.Has(event.GetName(), other))
+}
+
+type failingHandler struct {
+	err error
+}
+
+func (h *failingHandler) Handle(ctx context.Context, event IEvent) error {
+	return h.err
+}
+
+type panickingHandler struct{}
+
+func (h *panickingHandler) Handle(ctx context.Context, event IEvent) error {
+	panic("boom")
+}
+
+func TestEventDispatcher_DispatchJoinsHandlerErrors(t *testing.T) {
+	ed := NewEventDispatcher()
+	event := &TestEvent{Name: "evento1"}
+
+	errFirst := errors.New("first")
+	errSecond := errors.New("second")
+	ok := &countingHandler{}
+
+	ed.Register(event.GetName(), &failingHandler{err: errFirst})
+	ed.Register(event.GetName(), ok)
+	ed.Register(event.GetName(), &failingHandler{err: errSecond})
+	ed.Register(event.GetName(), &panickingHandler{})
+
+	err := ed.Dispatch(context.Background(), event)
+
+	assert.ErrorIs(t, err, errFirst)
+	assert.ErrorIs(t, err, errSecond)
+	assert.ErrorIs(t, err, ErrHandlerPanicked)
+	assert.Contains(t, err.Error(), "boom")
+	assert.Equal(t, 1, ok.calls)
+}
+
+type blockingHandler struct{}
+
+func (h *blockingHandler) Handle(ctx context.Context, event IEvent) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func TestEventDispatcher_DispatchHonorsContext(t *testing.T) {
+	ed := NewEventDispatcher()
+	event := &TestEvent{Name: "evento1"}
+
+	ed.Register(event.GetName(), &blockingHandler{})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	assert.ErrorIs(t, ed.Dispatch(ctx, event), context.DeadlineExceeded)
+
+	counter := &countingHandler{}
+	ed.Register("evento2", counter)
+
+	assert.ErrorIs(t, ed.Dispatch(ctx, &TestEvent{Name: "evento2"}), context.DeadlineExceeded)
+	assert.Equal(t, 0, counter.calls)
+}
+
+type legacyTestHandler struct {
+	calls int
+}
+
+func (h *legacyTestHandler) Handle(event IEvent, wg *sync.WaitGroup) {
+	defer wg.Done()
+	h.calls++
+}
+
+func TestEventDispatcher_LegacyHandler(t *testing.T) {
+	ed := NewEventDispatcher()
+	event := &TestEvent{Name: "evento1"}
+	legacy := &legacyTestHandler{}
+
+	assert.NoError(t, ed.Register(event.GetName(), AdaptLegacyHandler(legacy)))
+	assert.Equal(t, ErrHandlerAlreadyRegistered, ed.Register(event.GetName(), AdaptLegacyHandler(legacy)))
+
+	assert.NoError(t, ed.Dispatch(context.Background(), event))
+	assert.Equal(t, 1, legacy.calls)
+
+	assert.True(t, ed.Has(event.GetName(), AdaptLegacyHandler(legacy)))
+	assert.NoError(t, ed.Remove(event.GetName(), AdaptLegacyHandler(legacy)))
+	assert.False(t, ed.Has(event.GetName(), AdaptLegacyHandler(legacy)))
+}
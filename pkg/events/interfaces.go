@@ -1,6 +1,7 @@
 package events
 
 import (
+	"context"
 	"sync"
 	"time"
 )
@@ -11,13 +12,22 @@ type IEvent interface {
 	GetPayLoad() any
 }
 
+// IEventHandler handles a dispatched event. Handlers should stop when ctx is
+// done; the error they return is reported by Dispatch.
 type IEventHandler interface {
+	Handle(ctx context.Context, event IEvent) error
+}
+
+// ILegacyEventHandler is the former handler contract, which had to call
+// wg.Done when finished and couldn't report errors. Wrap these handlers with
+// AdaptLegacyHandler.
+type ILegacyEventHandler interface {
 	Handle(event IEvent, wg *sync.WaitGroup)
 }
 
 type IEventDispacher interface {
 	Register(eventName string, handler IEventHandler) error
-	Dispatch(event IEvent) error
+	Dispatch(ctx context.Context, event IEvent) error
 	Remove(eventName string, handler IEventHandler) error
 	Has(eventName string, handler IEventHandler) bool
 	Clear() error
@@ -0,0 +1,46 @@
+package events
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// legacyHandler is a value type, so adapting the same handler twice gives
+// equal handlers and Has and Remove keep working with the adapted handler.
+type legacyHandler struct {
+	handler ILegacyEventHandler
+}
+
+// AdaptLegacyHandler turns a handler written against ILegacyEventHandler into
+// an IEventHandler. The adapted handler waits for wg.Done, or for ctx to be
+// done; it only fails if ctx is done first or the handler panics.
+func AdaptLegacyHandler(handler ILegacyEventHandler) IEventHandler {
+	return legacyHandler{handler: handler}
+}
+
+func (h legacyHandler) Handle(ctx context.Context, event IEvent) error {
+	wg := &sync.WaitGroup{}
+	wg.Add(1)
+
+	done := make(chan error, 1)
+
+	go func() {
+		done <- callHandler(func() error {
+			h.handler.Handle(event, wg)
+			wg.Wait()
+			return nil
+		})
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (h legacyHandler) HandlerName() string {
+	return fmt.Sprintf("%T", h.handler)
+}
@@ -0,0 +1,269 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"runtime/debug"
+	"sort"
+	"sync"
+	"time"
+)
+
+var ErrHandlerTimedOut = errors.New("event handler timed out")
+
+// Middleware wraps a handler to add behaviour around it, such as logging or
+// timing. Middlewares are applied when events are dispatched, so the
+// registered handler is what Has and Remove compare.
+type Middleware func(next IEventHandler) IEventHandler
+
+// Use adds middlewares wrapping every handler, around those given to
+// RegisterWith. The first middleware is the outermost one, so
+//
+//	ed.Use(Logging(nil), Recover(nil))
+//
+// logs the error of a handler that panicked. Use applies from the next
+// dispatch on.
+func (ed *EventDispatcher) Use(middlewares ...Middleware) {
+	ed.mu.Lock()
+	defer ed.mu.Unlock()
+
+	ed.middlewares = append(ed.middlewares[:len(ed.middlewares):len(ed.middlewares)], middlewares...)
+}
+
+// RegisterWith is like Register, with middlewares wrapping only this
+// subscription of the handler.
+func (ed *EventDispatcher) RegisterWith(eventName string, handler IEventHandler, middlewares ...Middleware) error {
+	return ed.register(eventName, handler, middlewares)
+}
+
+// chain wraps the handler of registration id with its subscription
+// middlewares inside the global ones. ed.mu must be held.
+func (ed *EventDispatcher) chain(id uint64, handler IEventHandler) IEventHandler {
+	local := ed.subscriptionMiddlewares[id]
+
+	if len(local) == 0 && len(ed.middlewares) == 0 {
+		return handler
+	}
+
+	name := handlerName(handler)
+	wrapped := handler
+
+	for _, middlewares := range [][]Middleware{local, ed.middlewares} {
+		for i := len(middlewares) - 1; i >= 0; i-- {
+			wrapped = named(name, middlewares[i](wrapped))
+		}
+	}
+
+	return wrapped
+}
+
+// namedHandler is implemented by handlers that tell their name to logs and
+// metrics; other handlers are known by their type.
+type namedHandler interface {
+	HandlerName() string
+}
+
+func handlerName(handler IEventHandler) string {
+	if h, ok := handler.(namedHandler); ok {
+		return h.HandlerName()
+	}
+
+	return fmt.Sprintf("%T", handler)
+}
+
+// middlewareHandler is the handler the built-in middlewares return.
+type middlewareHandler struct {
+	name   string
+	handle func(ctx context.Context, event IEvent) error
+}
+
+func (h *middlewareHandler) Handle(ctx context.Context, event IEvent) error {
+	return h.handle(ctx, event)
+}
+
+func (h *middlewareHandler) HandlerName() string {
+	return h.name
+}
+
+// named keeps the name of the registered handler on a handler returned by a
+// middleware, so middlewares further out still report it.
+func named(name string, handler IEventHandler) IEventHandler {
+	if h, ok := handler.(*middlewareHandler); ok {
+		return h
+	}
+
+	return &middlewareHandler{name: name, handle: handler.Handle}
+}
+
+func wrap(next IEventHandler, handle func(ctx context.Context, event IEvent) error) IEventHandler {
+	return &middlewareHandler{name: handlerName(next), handle: handle}
+}
+
+// Logging logs each handled event with its handler and duration, at debug
+// level if the handler succeeded and at error level otherwise. A nil logger
+// means slog.Default().
+func Logging(logger *slog.Logger) Middleware {
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	return func(next IEventHandler) IEventHandler {
+		return wrap(next, func(ctx context.Context, event IEvent) error {
+			start := time.Now()
+			err := next.Handle(ctx, event)
+
+			attrs := []slog.Attr{
+				slog.String("event", event.GetName()),
+				slog.String("handler", handlerName(next)),
+				slog.Duration("duration", time.Since(start)),
+			}
+
+			if err != nil {
+				logger.LogAttrs(ctx, slog.LevelError, "event handler failed", append(attrs, slog.Any("error", err))...)
+			} else {
+				logger.LogAttrs(ctx, slog.LevelDebug, "event handled", attrs...)
+			}
+
+			return err
+		})
+	}
+}
+
+// Recover turns a panic in the handler into an error wrapping
+// ErrHandlerPanicked, logging the stack. The dispatcher recovers panics
+// anyway; Recover lets the middlewares outside it see the error. A nil
+// logger means slog.Default().
+func Recover(logger *slog.Logger) Middleware {
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	return func(next IEventHandler) IEventHandler {
+		return wrap(next, func(ctx context.Context, event IEvent) (err error) {
+			defer func() {
+				if r := recover(); r != nil {
+					logger.LogAttrs(ctx, slog.LevelError, "event handler panicked",
+						slog.String("event", event.GetName()),
+						slog.String("handler", handlerName(next)),
+						slog.Any("panic", r),
+						slog.String("stack", string(debug.Stack())),
+					)
+
+					err = fmt.Errorf("%w: %v", ErrHandlerPanicked, r)
+				}
+			}()
+
+			return next.Handle(ctx, event)
+		})
+	}
+}
+
+// Timeout cancels the handler's context after d and fails with
+// ErrHandlerTimedOut if the handler hasn't returned by then. A handler that
+// ignores its context keeps running in the background.
+func Timeout(d time.Duration) Middleware {
+	return func(next IEventHandler) IEventHandler {
+		return wrap(next, func(ctx context.Context, event IEvent) error {
+			timeoutCtx, cancel := context.WithTimeout(ctx, d)
+			defer cancel()
+
+			done := make(chan error, 1)
+			go func() {
+				done <- callHandler(func() error { return next.Handle(timeoutCtx, event) })
+			}()
+
+			select {
+			case err := <-done:
+				return err
+			case <-timeoutCtx.Done():
+				if err := ctx.Err(); err != nil {
+					return err
+				}
+
+				return fmt.Errorf("%w after %s", ErrHandlerTimedOut, d)
+			}
+		})
+	}
+}
+
+// MetricsRecorder receives the duration and outcome of each handled event.
+type MetricsRecorder interface {
+	Observe(eventName, handler string, duration time.Duration, err error)
+}
+
+// Metrics reports how long each handler takes to recorder.
+func Metrics(recorder MetricsRecorder) Middleware {
+	return func(next IEventHandler) IEventHandler {
+		return wrap(next, func(ctx context.Context, event IEvent) error {
+			start := time.Now()
+			err := next.Handle(ctx, event)
+
+			recorder.Observe(event.GetName(), handlerName(next), time.Since(start), err)
+
+			return err
+		})
+	}
+}
+
+// HandlerStats sums up the events a handler handled for an event name.
+type HandlerStats struct {
+	Event   string
+	Handler string
+	Count   int64
+	Errors  int64
+	Total   time.Duration
+	Max     time.Duration
+}
+
+// HandlerMetrics is a MetricsRecorder keeping the stats in memory.
+type HandlerMetrics struct {
+	mu    sync.Mutex
+	stats map[[2]string]*HandlerStats
+}
+
+func NewHandlerMetrics() *HandlerMetrics {
+	return &HandlerMetrics{stats: make(map[[2]string]*HandlerStats)}
+}
+
+func (m *HandlerMetrics) Observe(eventName, handler string, duration time.Duration, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	key := [2]string{eventName, handler}
+
+	s, ok := m.stats[key]
+	if !ok {
+		s = &HandlerStats{Event: eventName, Handler: handler}
+		m.stats[key] = s
+	}
+
+	s.Count++
+	s.Total += duration
+	s.Max = max(s.Max, duration)
+
+	if err != nil {
+		s.Errors++
+	}
+}
+
+// Stats returns the stats by event name and handler.
+func (m *HandlerMetrics) Stats() []HandlerStats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stats := make([]HandlerStats, 0, len(m.stats))
+	for _, s := range m.stats {
+		stats = append(stats, *s)
+	}
+
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].Event != stats[j].Event {
+			return stats[i].Event < stats[j].Event
+		}
+		return stats[i].Handler < stats[j].Handler
+	})
+
+	return stats
+}
@@ -0,0 +1,136 @@
+package events
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// tracing records the order middlewares run in.
+func tracing(name string, mu *sync.Mutex, trace *[]string) Middleware {
+	return func(next IEventHandler) IEventHandler {
+		return handlerFunc(func(ctx context.Context, event IEvent) error {
+			mu.Lock()
+			*trace = append(*trace, name)
+			mu.Unlock()
+
+			return next.Handle(ctx, event)
+		})
+	}
+}
+
+func TestEventDispatcher_MiddlewareOrder(t *testing.T) {
+	ed := NewEventDispatcher()
+	event := &TestEvent{Name: "order.paid"}
+
+	mu := &sync.Mutex{}
+	var trace []string
+
+	ed.Use(tracing("global1", mu, &trace), tracing("global2", mu, &trace))
+
+	plain := &countingHandler{}
+	wrapped := &countingHandler{}
+
+	// A single subscription, so that the trace is that of one chain:
+	// global middlewares first, then the subscription's.
+	assert.NoError(t, ed.RegisterWith("order.*", wrapped, tracing("local", mu, &trace)))
+	assert.NoError(t, ed.Dispatch(context.Background(), event))
+	assert.Equal(t, []string{"global1", "global2", "local"}, trace)
+
+	// Handlers run concurrently, so across chains only the steps are known.
+	trace = nil
+	assert.NoError(t, ed.Register("order.paid", plain))
+	assert.NoError(t, ed.Dispatch(context.Background(), event))
+
+	assert.Equal(t, 1, plain.calls)
+	assert.Equal(t, 2, wrapped.calls)
+	assert.ElementsMatch(t, []string{"global1", "global2", "global1", "global2", "local"}, trace)
+
+	// The registered handler, not the wrapped one, is what Has and Remove
+	// see.
+	assert.True(t, ed.Has("order.*", wrapped))
+	assert.NoError(t, ed.Remove("order.*", wrapped))
+	assert.Empty(t, ed.subscriptionMiddlewares)
+
+	trace = nil
+	assert.NoError(t, ed.Register("order.*", wrapped))
+	assert.NoError(t, ed.Dispatch(context.Background(), event))
+	assert.ElementsMatch(t, []string{"global1", "global2", "global1", "global2"}, trace)
+}
+
+func TestLogging(t *testing.T) {
+	buf := &bytes.Buffer{}
+	logger := slog.New(slog.NewJSONHandler(buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	ed := NewEventDispatcher()
+	ed.Use(Logging(logger), Recover(logger))
+
+	ed.Register("evento1", &countingHandler{})
+	ed.Register("evento2", &panickingHandler{})
+
+	assert.NoError(t, ed.Dispatch(context.Background(), &TestEvent{Name: "evento1"}))
+	assert.ErrorIs(t, ed.Dispatch(context.Background(), &TestEvent{Name: "evento2"}), ErrHandlerPanicked)
+
+	out := buf.String()
+	assert.Contains(t, out, `"msg":"event handled","event":"evento1","handler":"*events.countingHandler"`)
+	assert.Contains(t, out, `"msg":"event handler panicked","event":"evento2","handler":"*events.panickingHandler"`)
+	assert.Contains(t, out, `"msg":"event handler failed","event":"evento2","handler":"*events.panickingHandler"`)
+}
+
+func TestTimeout(t *testing.T) {
+	ed := NewEventDispatcher()
+	ed.RegisterWith("evento1", &blockingHandler{}, Timeout(20*time.Millisecond))
+	ed.RegisterWith("evento2", &countingHandler{}, Timeout(time.Second))
+
+	assert.ErrorIs(t, ed.Dispatch(context.Background(), &TestEvent{Name: "evento1"}), ErrHandlerTimedOut)
+	assert.NoError(t, ed.Dispatch(context.Background(), &TestEvent{Name: "evento2"}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	handler := Timeout(time.Second)(&blockingHandler{})
+	assert.ErrorIs(t, handler.Handle(ctx, &TestEvent{Name: "evento1"}), context.Canceled)
+}
+
+func TestMetrics(t *testing.T) {
+	metrics := NewHandlerMetrics()
+
+	ed := NewEventDispatcher()
+	ed.Use(Metrics(metrics))
+
+	retrying := WithRetry(&failingHandler{err: errors.New("nope")}, RetryPolicy{MaxAttempts: 1})
+	queue := NewDeadLetterQueue(NewMemoryDeadLetterSink())
+	named, err := queue.WithRetry("audit", &countingHandler{}, RetryPolicy{})
+	assert.NoError(t, err)
+
+	ed.Register("evento1", &countingHandler{})
+	ed.Register("evento1", retrying)
+	ed.Register("evento1", named)
+
+	ed.Dispatch(context.Background(), &TestEvent{Name: "evento1"})
+	ed.Dispatch(context.Background(), &TestEvent{Name: "evento1"})
+
+	stats := metrics.Stats()
+	assert.Len(t, stats, 3)
+
+	assert.Equal(t, "*events.countingHandler", stats[0].Handler)
+	assert.Equal(t, "*events.failingHandler", stats[1].Handler)
+	assert.Equal(t, "audit", stats[2].Handler)
+
+	byHandler := map[string]HandlerStats{}
+	for _, s := range stats {
+		byHandler[s.Handler] = s
+	}
+
+	assert.Equal(t, int64(2), byHandler["*events.failingHandler"].Count)
+	assert.Equal(t, int64(2), byHandler["*events.failingHandler"].Errors)
+	assert.Equal(t, int64(2), byHandler["audit"].Count)
+	assert.Equal(t, int64(0), byHandler["audit"].Errors)
+	assert.GreaterOrEqual(t, byHandler["audit"].Total, byHandler["audit"].Max)
+}
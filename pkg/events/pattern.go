@@ -0,0 +1,97 @@
+package events
+
+import (
+	"errors"
+	"strings"
+)
+
+var ErrInvalidEventPattern = errors.New("invalid event pattern, wildcards must be whole dot-separated segments")
+
+// Wildcards of the event patterns; see EventDispatcher.
+const (
+	wildcardOne  = "*"
+	wildcardMany = "#"
+)
+
+func isPattern(name string) bool {
+	return strings.ContainsAny(name, wildcardOne+wildcardMany)
+}
+
+func patternSegments(pattern string) ([]string, error) {
+	if pattern == wildcardOne {
+		return []string{wildcardMany}, nil
+	}
+
+	segments := strings.Split(pattern, ".")
+	for _, s := range segments {
+		if s == "" || (isPattern(s) && s != wildcardOne && s != wildcardMany) {
+			return nil, ErrInvalidEventPattern
+		}
+	}
+
+	return segments, nil
+}
+
+// patternNode is a trie of the registered patterns, one level per segment,
+// so an event name is matched by walking its segments rather than by testing
+// every pattern.
+type patternNode struct {
+	children map[string]*patternNode
+	// patterns ending at this node; * and # both end at the same node.
+	patterns map[string]struct{}
+}
+
+func newPatternNode() *patternNode {
+	return &patternNode{children: make(map[string]*patternNode)}
+}
+
+func (n *patternNode) add(pattern string, segments []string) {
+	for _, s := range segments {
+		child, ok := n.children[s]
+		if !ok {
+			child = newPatternNode()
+			n.children[s] = child
+		}
+		n = child
+	}
+
+	if n.patterns == nil {
+		n.patterns = make(map[string]struct{})
+	}
+	n.patterns[pattern] = struct{}{}
+}
+
+// remove drops pattern and prunes the nodes left empty. It reports whether n
+// is empty afterwards.
+func (n *patternNode) remove(pattern string, segments []string) bool {
+	if len(segments) == 0 {
+		delete(n.patterns, pattern)
+	} else if child, ok := n.children[segments[0]]; ok && child.remove(pattern, segments[1:]) {
+		delete(n.children, segments[0])
+	}
+
+	return len(n.patterns) == 0 && len(n.children) == 0
+}
+
+// match adds to found the patterns matching the remaining segments of a
+// name.
+func (n *patternNode) match(segments []string, found map[string]struct{}) {
+	if len(segments) == 0 {
+		for p := range n.patterns {
+			found[p] = struct{}{}
+		}
+	} else {
+		if child, ok := n.children[segments[0]]; ok {
+			child.match(segments[1:], found)
+		}
+		if child, ok := n.children[wildcardOne]; ok {
+			child.match(segments[1:], found)
+		}
+	}
+
+	if child, ok := n.children[wildcardMany]; ok {
+		for i := 0; i <= len(segments); i++ {
+			child.match(segments[i:], found)
+		}
+	}
+}
@@ -0,0 +1,190 @@
+package events
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPatternNode_Match(t *testing.T) {
+	cases := []struct {
+		pattern string
+		name    string
+		match   bool
+	}{
+		{"product.*", "product.created", true},
+		{"product.*", "product", false},
+		{"product.*", "product.price.changed", false},
+		{"product.*", "order.created", false},
+		{"*.created", "order.created", true},
+		{"order.#", "order", true},
+		{"order.#", "order.paid", true},
+		{"order.#", "order.item.added", true},
+		{"order.#", "orders.paid", false},
+		{"#.added", "order.item.added", true},
+		{"#.added", "added", true},
+		{"order.#.added", "order.added", true},
+		{"order.#.added", "order.item.added", true},
+		{"order.*.#", "order", false},
+		{"order.*.#", "order.paid", true},
+		{"#", "order.item.added", true},
+		{"*", "order.item.added", true},
+		{"*", "ProductCreated", true},
+	}
+
+	for _, c := range cases {
+		t.Run(fmt.Sprintf("%s %s", c.pattern, c.name), func(t *testing.T) {
+			segments, err := patternSegments(c.pattern)
+			assert.NoError(t, err)
+
+			root := newPatternNode()
+			root.add(c.pattern, segments)
+
+			found := make(map[string]struct{})
+			root.match(strings.Split(c.name, "."), found)
+
+			_, ok := found[c.pattern]
+			assert.Equal(t, c.match, ok)
+		})
+	}
+}
+
+func TestPatternSegments_Invalid(t *testing.T) {
+	for _, pattern := range []string{"product.cre*", "order..#", "order.#.", "**", "a.b#"} {
+		_, err := patternSegments(pattern)
+		assert.ErrorIs(t, err, ErrInvalidEventPattern, pattern)
+	}
+}
+
+func TestPatternNode_RemovePrunes(t *testing.T) {
+	root := newPatternNode()
+
+	for _, p := range []string{"order.#", "order.*", "#"} {
+		segments, _ := patternSegments(p)
+		root.add(p, segments)
+	}
+
+	for _, p := range []string{"order.#", "order.*", "#"} {
+		segments, _ := patternSegments(p)
+		root.remove(p, segments)
+	}
+
+	assert.Empty(t, root.children)
+}
+
+func TestEventDispatcher_PatternSubscriptions(t *testing.T) {
+	ed := NewEventDispatcher()
+
+	products := &countingHandler{}
+	orders := &countingHandler{}
+	all := &countingHandler{}
+	paid := &countingHandler{}
+
+	assert.NoError(t, ed.Register("product.*", products))
+	assert.NoError(t, ed.Register("order.#", orders))
+	assert.NoError(t, ed.Register("*", all))
+	assert.NoError(t, ed.Register("order.paid", paid))
+	// Matched twice for order.paid, but run once.
+	assert.NoError(t, ed.Register("order.*", paid))
+
+	assert.ErrorIs(t, ed.Register("product.cre*", products), ErrInvalidEventPattern)
+	assert.Equal(t, ErrHandlerAlreadyRegistered, ed.Register("product.*", products))
+
+	for _, name := range []string{"product.created", "product.price.changed", "order.paid", "order.item.added", "ProductCreated"} {
+		assert.NoError(t, ed.Dispatch(context.Background(), &TestEvent{Name: name}))
+	}
+
+	assert.Equal(t, 1, products.calls)
+	assert.Equal(t, 2, orders.calls)
+	assert.Equal(t, 5, all.calls)
+	assert.Equal(t, 1, paid.calls)
+}
+
+func TestEventDispatcher_PatternHasAndRemove(t *testing.T) {
+	ed := NewEventDispatcher()
+	handler := &countingHandler{}
+	event := &TestEvent{Name: "product.created"}
+
+	assert.NoError(t, ed.Register("product.*", handler))
+
+	assert.True(t, ed.Has("product.*", handler))
+	assert.False(t, ed.Has("product.created", handler))
+	assert.False(t, ed.Has("product.#", handler))
+
+	assert.NoError(t, ed.Remove("product.*", handler))
+	assert.False(t, ed.Has("product.*", handler))
+	assert.Empty(t, ed.patterns.children)
+
+	assert.NoError(t, ed.Dispatch(context.Background(), event))
+	assert.Equal(t, 0, handler.calls)
+
+	assert.NoError(t, ed.Register("#", handler))
+	assert.NoError(t, ed.Clear())
+	assert.NoError(t, ed.Dispatch(context.Background(), event))
+	assert.Equal(t, 0, handler.calls)
+}
+
+// funcHandler and sliceHandler are handlers of uncomparable types.
+type funcHandler struct {
+	fn func()
+}
+
+func (h funcHandler) Handle(ctx context.Context, event IEvent) error {
+	h.fn()
+	return nil
+}
+
+type sliceHandler []*int
+
+func (h sliceHandler) Handle(ctx context.Context, event IEvent) error {
+	*h[0]++
+	return nil
+}
+
+func TestEventDispatcher_UncomparableHandlers(t *testing.T) {
+	ed := NewEventDispatcher()
+	event := &TestEvent{Name: "product.created"}
+
+	var funcCalls, sliceCalls int32
+	byFunc := funcHandler{fn: func() { atomic.AddInt32(&funcCalls, 1) }}
+	counter := 0
+	bySlice := sliceHandler{&counter}
+
+	assert.NoError(t, ed.Register("product.created", byFunc))
+	assert.NoError(t, ed.Register("product.*", byFunc))
+	assert.NoError(t, ed.RegisterWith("#", bySlice, func(next IEventHandler) IEventHandler {
+		return handlerFunc(func(ctx context.Context, event IEvent) error {
+			atomic.AddInt32(&sliceCalls, 1)
+			return next.Handle(ctx, event)
+		})
+	}))
+
+	// They can't be told apart, so they're never found again.
+	assert.False(t, ed.Has("product.created", byFunc))
+	assert.NoError(t, ed.Remove("#", bySlice))
+
+	assert.NoError(t, ed.Dispatch(context.Background(), event))
+	assert.Equal(t, int32(1), funcCalls)
+	assert.Equal(t, int32(1), sliceCalls)
+	assert.Equal(t, 1, counter)
+}
+
+func TestEventDispatcher_UncomparableHandlerUnderSeveralPatterns(t *testing.T) {
+	ed := NewEventDispatcher()
+
+	var calls, otherCalls int32
+	handler := funcHandler{fn: func() { atomic.AddInt32(&calls, 1) }}
+	other := funcHandler{fn: func() { atomic.AddInt32(&otherCalls, 1) }}
+
+	assert.NoError(t, ed.Register("product.*", handler))
+	assert.NoError(t, ed.Register("#", handler))
+	assert.NoError(t, ed.Register("product.#", other))
+
+	assert.NoError(t, ed.Dispatch(context.Background(), &TestEvent{Name: "product.created"}))
+	assert.Equal(t, int32(1), calls)
+	assert.Equal(t, int32(1), otherCalls)
+}
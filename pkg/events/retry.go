@@ -0,0 +1,119 @@
+package events
+
+import (
+	"context"
+	"math"
+	"math/rand/v2"
+	"time"
+)
+
+// RetryPolicy tells how often and how fast a failing handler is retried.
+// Backoff grows exponentially from InitialBackoff by Multiplier, up to
+// MaxBackoff, and Jitter takes a random fraction of up to that much off each
+// wait so that retries of many events don't line up.
+type RetryPolicy struct {
+	// MaxAttempts counts the first attempt too; below 1 means 1.
+	MaxAttempts    int
+	InitialBackoff time.Duration
+	// MaxBackoff caps the wait between attempts; zero means no cap.
+	MaxBackoff time.Duration
+	// Multiplier below 1 means 2.
+	Multiplier float64
+	// Jitter is between 0 and 1.
+	Jitter float64
+	// Retryable tells whether an error is worth retrying; nil retries
+	// every error.
+	Retryable func(err error) bool
+}
+
+// Backoff returns the wait after the given failed attempt, counting from 1,
+// before jitter.
+func (p RetryPolicy) Backoff(attempt int) time.Duration {
+	multiplier := p.Multiplier
+	if multiplier < 1 {
+		multiplier = 2
+	}
+
+	backoff := float64(p.InitialBackoff) * math.Pow(multiplier, float64(attempt-1))
+
+	if p.MaxBackoff > 0 && backoff > float64(p.MaxBackoff) {
+		return p.MaxBackoff
+	}
+
+	// Without a cap, many attempts would overflow time.Duration; math.Pow
+	// may even give +Inf.
+	if backoff >= math.MaxInt64 {
+		return math.MaxInt64
+	}
+
+	return time.Duration(backoff)
+}
+
+func (p RetryPolicy) wait(attempt int) time.Duration {
+	backoff := p.Backoff(attempt)
+
+	jitter := min(max(p.Jitter, 0), 1)
+	if jitter > 0 {
+		backoff -= time.Duration(rand.Float64() * jitter * float64(backoff))
+	}
+
+	return backoff
+}
+
+// run calls fn until it succeeds, fails with an error that isn't retryable,
+// runs out of attempts or ctx is done. It returns the last error and the
+// number of attempts made.
+func (p RetryPolicy) run(ctx context.Context, fn func() error) (int, error) {
+	attempts := max(p.MaxAttempts, 1)
+
+	for attempt := 1; ; attempt++ {
+		err := callHandler(fn)
+
+		if err == nil || attempt >= attempts || (p.Retryable != nil && !p.Retryable(err)) {
+			return attempt, err
+		}
+
+		timer := time.NewTimer(p.wait(attempt))
+
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return attempt, err
+		}
+	}
+}
+
+// retryHandler is a pointer, so the handler returned by WithRetry can be
+// removed from a dispatcher.
+type retryHandler struct {
+	name    string
+	handler IEventHandler
+	policy  RetryPolicy
+	queue   *DeadLetterQueue
+}
+
+// WithRetry retries handler according to policy. Retries happen within the
+// dispatch, so on an async dispatcher they hold back the following events of
+// the same key, which keeps them in order.
+func WithRetry(handler IEventHandler, policy RetryPolicy) IEventHandler {
+	return &retryHandler{handler: handler, policy: policy}
+}
+
+func (h *retryHandler) Handle(ctx context.Context, event IEvent) error {
+	attempts, err := h.policy.run(ctx, func() error { return h.handler.Handle(ctx, event) })
+
+	if err == nil || h.queue == nil {
+		return err
+	}
+
+	return h.queue.deadLetter(ctx, h.name, event, attempts, err)
+}
+
+func (h *retryHandler) HandlerName() string {
+	if h.name != "" {
+		return h.name
+	}
+
+	return handlerName(h.handler)
+}
@@ -0,0 +1,122 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// flakyHandler fails its first failures calls with err.
+type flakyHandler struct {
+	failures int
+	err      error
+	calls    int
+}
+
+func (h *flakyHandler) Handle(ctx context.Context, event IEvent) error {
+	h.calls++
+
+	if h.calls <= h.failures {
+		return h.err
+	}
+
+	return nil
+}
+
+func TestRetryPolicy_Backoff(t *testing.T) {
+	p := RetryPolicy{InitialBackoff: 100 * time.Millisecond, MaxBackoff: time.Second}
+
+	assert.Equal(t, 100*time.Millisecond, p.Backoff(1))
+	assert.Equal(t, 200*time.Millisecond, p.Backoff(2))
+	assert.Equal(t, 800*time.Millisecond, p.Backoff(4))
+	assert.Equal(t, time.Second, p.Backoff(5))
+
+	p = RetryPolicy{InitialBackoff: 10 * time.Millisecond, Multiplier: 3}
+	assert.Equal(t, 90*time.Millisecond, p.Backoff(3))
+
+	// Without MaxBackoff the wait saturates instead of overflowing.
+	p = RetryPolicy{InitialBackoff: time.Second}
+	assert.Equal(t, time.Duration(math.MaxInt64), p.Backoff(64))
+	assert.Equal(t, time.Duration(math.MaxInt64), p.Backoff(10000))
+	assert.Positive(t, p.wait(10000))
+}
+
+func TestRetryPolicy_Jitter(t *testing.T) {
+	p := RetryPolicy{InitialBackoff: 100 * time.Millisecond, Jitter: 0.5}
+
+	for i := 0; i < 100; i++ {
+		wait := p.wait(1)
+		assert.GreaterOrEqual(t, wait, 50*time.Millisecond)
+		assert.LessOrEqual(t, wait, 100*time.Millisecond)
+	}
+}
+
+func TestWithRetry(t *testing.T) {
+	errFlaky := errors.New("flaky")
+	event := &TestEvent{Name: "evento1"}
+
+	h := &flakyHandler{failures: 2, err: errFlaky}
+	retrying := WithRetry(h, RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond})
+
+	assert.NoError(t, retrying.Handle(context.Background(), event))
+	assert.Equal(t, 3, h.calls)
+
+	h = &flakyHandler{failures: 5, err: errFlaky}
+	retrying = WithRetry(h, RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond})
+
+	assert.ErrorIs(t, retrying.Handle(context.Background(), event), errFlaky)
+	assert.Equal(t, 3, h.calls)
+}
+
+func TestWithRetry_NotRetryable(t *testing.T) {
+	errPermanent := errors.New("permanent")
+
+	h := &flakyHandler{failures: 5, err: errPermanent}
+	retrying := WithRetry(h, RetryPolicy{
+		MaxAttempts:    5,
+		InitialBackoff: time.Millisecond,
+		Retryable:      func(err error) bool { return !errors.Is(err, errPermanent) },
+	})
+
+	assert.ErrorIs(t, retrying.Handle(context.Background(), &TestEvent{Name: "evento1"}), errPermanent)
+	assert.Equal(t, 1, h.calls)
+}
+
+func TestWithRetry_StopsWhenContextIsDone(t *testing.T) {
+	errFlaky := errors.New("flaky")
+
+	h := &flakyHandler{failures: 5, err: errFlaky}
+	retrying := WithRetry(h, RetryPolicy{MaxAttempts: 5, InitialBackoff: time.Hour})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	assert.ErrorIs(t, retrying.Handle(ctx, &TestEvent{Name: "evento1"}), errFlaky)
+	assert.Equal(t, 1, h.calls)
+}
+
+func TestWithRetry_RetriesPanics(t *testing.T) {
+	calls := 0
+	retrying := WithRetry(handlerFunc(func(ctx context.Context, event IEvent) error {
+		calls++
+		if calls == 1 {
+			panic("boom")
+		}
+		return nil
+	}), RetryPolicy{MaxAttempts: 2})
+
+	assert.NoError(t, retrying.Handle(context.Background(), &TestEvent{Name: "evento1"}))
+	assert.Equal(t, 2, calls)
+}
+
+// handlerFunc adapts a function for tests that don't register it, as
+// functions can't be compared by Has and Remove.
+type handlerFunc func(ctx context.Context, event IEvent) error
+
+func (f handlerFunc) Handle(ctx context.Context, event IEvent) error {
+	return f(ctx, event)
+}
@@ -0,0 +1,340 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+var (
+	ErrConsumerRequired      = errors.New("event store consumer name is required")
+	ErrStreamRequired        = errors.New("event stream name is required")
+	ErrStreamVersionConflict = errors.New("event stream is not at the expected version")
+)
+
+// StoredEvent is an event as recorded by an EventStore. Sequence numbers
+// start at 1 and grow with every appended event. Events appended to a
+// stream also carry its name and their version in it, numbered from 1.
+type StoredEvent struct {
+	Sequence      int64
+	Stream        string
+	StreamVersion int
+	Event         IEvent
+	RecordedAt    time.Time
+}
+
+// EventQuery selects stored events in sequence order: those after the
+// sequence After, dated (by the event's GetDateTime) from From included to To
+// excluded. Zero fields don't filter; Limit caps the number of events. Events
+// appended to streams are the private history of an aggregate, so they are
+// left out unless Streams is set.
+type EventQuery struct {
+	After   int64
+	From    time.Time
+	To      time.Time
+	Limit   int
+	Streams bool
+}
+
+// EventStore is an append-only log of events.
+type EventStore interface {
+	Append(ctx context.Context, event IEvent) (int64, error)
+	Load(ctx context.Context, query EventQuery) ([]StoredEvent, error)
+}
+
+// StreamStore is an EventStore whose events can also make up streams, such
+// as the history of an aggregate. AppendToStream appends events after the
+// version the caller read, 0 for a new stream, and returns the new version;
+// if the stream is at another version it appends nothing and fails with
+// ErrStreamVersionConflict. LoadStream returns the events of a stream after
+// a version, in order.
+type StreamStore interface {
+	EventStore
+	AppendToStream(ctx context.Context, stream string, expectedVersion int, events ...IEvent) (int, error)
+	LoadStream(ctx context.Context, stream string, after int) ([]StoredEvent, error)
+}
+
+// Snapshot is the state of a stream at a version, saved now and then so
+// that loading the stream doesn't replay all of it.
+type Snapshot struct {
+	Stream    string
+	Version   int
+	State     []byte
+	CreatedAt time.Time
+}
+
+// SnapshotStore keeps the latest snapshot of each stream. LoadSnapshot
+// returns nil for streams without one.
+type SnapshotStore interface {
+	LoadSnapshot(ctx context.Context, stream string) (*Snapshot, error)
+	SaveSnapshot(ctx context.Context, snapshot Snapshot) error
+}
+
+// CheckpointStore keeps the sequence of the last event each consumer
+// handled. Consumers that never saved one are at 0.
+type CheckpointStore interface {
+	Checkpoint(ctx context.Context, consumer string) (int64, error)
+	SaveCheckpoint(ctx context.Context, consumer string, sequence int64) error
+}
+
+// RecordingDispatcher appends every event to an EventStore before handing
+// it to the dispatcher it wraps. Events that can't be stored aren't
+// dispatched. Events their caller already appended, e.g. in the transaction
+// of the change they tell of, go through DispatchRecorded instead.
+type RecordingDispatcher struct {
+	IEventDispacher
+	Store EventStore
+}
+
+func NewRecordingDispatcher(dispatcher IEventDispacher, store EventStore) *RecordingDispatcher {
+	return &RecordingDispatcher{IEventDispacher: dispatcher, Store: store}
+}
+
+func (d *RecordingDispatcher) Dispatch(ctx context.Context, event IEvent) error {
+	if _, err := d.Store.Append(ctx, event); err != nil {
+		return err
+	}
+
+	return d.IEventDispacher.Dispatch(ctx, event)
+}
+
+// DispatchRecorded hands event, already in Store, to the wrapped dispatcher
+// without appending it again.
+func (d *RecordingDispatcher) DispatchRecorded(ctx context.Context, event IEvent) error {
+	return d.IEventDispacher.Dispatch(ctx, event)
+}
+
+// replayBatch is how many events Replay loads at a time.
+const replayBatch = 100
+
+// Replay hands the stored events selected by query to handler, one at a
+// time in sequence order, for instance to build a new projection from
+// history. It stops at the first error and returns the sequence of the last
+// event handled.
+func Replay(ctx context.Context, store EventStore, query EventQuery, handler IEventHandler) (int64, error) {
+	return replay(ctx, store, query, handler, nil)
+}
+
+// CatchUp replays to handler the events stored since consumer's checkpoint,
+// those in streams aside, saving the checkpoint after each batch and on
+// failure, so that a consumer stopped for any reason resumes where it left
+// off. An event may be handled again if the process dies before its
+// checkpoint is saved. CatchUp returns the saved checkpoint.
+func CatchUp(ctx context.Context, store EventStore, checkpoints CheckpointStore, consumer string, handler IEventHandler) (int64, error) {
+	if consumer == "" {
+		return 0, ErrConsumerRequired
+	}
+
+	after, err := checkpoints.Checkpoint(ctx, consumer)
+	if err != nil {
+		return 0, err
+	}
+
+	saved := after
+	save := func(last int64) error {
+		if last == saved {
+			return nil
+		}
+
+		// Progress made before a cancellation is still worth keeping.
+		if err := checkpoints.SaveCheckpoint(context.WithoutCancel(ctx), consumer, last); err != nil {
+			return err
+		}
+
+		saved = last
+
+		return nil
+	}
+
+	last, err := replay(ctx, store, EventQuery{After: after}, handler, save)
+	if err != nil {
+		if saveErr := save(last); saveErr != nil {
+			err = errors.Join(err, saveErr)
+		}
+	}
+
+	return saved, err
+}
+
+// replay pages through the events selected by query, calling afterBatch
+// with the sequence of the last event handled once each batch is done.
+func replay(ctx context.Context, store EventStore, query EventQuery, handler IEventHandler, afterBatch func(last int64) error) (int64, error) {
+	last := query.After
+	remaining := query.Limit
+
+	for {
+		batch := query
+		batch.After = last
+		batch.Limit = replayBatch
+		if query.Limit > 0 {
+			batch.Limit = min(remaining, replayBatch)
+		}
+
+		stored, err := store.Load(ctx, batch)
+		if err != nil {
+			return last, err
+		}
+
+		for _, s := range stored {
+			if err := ctx.Err(); err != nil {
+				return last, err
+			}
+
+			if err := callHandler(func() error { return handler.Handle(ctx, s.Event) }); err != nil {
+				return last, err
+			}
+
+			last = s.Sequence
+		}
+
+		if afterBatch != nil {
+			if err := afterBatch(last); err != nil {
+				return last, err
+			}
+		}
+
+		remaining -= len(stored)
+
+		if len(stored) < batch.Limit || (query.Limit > 0 && remaining <= 0) {
+			return last, nil
+		}
+	}
+}
+
+// MemoryEventStore is a StreamStore, SnapshotStore and CheckpointStore kept
+// in memory, lost on restart.
+type MemoryEventStore struct {
+	mu          sync.RWMutex
+	events      []StoredEvent
+	streams     map[string]int
+	snapshots   map[string]Snapshot
+	checkpoints map[string]int64
+}
+
+func NewMemoryEventStore() *MemoryEventStore {
+	return &MemoryEventStore{
+		streams:     make(map[string]int),
+		snapshots:   make(map[string]Snapshot),
+		checkpoints: make(map[string]int64),
+	}
+}
+
+func (s *MemoryEventStore) Append(ctx context.Context, event IEvent) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sequence := int64(len(s.events)) + 1
+	s.events = append(s.events, StoredEvent{Sequence: sequence, Event: event, RecordedAt: time.Now()})
+
+	return sequence, nil
+}
+
+func (s *MemoryEventStore) Load(ctx context.Context, query EventQuery) ([]StoredEvent, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var stored []StoredEvent
+
+	for _, e := range s.events[min(max(query.After, 0), int64(len(s.events))):] {
+		at := e.Event.GetDateTime()
+
+		if (!query.From.IsZero() && at.Before(query.From)) || (!query.To.IsZero() && !at.Before(query.To)) {
+			continue
+		}
+
+		if e.Stream != "" && !query.Streams {
+			continue
+		}
+
+		stored = append(stored, e)
+
+		if query.Limit > 0 && len(stored) == query.Limit {
+			break
+		}
+	}
+
+	return stored, nil
+}
+
+func (s *MemoryEventStore) AppendToStream(ctx context.Context, stream string, expectedVersion int, events ...IEvent) (int, error) {
+	if stream == "" {
+		return 0, ErrStreamRequired
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if version := s.streams[stream]; version != expectedVersion {
+		return 0, fmt.Errorf("%w: %s is at version %d, not %d", ErrStreamVersionConflict, stream, version, expectedVersion)
+	}
+
+	now := time.Now()
+
+	for i, event := range events {
+		s.events = append(s.events, StoredEvent{
+			Sequence:      int64(len(s.events)) + 1,
+			Stream:        stream,
+			StreamVersion: expectedVersion + i + 1,
+			Event:         event,
+			RecordedAt:    now,
+		})
+	}
+
+	s.streams[stream] = expectedVersion + len(events)
+
+	return s.streams[stream], nil
+}
+
+func (s *MemoryEventStore) LoadStream(ctx context.Context, stream string, after int) ([]StoredEvent, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var stored []StoredEvent
+
+	for _, e := range s.events {
+		if e.Stream == stream && e.StreamVersion > after {
+			stored = append(stored, e)
+		}
+	}
+
+	return stored, nil
+}
+
+func (s *MemoryEventStore) LoadSnapshot(ctx context.Context, stream string) (*Snapshot, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	snapshot, ok := s.snapshots[stream]
+	if !ok {
+		return nil, nil
+	}
+
+	return &snapshot, nil
+}
+
+func (s *MemoryEventStore) SaveSnapshot(ctx context.Context, snapshot Snapshot) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.snapshots[snapshot.Stream] = snapshot
+
+	return nil
+}
+
+func (s *MemoryEventStore) Checkpoint(ctx context.Context, consumer string) (int64, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.checkpoints[consumer], nil
+}
+
+func (s *MemoryEventStore) SaveCheckpoint(ctx context.Context, consumer string, sequence int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.checkpoints[consumer] = sequence
+
+	return nil
+}
@@ -0,0 +1,226 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// recordingHandler keeps the events it handled, failing on the event named
+// failOn.
+type recordingHandler struct {
+	names  []string
+	failOn string
+}
+
+func (h *recordingHandler) Handle(ctx context.Context, event IEvent) error {
+	if event.GetName() == h.failOn {
+		return errors.New("nope")
+	}
+
+	h.names = append(h.names, event.GetName())
+
+	return nil
+}
+
+func TestRecordingDispatcher(t *testing.T) {
+	store := NewMemoryEventStore()
+	ed := NewRecordingDispatcher(NewEventDispatcher(), store)
+
+	handler := &recordingHandler{}
+	assert.NoError(t, ed.Register("#", handler))
+
+	assert.NoError(t, ed.Dispatch(context.Background(), &TestEvent{Name: "evento1"}))
+	assert.NoError(t, ed.Dispatch(context.Background(), &TestEvent{Name: "evento2"}))
+
+	stored, err := store.Load(context.Background(), EventQuery{})
+	assert.NoError(t, err)
+	assert.Len(t, stored, 2)
+	assert.Equal(t, int64(1), stored[0].Sequence)
+	assert.Equal(t, "evento2", stored[1].Event.GetName())
+	assert.Equal(t, []string{"evento1", "evento2"}, handler.names)
+}
+
+type failingStore struct {
+	MemoryEventStore
+}
+
+func (s *failingStore) Append(ctx context.Context, event IEvent) (int64, error) {
+	return 0, errors.New("disk full")
+}
+
+func TestRecordingDispatcher_StoreFails(t *testing.T) {
+	ed := NewRecordingDispatcher(NewEventDispatcher(), &failingStore{})
+
+	handler := &recordingHandler{}
+	ed.Register("evento1", handler)
+
+	assert.Error(t, ed.Dispatch(context.Background(), &TestEvent{Name: "evento1"}))
+	assert.Empty(t, handler.names)
+}
+
+func TestRecordingDispatcher_DispatchRecorded(t *testing.T) {
+	store := NewMemoryEventStore()
+	ed := NewRecordingDispatcher(NewEventDispatcher(), store)
+
+	handler := &recordingHandler{}
+	ed.Register("evento1", handler)
+
+	event := &TestEvent{Name: "evento1"}
+	_, err := store.Append(context.Background(), event)
+	assert.NoError(t, err)
+
+	assert.NoError(t, ed.DispatchRecorded(context.Background(), event))
+	assert.Equal(t, []string{"evento1"}, handler.names)
+
+	stored, err := store.Load(context.Background(), EventQuery{})
+	assert.NoError(t, err)
+	assert.Len(t, stored, 1)
+}
+
+type datedEvent struct {
+	name string
+	at   time.Time
+}
+
+func (e *datedEvent) GetName() string        { return e.name }
+func (e *datedEvent) GetDateTime() time.Time { return e.at }
+func (e *datedEvent) GetPayLoad() any        { return nil }
+
+func TestReplay(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryEventStore()
+	start := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+
+	for i := 1; i <= 250; i++ {
+		store.Append(ctx, &datedEvent{name: fmt.Sprintf("e%d", i), at: start.Add(time.Duration(i) * time.Hour)})
+	}
+
+	handler := &recordingHandler{}
+	last, err := Replay(ctx, store, EventQuery{}, handler)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(250), last)
+	assert.Len(t, handler.names, 250)
+
+	handler = &recordingHandler{}
+	last, err = Replay(ctx, store, EventQuery{After: 240}, handler)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(250), last)
+	assert.Equal(t, "e241", handler.names[0])
+
+	handler = &recordingHandler{}
+	last, err = Replay(ctx, store, EventQuery{After: 10, Limit: 150}, handler)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(160), last)
+	assert.Len(t, handler.names, 150)
+
+	handler = &recordingHandler{}
+	last, err = Replay(ctx, store, EventQuery{From: start.Add(5 * time.Hour), To: start.Add(8 * time.Hour)}, handler)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(7), last)
+	assert.Equal(t, []string{"e5", "e6", "e7"}, handler.names)
+
+	handler = &recordingHandler{failOn: "e42"}
+	last, err = Replay(ctx, store, EventQuery{}, handler)
+	assert.Error(t, err)
+	assert.Equal(t, int64(41), last)
+}
+
+func TestCatchUp(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryEventStore()
+
+	for i := 1; i <= 150; i++ {
+		store.Append(ctx, &TestEvent{Name: fmt.Sprintf("e%d", i)})
+	}
+
+	_, err := CatchUp(ctx, store, store, "", &recordingHandler{})
+	assert.ErrorIs(t, err, ErrConsumerRequired)
+
+	handler := &recordingHandler{failOn: "e120"}
+	checkpoint, err := CatchUp(ctx, store, store, "projection", handler)
+	assert.Error(t, err)
+	assert.Equal(t, int64(119), checkpoint)
+
+	saved, _ := store.Checkpoint(ctx, "projection")
+	assert.Equal(t, int64(119), saved)
+
+	handler.failOn = ""
+	handler.names = nil
+	checkpoint, err = CatchUp(ctx, store, store, "projection", handler)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(150), checkpoint)
+	assert.Equal(t, "e120", handler.names[0])
+	assert.Len(t, handler.names, 31)
+
+	// Nothing new: nothing handled.
+	handler.names = nil
+	checkpoint, err = CatchUp(ctx, store, store, "projection", handler)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(150), checkpoint)
+	assert.Empty(t, handler.names)
+
+	// Other consumers keep their own checkpoint.
+	other, _ := store.Checkpoint(ctx, "audit")
+	assert.Equal(t, int64(0), other)
+}
+
+func TestMemoryEventStore_Streams(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryEventStore()
+
+	_, err := store.Append(ctx, &TestEvent{Name: "evento1"})
+	assert.NoError(t, err)
+
+	version, err := store.AppendToStream(ctx, "order-1", 0, &TestEvent{Name: "criado"}, &TestEvent{Name: "pago"})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, version)
+
+	// Appending after a version that is no longer the last one conflicts.
+	_, err = store.AppendToStream(ctx, "order-1", 1, &TestEvent{Name: "cancelado"})
+	assert.ErrorIs(t, err, ErrStreamVersionConflict)
+
+	_, err = store.AppendToStream(ctx, "", 0, &TestEvent{Name: "criado"})
+	assert.ErrorIs(t, err, ErrStreamRequired)
+
+	version, err = store.AppendToStream(ctx, "order-1", 2, &TestEvent{Name: "enviado"})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, version)
+
+	stored, err := store.LoadStream(ctx, "order-1", 1)
+	assert.NoError(t, err)
+	assert.Len(t, stored, 2)
+	assert.Equal(t, "pago", stored[0].Event.GetName())
+	assert.Equal(t, 2, stored[0].StreamVersion)
+	assert.Equal(t, int64(3), stored[0].Sequence)
+
+	// Stream events are in the log, but only read when asked for.
+	all, err := store.Load(ctx, EventQuery{})
+	assert.NoError(t, err)
+	assert.Len(t, all, 1)
+	assert.Empty(t, all[0].Stream)
+
+	all, err = store.Load(ctx, EventQuery{Streams: true})
+	assert.NoError(t, err)
+	assert.Len(t, all, 4)
+
+	handler := &recordingHandler{}
+	last, err := Replay(ctx, store, EventQuery{}, handler)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), last)
+	assert.Len(t, handler.names, 1)
+
+	snapshot, err := store.LoadSnapshot(ctx, "order-1")
+	assert.NoError(t, err)
+	assert.Nil(t, snapshot)
+
+	assert.NoError(t, store.SaveSnapshot(ctx, Snapshot{Stream: "order-1", Version: 2, State: []byte(`{}`)}))
+
+	snapshot, err = store.LoadSnapshot(ctx, "order-1")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, snapshot.Version)
+}
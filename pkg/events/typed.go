@@ -0,0 +1,218 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+)
+
+var (
+	ErrEventPayloadType       = errors.New("event payload has the wrong type")
+	ErrEventTypeRegistered    = errors.New("event type already registered")
+	ErrEventNameRequired      = errors.New("event name is required")
+	ErrEventTypeNotRegistered = errors.New("event type is not registered")
+)
+
+// Event is an event whose payload has type T. It implements IEvent, so it
+// can be dispatched by any IEventDispacher, and IKeyedEvent, an empty Key
+// ordering it by name. Its JSON form is the one MarshalEvent writes.
+type Event[T any] struct {
+	Name     string    `json:"name"`
+	Key      string    `json:"key,omitempty"`
+	Payload  T         `json:"payload"`
+	DateTime time.Time `json:"date_time"`
+}
+
+func NewEvent[T any](name string, payload T) *Event[T] {
+	return &Event[T]{
+		Name:     name,
+		Payload:  payload,
+		DateTime: time.Now(),
+	}
+}
+
+func (e *Event[T]) GetName() string {
+	return e.Name
+}
+
+func (e *Event[T]) GetKey() string {
+	return e.Key
+}
+
+func (e *Event[T]) GetDateTime() time.Time {
+	return e.DateTime
+}
+
+func (e *Event[T]) GetPayLoad() any {
+	return e.Payload
+}
+
+// HandlerFunc handles events with a payload of type T.
+type HandlerFunc[T any] func(ctx context.Context, event *Event[T]) error
+
+// typedHandler is a pointer, as functions can't be compared by Has and
+// Remove.
+type typedHandler[T any] struct {
+	fn HandlerFunc[T]
+}
+
+// Subscribe registers fn for an event name or pattern on d. It returns the
+// registered handler, to be given to Has and Remove.
+//
+// fn is called with the dispatched *Event[T] as is. Other events are
+// converted: a payload of type T is used as is, and a JSON payload, such as
+// that of a RawEvent read from a broker or a dead letter, is decoded into a
+// T. Any other payload fails the handler with ErrEventPayloadType instead of
+// reaching fn.
+func Subscribe[T any](d IEventDispacher, eventName string, fn HandlerFunc[T]) (IEventHandler, error) {
+	h := &typedHandler[T]{fn: fn}
+
+	if err := d.Register(eventName, h); err != nil {
+		return nil, err
+	}
+
+	return h, nil
+}
+
+func (h *typedHandler[T]) Handle(ctx context.Context, event IEvent) error {
+	typed, err := AsEvent[T](event)
+	if err != nil {
+		return err
+	}
+
+	return h.fn(ctx, typed)
+}
+
+func (h *typedHandler[T]) HandlerName() string {
+	return fmt.Sprintf("events.HandlerFunc[%s]", reflect.TypeFor[T]())
+}
+
+// AsEvent converts event to an *Event[T] the way Subscribe does.
+func AsEvent[T any](event IEvent) (*Event[T], error) {
+	if typed, ok := event.(*Event[T]); ok {
+		return typed, nil
+	}
+
+	typed := &Event[T]{
+		Name:     event.GetName(),
+		DateTime: event.GetDateTime(),
+	}
+
+	if keyed, ok := event.(IKeyedEvent); ok {
+		typed.Key = keyed.GetKey()
+	}
+
+	switch payload := event.GetPayLoad().(type) {
+	case T:
+		typed.Payload = payload
+	case json.RawMessage:
+		if err := json.Unmarshal(payload, &typed.Payload); err != nil {
+			return nil, fmt.Errorf("%w: %s: %w", ErrEventPayloadType, event.GetName(), err)
+		}
+	case []byte:
+		if err := json.Unmarshal(payload, &typed.Payload); err != nil {
+			return nil, fmt.Errorf("%w: %s: %w", ErrEventPayloadType, event.GetName(), err)
+		}
+	default:
+		return nil, fmt.Errorf("%w: %s carries %T, want %T", ErrEventPayloadType, event.GetName(), payload, typed.Payload)
+	}
+
+	return typed, nil
+}
+
+// eventJSON is the JSON form of every event, as Event[T] writes it.
+type eventJSON struct {
+	Name     string          `json:"name"`
+	Key      string          `json:"key,omitempty"`
+	Payload  json.RawMessage `json:"payload"`
+	DateTime time.Time       `json:"date_time"`
+}
+
+// MarshalEvent writes any event as JSON with its name, key, payload and
+// date, for EventRegistry.Unmarshal to read back.
+func MarshalEvent(event IEvent) ([]byte, error) {
+	payload, err := json.Marshal(event.GetPayLoad())
+	if err != nil {
+		return nil, err
+	}
+
+	v := eventJSON{
+		Name:     event.GetName(),
+		Payload:  payload,
+		DateTime: event.GetDateTime(),
+	}
+
+	if keyed, ok := event.(IKeyedEvent); ok {
+		v.Key = keyed.GetKey()
+	}
+
+	return json.Marshal(v)
+}
+
+// EventRegistry knows the payload type of each event name, so events
+// received as JSON, e.g. from a broker, are read back as *Event[T].
+type EventRegistry struct {
+	mu       sync.RWMutex
+	decoders map[string]func(v eventJSON) (IEvent, error)
+}
+
+func NewEventRegistry() *EventRegistry {
+	return &EventRegistry{
+		decoders: make(map[string]func(v eventJSON) (IEvent, error)),
+	}
+}
+
+// RegisterEventType makes r read the events called name as *Event[T].
+func RegisterEventType[T any](r *EventRegistry, name string) error {
+	if name == "" {
+		return ErrEventNameRequired
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.decoders[name]; ok {
+		return fmt.Errorf("%w: %s", ErrEventTypeRegistered, name)
+	}
+
+	r.decoders[name] = func(v eventJSON) (IEvent, error) {
+		event := &Event[T]{Name: v.Name, Key: v.Key, DateTime: v.DateTime}
+
+		if err := json.Unmarshal(v.Payload, &event.Payload); err != nil {
+			return nil, fmt.Errorf("%w: %s: %w", ErrEventPayloadType, v.Name, err)
+		}
+
+		return event, nil
+	}
+
+	return nil
+}
+
+// Unmarshal reads an event written by MarshalEvent. Events of registered
+// names come back as *Event[T]; the others fail with
+// ErrEventTypeNotRegistered.
+func (r *EventRegistry) Unmarshal(data []byte) (IEvent, error) {
+	var v eventJSON
+
+	if err := json.Unmarshal(data, &v); err != nil {
+		return nil, err
+	}
+
+	if v.Name == "" {
+		return nil, ErrEventNameRequired
+	}
+
+	r.mu.RLock()
+	decode, ok := r.decoders[v.Name]
+	r.mu.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrEventTypeNotRegistered, v.Name)
+	}
+
+	return decode(v)
+}
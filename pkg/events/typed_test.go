@@ -0,0 +1,96 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type productCreated struct {
+	ID    string `json:"id"`
+	Price int    `json:"price"`
+}
+
+func TestSubscribe(t *testing.T) {
+	ed := NewEventDispatcher()
+
+	var got []*Event[productCreated]
+	handler, err := Subscribe(ed, "product.*", func(ctx context.Context, event *Event[productCreated]) error {
+		got = append(got, event)
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.True(t, ed.Has("product.*", handler))
+
+	event := NewEvent("product.created", productCreated{ID: "1", Price: 10})
+	assert.NoError(t, ed.Dispatch(context.Background(), event))
+
+	// Untyped events work too, when their payload fits.
+	assert.NoError(t, ed.Dispatch(context.Background(), &TestEvent{Name: "product.updated", Payload: productCreated{ID: "2"}}))
+	assert.NoError(t, ed.Dispatch(context.Background(), &RawEvent{Name: "product.deleted", Key: "3", Payload: json.RawMessage(`{"id":"3","price":30}`)}))
+
+	assert.ErrorIs(t, ed.Dispatch(context.Background(), &TestEvent{Name: "product.updated", Payload: "Conteúdo"}), ErrEventPayloadType)
+	assert.ErrorIs(t, ed.Dispatch(context.Background(), &RawEvent{Name: "product.updated", Payload: json.RawMessage(`"x"`)}), ErrEventPayloadType)
+
+	assert.Len(t, got, 3)
+	assert.Same(t, event, got[0])
+	assert.Equal(t, productCreated{ID: "2"}, got[1].Payload)
+	assert.Equal(t, "3", got[2].Key)
+	assert.Equal(t, productCreated{ID: "3", Price: 30}, got[2].Payload)
+
+	assert.NoError(t, ed.Remove("product.*", handler))
+	assert.False(t, ed.Has("product.*", handler))
+}
+
+func TestSubscribe_AlreadyRegistered(t *testing.T) {
+	ed := NewEventDispatcher()
+	fn := func(ctx context.Context, event *Event[int]) error { return nil }
+
+	_, err := Subscribe(ed, "evento1", fn)
+	assert.NoError(t, err)
+
+	// Every subscription is a handler of its own.
+	_, err = Subscribe(ed, "evento1", fn)
+	assert.NoError(t, err)
+
+	_, err = Subscribe(ed, "evento.cre*", fn)
+	assert.ErrorIs(t, err, ErrInvalidEventPattern)
+}
+
+func TestEventRegistry(t *testing.T) {
+	registry := NewEventRegistry()
+
+	assert.NoError(t, RegisterEventType[productCreated](registry, "product.created"))
+	assert.ErrorIs(t, RegisterEventType[int](registry, "product.created"), ErrEventTypeRegistered)
+	assert.ErrorIs(t, RegisterEventType[int](registry, ""), ErrEventNameRequired)
+
+	event := NewEvent("product.created", productCreated{ID: "1", Price: 10})
+	event.Key = "1"
+	event.DateTime = time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	data, err := MarshalEvent(event)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"name":"product.created","key":"1","payload":{"id":"1","price":10},"date_time":"2024-05-01T12:00:00Z"}`, string(data))
+
+	// Event[T] writes the same JSON itself.
+	direct, err := json.Marshal(event)
+	assert.NoError(t, err)
+	assert.JSONEq(t, string(data), string(direct))
+
+	decoded, err := registry.Unmarshal(data)
+	assert.NoError(t, err)
+	assert.Equal(t, event, decoded)
+
+	data, _ = MarshalEvent(&TestEvent{Name: "product.removed", Payload: "x"})
+	_, err = registry.Unmarshal(data)
+	assert.ErrorIs(t, err, ErrEventTypeNotRegistered)
+
+	_, err = registry.Unmarshal([]byte(`{"name":"product.created","payload":"x"}`))
+	assert.ErrorIs(t, err, ErrEventPayloadType)
+
+	_, err = registry.Unmarshal([]byte(`{"payload":{}}`))
+	assert.ErrorIs(t, err, ErrEventNameRequired)
+}
@@ -0,0 +1,88 @@
+package jsonpatch
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMergePatch(t *testing.T) {
+	doc := `{"name":"Notebook","price":{"amount":"10.00","currency":"BRL"},"stock":3}`
+
+	out, err := MergePatch([]byte(doc), []byte(`{"price":{"amount":"12.50"},"stock":null,"sku":"NB-1"}`))
+
+	assert.Nil(t, err)
+	assert.JSONEq(t, `{"name":"Notebook","price":{"amount":"12.50","currency":"BRL"},"sku":"NB-1"}`, string(out))
+}
+
+func TestMergePatchReplacesNonObjects(t *testing.T) {
+	out, err := MergePatch([]byte(`{"a":["x","y"],"b":{"c":1}}`), []byte(`{"a":["z"],"b":"flat"}`))
+
+	assert.Nil(t, err)
+	assert.JSONEq(t, `{"a":["z"],"b":"flat"}`, string(out))
+}
+
+func TestMergePatchInvalidJSON(t *testing.T) {
+	_, err := MergePatch([]byte(`{}`), []byte(`{`))
+
+	assert.NotNil(t, err)
+}
+
+func TestApply(t *testing.T) {
+	doc := `{"name":"Notebook","tags":["a","c"],"price":{"amount":"10.00","currency":"BRL"}}`
+	patch := `[
+		{"op":"test","path":"/name","value":"Notebook"},
+		{"op":"replace","path":"/price/amount","value":"12.50"},
+		{"op":"add","path":"/tags/1","value":"b"},
+		{"op":"add","path":"/tags/-","value":"d"},
+		{"op":"copy","from":"/name","path":"/title"},
+		{"op":"move","from":"/title","path":"/label"},
+		{"op":"remove","path":"/tags/0"}
+	]`
+
+	out, err := Apply([]byte(doc), []byte(patch))
+
+	assert.Nil(t, err)
+	assert.JSONEq(t, `{"name":"Notebook","label":"Notebook","tags":["b","c","d"],"price":{"amount":"12.50","currency":"BRL"}}`, string(out))
+}
+
+func TestApplyEscapedPointer(t *testing.T) {
+	out, err := Apply([]byte(`{"a/b":1,"m~n":2}`), []byte(`[{"op":"remove","path":"/a~1b"},{"op":"replace","path":"/m~0n","value":3}]`))
+
+	assert.Nil(t, err)
+	assert.JSONEq(t, `{"m~n":3}`, string(out))
+}
+
+func TestApplyErrors(t *testing.T) {
+	doc := []byte(`{"name":"Notebook","tags":["a"]}`)
+
+	cases := map[string]struct {
+		patch string
+		err   error
+	}{
+		"failed test":        {`[{"op":"test","path":"/name","value":"Phone"}]`, ErrTestFailed},
+		"missing member":     {`[{"op":"remove","path":"/sku"}]`, ErrPathNotFound},
+		"replace missing":    {`[{"op":"replace","path":"/sku","value":"x"}]`, ErrPathNotFound},
+		"index out of range": {`[{"op":"add","path":"/tags/5","value":"x"}]`, ErrPathNotFound},
+		"invalid pointer":    {`[{"op":"remove","path":"name"}]`, ErrInvalidPointer},
+		"unknown op":         {`[{"op":"merge","path":"/name"}]`, ErrInvalidOperation},
+		"missing value":      {`[{"op":"add","path":"/sku"}]`, ErrInvalidOperation},
+		"move into child":    {`[{"op":"move","from":"/tags","path":"/tags/0"}]`, ErrInvalidOperation},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			_, err := Apply(doc, []byte(c.patch))
+			assert.ErrorIs(t, err, c.err)
+		})
+	}
+}
+
+func TestApplyIsAtomic(t *testing.T) {
+	doc := []byte(`{"name":"Notebook"}`)
+
+	_, err := Apply(doc, []byte(`[{"op":"replace","path":"/name","value":"Phone"},{"op":"test","path":"/name","value":"Notebook"}]`))
+
+	assert.ErrorIs(t, err, ErrTestFailed)
+	assert.JSONEq(t, `{"name":"Notebook"}`, string(doc))
+}
@@ -0,0 +1,43 @@
+package jsonpatch
+
+import "encoding/json"
+
+// MergePatch applies an RFC 7396 JSON Merge Patch to doc. Members set to
+// null in the patch are removed from the document, absent members are left
+// untouched and any non object value replaces the target as a whole.
+func MergePatch(doc []byte, patch []byte) ([]byte, error) {
+	var target, p any
+
+	if err := json.Unmarshal(doc, &target); err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(patch, &p); err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(mergeValue(target, p))
+}
+
+func mergeValue(target any, patch any) any {
+	p, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+
+	t, ok := target.(map[string]any)
+	if !ok {
+		t = map[string]any{}
+	}
+
+	for k, v := range p {
+		if v == nil {
+			delete(t, k)
+			continue
+		}
+
+		t[k] = mergeValue(t[k], v)
+	}
+
+	return t
+}
@@ -0,0 +1,245 @@
+package jsonpatch
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+var (
+	ErrInvalidOperation = errors.New("invalid patch operation")
+	ErrTestFailed       = errors.New("test operation failed")
+)
+
+// Operation is a single RFC 6902 JSON Patch operation.
+type Operation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	From  string          `json:"from,omitempty"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+// Apply applies an RFC 6902 JSON Patch, a JSON array of operations, to doc.
+// Operations run in order and the patch is atomic: any failure leaves doc
+// unchanged and is returned with the index of the failing operation.
+func Apply(doc []byte, patch []byte) ([]byte, error) {
+	var ops []Operation
+
+	if err := json.Unmarshal(patch, &ops); err != nil {
+		return nil, err
+	}
+
+	var target any
+
+	if err := json.Unmarshal(doc, &target); err != nil {
+		return nil, err
+	}
+
+	for i, op := range ops {
+		var err error
+
+		if target, err = op.apply(target); err != nil {
+			return nil, fmt.Errorf("operation %d (%s %s): %w", i, op.Op, op.Path, err)
+		}
+	}
+
+	return json.Marshal(target)
+}
+
+func (o Operation) apply(doc any) (any, error) {
+	path, err := parsePointer(o.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch o.Op {
+	case "add", "replace", "test":
+		var value any
+
+		if len(o.Value) == 0 {
+			return nil, ErrInvalidOperation
+		}
+
+		if err := json.Unmarshal(o.Value, &value); err != nil {
+			return nil, err
+		}
+
+		switch o.Op {
+		case "add":
+			return add(doc, path, value)
+		case "replace":
+			if doc, err = remove(doc, path); err != nil {
+				return nil, err
+			}
+			return add(doc, path, value)
+		}
+
+		current, err := path.get(doc)
+		if err != nil {
+			return nil, err
+		}
+
+		if !reflect.DeepEqual(current, value) {
+			return nil, ErrTestFailed
+		}
+
+		return doc, nil
+	case "remove":
+		return remove(doc, path)
+	case "move", "copy":
+		from, err := parsePointer(o.From)
+		if err != nil {
+			return nil, err
+		}
+
+		value, err := from.get(doc)
+		if err != nil {
+			return nil, err
+		}
+
+		if o.Op == "move" {
+			if isPrefix(from, path) && len(from) < len(path) {
+				return nil, ErrInvalidOperation
+			}
+
+			if doc, err = remove(doc, from); err != nil {
+				return nil, err
+			}
+		} else {
+			value = deepCopy(value)
+		}
+
+		return add(doc, path, value)
+	}
+
+	return nil, ErrInvalidOperation
+}
+
+func add(doc any, path pointer, value any) (any, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+
+	parentPath, key := path.parent()
+
+	parent, err := parentPath.get(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	switch node := parent.(type) {
+	case map[string]any:
+		node[key] = value
+		return doc, nil
+	case []any:
+		i := len(node)
+		if key != "-" {
+			if i, err = arrayIndex(key, len(node)); err != nil {
+				return nil, err
+			}
+		}
+
+		node = append(node, nil)
+		copy(node[i+1:], node[i:])
+		node[i] = value
+
+		return replaceAt(doc, parentPath, node)
+	}
+
+	return nil, ErrPathNotFound
+}
+
+func remove(doc any, path pointer) (any, error) {
+	if len(path) == 0 {
+		return nil, nil
+	}
+
+	parentPath, key := path.parent()
+
+	parent, err := parentPath.get(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	switch node := parent.(type) {
+	case map[string]any:
+		if _, ok := node[key]; !ok {
+			return nil, ErrPathNotFound
+		}
+		delete(node, key)
+		return doc, nil
+	case []any:
+		i, err := arrayIndex(key, len(node)-1)
+		if err != nil {
+			return nil, err
+		}
+
+		node = append(node[:i], node[i+1:]...)
+
+		return replaceAt(doc, parentPath, node)
+	}
+
+	return nil, ErrPathNotFound
+}
+
+// replaceAt stores value at path, needed when an array grows or shrinks and
+// its parent must point to the new slice.
+func replaceAt(doc any, path pointer, value any) (any, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+
+	parentPath, key := path.parent()
+
+	parent, err := parentPath.get(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	switch node := parent.(type) {
+	case map[string]any:
+		node[key] = value
+	case []any:
+		i, err := arrayIndex(key, len(node)-1)
+		if err != nil {
+			return nil, err
+		}
+		node[i] = value
+	}
+
+	return doc, nil
+}
+
+func isPrefix(prefix, path pointer) bool {
+	if len(prefix) > len(path) {
+		return false
+	}
+
+	for i := range prefix {
+		if prefix[i] != path[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+func deepCopy(v any) any {
+	switch node := v.(type) {
+	case map[string]any:
+		c := make(map[string]any, len(node))
+		for k, v := range node {
+			c[k] = deepCopy(v)
+		}
+		return c
+	case []any:
+		c := make([]any, len(node))
+		for i, v := range node {
+			c[i] = deepCopy(v)
+		}
+		return c
+	}
+
+	return v
+}
@@ -0,0 +1,80 @@
+package jsonpatch
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrInvalidPointer = errors.New("invalid json pointer")
+	ErrPathNotFound   = errors.New("path not found")
+)
+
+// pointer is a parsed RFC 6901 JSON Pointer.
+type pointer []string
+
+func parsePointer(s string) (pointer, error) {
+	if s == "" {
+		return pointer{}, nil
+	}
+
+	if !strings.HasPrefix(s, "/") {
+		return nil, ErrInvalidPointer
+	}
+
+	tokens := strings.Split(s[1:], "/")
+	for i, t := range tokens {
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(t, "~1", "/"), "~0", "~")
+	}
+
+	return tokens, nil
+}
+
+func (p pointer) parent() (pointer, string) {
+	return p[:len(p)-1], p[len(p)-1]
+}
+
+// get returns the value p points to inside doc.
+func (p pointer) get(doc any) (any, error) {
+	current := doc
+
+	for _, token := range p {
+		switch node := current.(type) {
+		case map[string]any:
+			v, ok := node[token]
+			if !ok {
+				return nil, ErrPathNotFound
+			}
+			current = v
+		case []any:
+			i, err := arrayIndex(token, len(node)-1)
+			if err != nil {
+				return nil, err
+			}
+			current = node[i]
+		default:
+			return nil, ErrPathNotFound
+		}
+	}
+
+	return current, nil
+}
+
+// arrayIndex parses an array index token, accepting values up to max.
+func arrayIndex(token string, max int) (int, error) {
+	if token == "" || (len(token) > 1 && token[0] == '0') {
+		return 0, ErrInvalidPointer
+	}
+
+	i, err := strconv.Atoi(token)
+	if err != nil {
+		return 0, ErrInvalidPointer
+	}
+
+	if i < 0 || i > max {
+		return 0, ErrPathNotFound
+	}
+
+	return i, nil
+}
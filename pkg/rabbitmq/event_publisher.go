@@ -0,0 +1,52 @@
+package rabbitmq
+
+import (
+	"context"
+	"strings"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+	"github.com/rgoncalvesrr/fullcycle-clean-arch/pkg/events"
+)
+
+// EventPublisher is an event handler that publishes events to an exchange,
+// as the JSON written by events.MarshalEvent, routed by their name. Failed
+// publishes are returned, for a retry policy to deal with.
+type EventPublisher struct {
+	Conn     *Connection
+	Exchange string
+}
+
+func NewEventPublisher(conn *Connection, exchange string) *EventPublisher {
+	return &EventPublisher{Conn: conn, Exchange: exchange}
+}
+
+// DeclareExchange is the topology of the publisher: a durable topic
+// exchange, so consumers bind to event patterns such as "order.*". The
+// predeclared amq.* exchanges can't be declared and are left alone.
+func DeclareExchange(name string) Topology {
+	return func(ch AMQPChannel) error {
+		if strings.HasPrefix(name, "amq.") {
+			return nil
+		}
+
+		return ch.ExchangeDeclare(name, amqp.ExchangeTopic, true, false, false, false, nil)
+	}
+}
+
+func (p *EventPublisher) Handle(ctx context.Context, event events.IEvent) error {
+	body, err := events.MarshalEvent(event)
+	if err != nil {
+		return err
+	}
+
+	return p.Conn.Publish(ctx, p.Exchange, event.GetName(), amqp.Publishing{
+		ContentType:  "application/json",
+		DeliveryMode: amqp.Persistent,
+		Timestamp:    event.GetDateTime(),
+		Body:         body,
+	})
+}
+
+func (p *EventPublisher) HandlerName() string {
+	return "rabbitmq:" + p.Exchange
+}
@@ -8,6 +8,11 @@ import (
 	"github.com/99designs/gqlgen/graphql/handler"
 	"github.com/99designs/gqlgen/graphql/playground"
 	"github.com/rgoncalvesrr/fullcycle-clean-arch/graph"
+	"github.com/rgoncalvesrr/fullcycle-clean-arch/internal/entity"
+	"github.com/rgoncalvesrr/fullcycle-clean-arch/internal/infra/database"
+	"github.com/rgoncalvesrr/fullcycle-clean-arch/pkg/events"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
 )
 
 const defaultPort = "8080"
@@ -18,7 +23,29 @@ func main() {
 		port = defaultPort
 	}
 
-	srv := handler.NewDefaultServer(graph.NewExecutableSchema(graph.Config{Resolvers: &graph.Resolver{}}))
+	db, err := gorm.Open(sqlite.Open("teste.db"), &gorm.Config{})
+	if err != nil {
+		panic(err)
+	}
+	if err = database.Migrate(db); err != nil {
+		panic(err)
+	}
+
+	categoryGateway := database.NewCategoryGateway(db)
+
+	taxPolicy, err := entity.NewRuleTaxPolicy(nil, entity.RoundHalfUp)
+	if err != nil {
+		panic(err)
+	}
+	taxPolicy.Ancestors = categoryGateway.AncestorIDs
+
+	srv := handler.NewDefaultServer(graph.NewExecutableSchema(graph.Config{Resolvers: &graph.Resolver{
+		ProductGateway:  database.NewProductGateway(db),
+		CategoryGateway: categoryGateway,
+		OrderGateway:    database.NewOrderGateway(db),
+		TaxPolicy:       taxPolicy,
+		EventDispatcher: events.NewRecordingDispatcher(events.NewEventDispatcher(), database.NewEventStoreGateway(db)),
+	}}))
 
 	http.Handle("/", playground.Handler("GraphQL playground", "/query"))
 	http.Handle("/query", srv)